@@ -52,7 +52,12 @@ func (a *Adapter) processNode(otelTrace *ptrace.Traces, node *span.TreeNode, par
 }
 
 func (a *Adapter) createOrGetScopeSpans(otelTrace *ptrace.Traces, node *span.TreeNode, parentScopeSpans *ptrace.ScopeSpans) (*ptrace.ScopeSpans, error) {
-	if node.IsResourceEntryPoint() {
+	// A span without parent scope is either the entry point of its resource or an orphan delivered on its own,
+	// whose parent is missing or delivered in another batch
+	if node.IsResourceEntryPoint() || parentScopeSpans == nil {
+		if node.Resource() == nil {
+			return nil, fmt.Errorf("missing resource for node '%s'", node.Name())
+		}
 		resourceSpans := otelTrace.ResourceSpans().AppendEmpty()
 		resource := resourceSpans.Resource()
 		resource.Attributes().PutStr(conventions.AttributeServiceName, node.Resource().Name())
@@ -64,10 +69,6 @@ func (a *Adapter) createOrGetScopeSpans(otelTrace *ptrace.Traces, node *span.Tre
 		return &scopeSpans, nil
 	}
 
-	if parentScopeSpans == nil {
-		return nil, fmt.Errorf("missing ScopeSpans for node '%s'", node.Name())
-	}
-
 	return parentScopeSpans, nil
 }
 
@@ -79,6 +80,8 @@ func (a *Adapter) addSpanToScope(scopeSpans ptrace.ScopeSpans, node *span.TreeNo
 	otelSpan.SetKind(toOtelKind(node.Kind()))
 	otelSpan.SetStartTimestamp(pcommon.NewTimestampFromTime(node.StartTime()))
 	otelSpan.SetEndTimestamp(pcommon.NewTimestampFromTime(node.EndTime()))
+	otelSpan.SetFlags(uint32(node.TraceFlags()))
+	otelSpan.TraceState().FromRaw(node.TraceState())
 	setOtelStatusCode(&otelSpan, node.Status())
 
 	for _, event := range node.Events() {
@@ -102,6 +105,8 @@ func (a *Adapter) addSpanToScope(scopeSpans ptrace.ScopeSpans, node *span.TreeNo
 		otelLink := otelSpan.Links().AppendEmpty()
 		otelLink.SetTraceID(pcommon.TraceID(linked.TraceID().Bytes()))
 		otelLink.SetSpanID(pcommon.SpanID(linked.ID().Bytes()))
+		otelLink.SetFlags(uint32(linked.TraceFlags()))
+		otelLink.TraceState().FromRaw(linked.TraceState())
 	}
 }
 
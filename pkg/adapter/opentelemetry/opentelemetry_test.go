@@ -6,6 +6,7 @@ import (
 	"github.com/k4ji/tracesimulator/pkg/blueprint/service/model"
 	"github.com/k4ji/tracesimulator/pkg/model/task"
 	"github.com/k4ji/tracesimulator/pkg/model/task/taskduration"
+	"github.com/k4ji/tracesimulator/pkg/sampling"
 	"github.com/stretchr/testify/assert"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 	conventions "go.opentelemetry.io/collector/semconv/v1.27.0"
@@ -610,6 +611,31 @@ func TestAdapter_Transform(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("trace flags and trace state are kept", func(t *testing.T) {
+		sampler, err := sampling.NewHeadSampler(1)
+		assert.NoError(t, err)
+		sampledTraces, err := simulator.New[[]ptrace.Traces](NewAdapter(), simulator.WithStages(sampler)).Run(&blueprint, now)
+		assert.NoError(t, err)
+		for _, trace := range sampledTraces {
+			rs := trace.ResourceSpans()
+			for i := 0; i < rs.Len(); i++ {
+				scopeSpans := rs.At(i).ScopeSpans()
+				for j := 0; j < scopeSpans.Len(); j++ {
+					spans := scopeSpans.At(j).Spans()
+					for k := 0; k < spans.Len(); k++ {
+						span := spans.At(k)
+						assert.Equal(t, uint32(1), span.Flags(), "Unexpected flags for span: %s", span.Name())
+						assert.Equal(t, "ot=th:0", span.TraceState().AsRaw(), "Unexpected trace state for span: %s", span.Name())
+						for l := 0; l < span.Links().Len(); l++ {
+							assert.Equal(t, uint32(1), span.Links().At(l).Flags())
+							assert.Equal(t, "ot=th:0", span.Links().At(l).TraceState().AsRaw())
+						}
+					}
+				}
+			}
+		}
+	})
 }
 
 func NewAbsoluteDurationDelay(duration time.Duration) task.Delay {
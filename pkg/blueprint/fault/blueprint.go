@@ -0,0 +1,175 @@
+package fault
+
+import (
+	"fmt"
+	"github.com/k4ji/tracesimulator/pkg/blueprint"
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/k4ji/tracesimulator/pkg/model/task/taskduration"
+	"time"
+)
+
+// Blueprint implements `TimedBlueprint` interface
+var _ blueprint.TimedBlueprint = (*Blueprint)(nil)
+
+// Blueprint applies a schedule of faults to the tasks of another blueprint without editing it
+type Blueprint struct {
+	blueprint blueprint.Blueprint
+	faults    []Fault
+	// randomness is a function that returns a random value between 0 and 1
+	randomness func() float64
+}
+
+// NewFaultBlueprint creates a new blueprint applying the faults active at the time of the simulation to the given blueprint
+func NewFaultBlueprint(bp blueprint.Blueprint, faults []Fault, randomness func() float64) (Blueprint, error) {
+	if bp == nil {
+		return Blueprint{}, fmt.Errorf("fault blueprint requires a blueprint to wrap")
+	}
+	for i, f := range faults {
+		if err := f.validate(); err != nil {
+			return Blueprint{}, fmt.Errorf("invalid fault %d: %w", i, err)
+		}
+	}
+	if randomness == nil {
+		return Blueprint{}, fmt.Errorf("fault blueprint requires a randomness function")
+	}
+	return Blueprint{
+		blueprint:  bp,
+		faults:     faults,
+		randomness: randomness,
+	}, nil
+}
+
+// Interpret returns the tasks of the wrapped blueprint without faults, since the active faults depend on the time
+// of the simulation (see InterpretAt)
+func (b *Blueprint) Interpret() ([]*task.TreeNode, error) {
+	return b.blueprint.Interpret()
+}
+
+// InterpretAt applies the faults active at the given time, which the simulator sets to the base end time of the run
+func (b *Blueprint) InterpretAt(now time.Time) ([]*task.TreeNode, error) {
+	var traceRootTaskNodes []*task.TreeNode
+	var err error
+	if timed, ok := b.blueprint.(blueprint.TimedBlueprint); ok {
+		traceRootTaskNodes, err = timed.InterpretAt(now)
+	} else {
+		traceRootTaskNodes, err = b.blueprint.Interpret()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to interpret blueprint: %w", err)
+	}
+
+	active := make([]activeFault, 0, len(b.faults))
+	for _, f := range b.faults {
+		if intensity := f.intensity(now); intensity > 0 {
+			active = append(active, activeFault{Fault: f, probability: f.Rate * intensity})
+		}
+	}
+	if len(active) == 0 {
+		return traceRootTaskNodes, nil
+	}
+
+	result := make([]*task.TreeNode, 0, len(traceRootTaskNodes))
+	for _, root := range traceRootTaskNodes {
+		// A root span cannot be dropped from its trace, so an outage of the root removes the whole trace
+		if b.isOut(root, active) {
+			continue
+		}
+		applied, err := b.apply(root, active, true)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, applied)
+	}
+	return result, nil
+}
+
+// activeFault is a fault with the probability of affecting a span at the current intensity
+type activeFault struct {
+	Fault
+	probability float64
+}
+
+func (b *Blueprint) isOut(root *task.TreeNode, active []activeFault) bool {
+	for _, f := range active {
+		if f.Kind == KindOutage && f.matches(serviceName(root), root.Definition().Name()) {
+			if b.randomness() < f.probability {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// apply returns a copy of the task and its descendants with the conditional definitions of the matching faults appended,
+// leaving the tasks of the wrapped blueprint untouched
+func (b *Blueprint) apply(node *task.TreeNode, active []activeFault, isRoot bool) (*task.TreeNode, error) {
+	definitions := make([]*task.ConditionalDefinition, 0)
+	for _, f := range active {
+		if !f.matches(serviceName(node), node.Definition().Name()) {
+			continue
+		}
+		if f.Kind == KindOutage && isRoot {
+			continue
+		}
+		effect, err := f.effect()
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply fault to task %s: %w", node.Definition().Name(), err)
+		}
+		definitions = append(definitions, task.NewConditionalDefinition(
+			task.NewProbabilisticCondition(f.probability, b.randomness),
+			[]task.Effect{effect},
+		))
+	}
+	definition := node.Definition()
+	if len(definitions) > 0 {
+		definition = definition.WithAppendedConditionalDefinitions(definitions...)
+	}
+	applied := task.NewTreeNode(definition)
+	for _, child := range node.Children() {
+		appliedChild, err := b.apply(child, active, false)
+		if err != nil {
+			return nil, err
+		}
+		if err := applied.AddChild(appliedChild); err != nil {
+			return nil, fmt.Errorf("failed to apply faults to the children of task %s: %w", node.Definition().Name(), err)
+		}
+	}
+	return applied, nil
+}
+
+// serviceName returns the name of the resource of the task, empty for a task without resource which no fault matches
+func serviceName(node *task.TreeNode) string {
+	if node.Definition().Resource() == nil {
+		return ""
+	}
+	return node.Definition().Resource().Name()
+}
+
+func (f activeFault) effect() (task.Effect, error) {
+	switch f.Kind {
+	case KindError:
+		var message *string
+		if f.Message != "" {
+			message = &f.Message
+		}
+		return task.FromMarkAsFailedEffect(task.NewMarkAsFailedEffect(message)), nil
+	case KindLatency:
+		expr, err := taskduration.NewAbsoluteDuration(f.Latency)
+		if err != nil {
+			return task.Effect{}, err
+		}
+		latency, err := task.NewDelay(expr)
+		if err != nil {
+			return task.Effect{}, err
+		}
+		return task.FromAddLatencyEffect(task.NewAddLatencyEffect(*latency)), nil
+	case KindOutage:
+		drop, err := task.NewDropSpanEffect(task.DropModeSubtree)
+		if err != nil {
+			return task.Effect{}, err
+		}
+		return task.FromDropSpanEffect(*drop), nil
+	default:
+		return task.Effect{}, fmt.Errorf("unknown fault kind %s", f.Kind)
+	}
+}
@@ -0,0 +1,341 @@
+package fault
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/blueprint/service"
+	"github.com/k4ji/tracesimulator/pkg/blueprint/service/model"
+	"github.com/k4ji/tracesimulator/pkg/model/span"
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/k4ji/tracesimulator/pkg/model/task/taskduration"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBlueprint_Interpret(t *testing.T) {
+	type testCase struct {
+		name   string
+		fault  Fault
+		now    time.Time
+		random float64
+		// expectedSpans are the names of the emitted spans in depth-first order
+		expectedSpans []string
+		// expectedQueryStatus and expectedQueryDuration are those of the query span, if emitted
+		expectedQueryStatus   span.StatusCode
+		expectedQueryDuration time.Duration
+	}
+
+	start := faultStart()
+	all := []string{"GET /", "query", "connect"}
+	otherService := newQueryFault(KindError)
+	otherService.Service = "backend"
+	rootOutage := newQueryFault(KindOutage)
+	rootOutage.Task = ""
+	testCases := []testCase{
+		{
+			name:                  "fail the tasks during the fault",
+			fault:                 newQueryFault(KindError),
+			now:                   start.Add(30 * time.Minute),
+			random:                0.99,
+			expectedSpans:         all,
+			expectedQueryStatus:   span.StatusCodeError,
+			expectedQueryDuration: 50 * time.Millisecond,
+		},
+		{
+			name:                  "leave the tasks untouched before the fault",
+			fault:                 newQueryFault(KindError),
+			now:                   start.Add(-time.Minute),
+			expectedSpans:         all,
+			expectedQueryStatus:   span.StatusCodeOK,
+			expectedQueryDuration: 50 * time.Millisecond,
+		},
+		{
+			name:                  "fail the tasks below the rate halfway through the ramp in",
+			fault:                 newQueryFault(KindError),
+			now:                   start.Add(5 * time.Minute),
+			random:                0.4,
+			expectedSpans:         all,
+			expectedQueryStatus:   span.StatusCodeError,
+			expectedQueryDuration: 50 * time.Millisecond,
+		},
+		{
+			name:                  "leave the tasks untouched above the rate halfway through the ramp in",
+			fault:                 newQueryFault(KindError),
+			now:                   start.Add(5 * time.Minute),
+			random:                0.6,
+			expectedSpans:         all,
+			expectedQueryStatus:   span.StatusCodeOK,
+			expectedQueryDuration: 50 * time.Millisecond,
+		},
+		{
+			name:                  "fail the tasks below the rate during the ramp out",
+			fault:                 newQueryFault(KindError),
+			now:                   start.Add(58 * time.Minute),
+			random:                0.1,
+			expectedSpans:         all,
+			expectedQueryStatus:   span.StatusCodeError,
+			expectedQueryDuration: 50 * time.Millisecond,
+		},
+		{
+			name:                  "leave the tasks untouched above the rate during the ramp out",
+			fault:                 newQueryFault(KindError),
+			now:                   start.Add(58 * time.Minute),
+			random:                0.3,
+			expectedSpans:         all,
+			expectedQueryStatus:   span.StatusCodeOK,
+			expectedQueryDuration: 50 * time.Millisecond,
+		},
+		{
+			name:                  "leave the tasks untouched after the fault",
+			fault:                 newQueryFault(KindError),
+			now:                   start.Add(time.Hour),
+			expectedSpans:         all,
+			expectedQueryStatus:   span.StatusCodeOK,
+			expectedQueryDuration: 50 * time.Millisecond,
+		},
+		{
+			name:                  "add latency to the tasks",
+			fault:                 newQueryFault(KindLatency),
+			now:                   start.Add(30 * time.Minute),
+			expectedSpans:         all,
+			expectedQueryStatus:   span.StatusCodeOK,
+			expectedQueryDuration: 350 * time.Millisecond,
+		},
+		{
+			name:          "stop emitting the spans of the tasks and their descendants",
+			fault:         newQueryFault(KindOutage),
+			now:           start.Add(30 * time.Minute),
+			expectedSpans: []string{"GET /"},
+		},
+		{
+			name:          "remove the trace when its root is out",
+			fault:         rootOutage,
+			now:           start.Add(30 * time.Minute),
+			expectedSpans: []string{},
+		},
+		{
+			name:                  "leave other services untouched",
+			fault:                 otherService,
+			now:                   start.Add(30 * time.Minute),
+			expectedSpans:         all,
+			expectedQueryStatus:   span.StatusCodeOK,
+			expectedQueryDuration: 50 * time.Millisecond,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			bp, err := NewFaultBlueprint(newServiceBlueprint(), []Fault{tc.fault}, func() float64 { return tc.random })
+			assert.NoError(t, err)
+			roots, err := bp.InterpretAt(tc.now)
+			assert.NoError(t, err)
+
+			names := make([]string, 0)
+			var walk func(s *span.TreeNode)
+			walk = func(s *span.TreeNode) {
+				names = append(names, s.Name())
+				status := s.Status()
+				switch s.Name() {
+				case "query":
+					assert.Equal(t, tc.expectedQueryStatus, status.Code())
+					assert.Equal(t, tc.expectedQueryDuration, s.EndTime().Sub(s.StartTime()))
+				case "GET /":
+					// a failing task does not fail its parent
+					assert.Equal(t, span.StatusCodeOK, status.Code())
+				}
+				for _, child := range s.Children() {
+					walk(child)
+				}
+			}
+			for _, root := range roots {
+				s, err := span.FromTaskTree(root, span.NewTraceID([16]byte{0x01}), tc.now, func() span.ID { return span.NewSpanID([8]byte{0x01}) })
+				assert.NoError(t, err)
+				walk(s)
+			}
+			assert.Equal(t, tc.expectedSpans, names)
+		})
+	}
+
+	t.Run("apply the faults of a nested fault blueprint at the same time", func(t *testing.T) {
+		inner, err := NewFaultBlueprint(newServiceBlueprint(), []Fault{newQueryFault(KindError)}, func() float64 { return 0 })
+		assert.NoError(t, err)
+		bp, err := NewFaultBlueprint(&inner, []Fault{newQueryFault(KindLatency)}, func() float64 { return 0 })
+		assert.NoError(t, err)
+		roots, err := bp.InterpretAt(start.Add(30 * time.Minute))
+		assert.NoError(t, err)
+		root, err := span.FromTaskTree(roots[0], span.NewTraceID([16]byte{0x01}), start, func() span.ID { return span.NewSpanID([8]byte{0x01}) })
+		assert.NoError(t, err)
+		status := root.Children()[0].Status()
+		assert.Equal(t, span.StatusCodeError, status.Code())
+		assert.Equal(t, 350*time.Millisecond, root.Children()[0].EndTime().Sub(root.Children()[0].StartTime()))
+	})
+
+	t.Run("interpret the wrapped blueprint without faults when interpreted without the time", func(t *testing.T) {
+		bp, err := NewFaultBlueprint(newServiceBlueprint(), []Fault{newQueryFault(KindError)}, func() float64 { return 0 })
+		assert.NoError(t, err)
+		roots, err := bp.Interpret()
+		assert.NoError(t, err)
+		assert.Len(t, roots, 1)
+		assert.Empty(t, roots[0].Children()[0].Definition().ConditionalDefinitions())
+	})
+
+	t.Run("leave the tasks of the wrapped blueprint untouched", func(t *testing.T) {
+		wrapped, err := newServiceBlueprint().Interpret()
+		assert.NoError(t, err)
+		bp, err := NewFaultBlueprint(&staticBlueprint{roots: wrapped}, []Fault{newQueryFault(KindError)}, func() float64 { return 0 })
+		assert.NoError(t, err)
+		for i := 0; i < 2; i++ {
+			roots, err := bp.InterpretAt(start.Add(30 * time.Minute))
+			assert.NoError(t, err)
+			assert.Len(t, roots[0].Children()[0].Definition().ConditionalDefinitions(), 1)
+		}
+		assert.Empty(t, wrapped[0].Children()[0].Definition().ConditionalDefinitions())
+	})
+
+	t.Run("leave the tasks without resource untouched", func(t *testing.T) {
+		def, err := task.NewDefinition("query", false, nil, nil, task.KindClient, nil, NewAbsoluteDurationDelay(0), NewAbsoluteDurationDuration(time.Millisecond), nil, nil, nil, nil)
+		assert.NoError(t, err)
+		bp, err := NewFaultBlueprint(&staticBlueprint{roots: []*task.TreeNode{task.NewTreeNode(def)}}, []Fault{newQueryFault(KindOutage)}, func() float64 { return 0 })
+		assert.NoError(t, err)
+		roots, err := bp.InterpretAt(start.Add(30 * time.Minute))
+		assert.NoError(t, err)
+		assert.Len(t, roots, 1)
+		assert.Empty(t, roots[0].Definition().ConditionalDefinitions())
+	})
+}
+
+func TestNewFaultBlueprintError(t *testing.T) {
+	type testCase struct {
+		name          string
+		fault         Fault
+		randomness    func() float64
+		expectedError string
+	}
+
+	start := faultStart()
+	randomness := func() float64 { return 0 }
+	testCases := []testCase{
+		{
+			name:          "return error without service",
+			fault:         Fault{Kind: KindError, Rate: 1, Start: start, End: start.Add(time.Hour)},
+			randomness:    randomness,
+			expectedError: "fault requires a service",
+		},
+		{
+			name:          "return error for an unknown kind",
+			fault:         Fault{Service: "frontend", Kind: "unknown", Rate: 1, Start: start, End: start.Add(time.Hour)},
+			randomness:    randomness,
+			expectedError: "unknown fault kind unknown",
+		},
+		{
+			name:          "return error for a latency fault without latency",
+			fault:         Fault{Service: "frontend", Kind: KindLatency, Rate: 1, Start: start, End: start.Add(time.Hour)},
+			randomness:    randomness,
+			expectedError: "latency of a latency fault must be greater than 0",
+		},
+		{
+			name:          "return error for a rate above 1",
+			fault:         Fault{Service: "frontend", Kind: KindError, Rate: 2, Start: start, End: start.Add(time.Hour)},
+			randomness:    randomness,
+			expectedError: "fault rate must be between 0 and 1",
+		},
+		{
+			name:          "return error for an empty time window",
+			fault:         Fault{Service: "frontend", Kind: KindError, Rate: 1, Start: start, End: start},
+			randomness:    randomness,
+			expectedError: "fault must end after it starts",
+		},
+		{
+			name:          "return error for ramps longer than the time window",
+			fault:         Fault{Service: "frontend", Kind: KindError, Rate: 1, Start: start, End: start.Add(time.Hour), RampIn: time.Hour, RampOut: time.Minute},
+			randomness:    randomness,
+			expectedError: "ramps of a fault must fit within its time window",
+		},
+		{
+			name:          "return error without randomness",
+			fault:         newQueryFault(KindError),
+			expectedError: "fault blueprint requires a randomness function",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewFaultBlueprint(newServiceBlueprint(), []Fault{tc.fault}, tc.randomness)
+			assert.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
+
+// staticBlueprint returns the same task trees on every interpretation
+type staticBlueprint struct {
+	roots []*task.TreeNode
+}
+
+func (s *staticBlueprint) Interpret() ([]*task.TreeNode, error) {
+	return s.roots, nil
+}
+
+// newServiceBlueprint returns a frontend whose root task runs a query, which in turn connects
+func newServiceBlueprint() *service.Blueprint {
+	bp := service.NewServiceBlueprint([]model.Service{
+		{
+			Name: "frontend",
+			Tasks: []model.Task{
+				{
+					Name:     "GET /",
+					Kind:     "server",
+					Delay:    NewAbsoluteDurationDelay(0),
+					Duration: NewAbsoluteDurationDuration(100 * time.Millisecond),
+					Children: []model.Task{
+						{
+							Name:     "query",
+							Kind:     "client",
+							Delay:    NewAbsoluteDurationDelay(10 * time.Millisecond),
+							Duration: NewAbsoluteDurationDuration(50 * time.Millisecond),
+							Children: []model.Task{
+								{
+									Name:     "connect",
+									Kind:     "internal",
+									Delay:    NewAbsoluteDurationDelay(0),
+									Duration: NewAbsoluteDurationDuration(5 * time.Millisecond),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	return &bp
+}
+
+// newQueryFault returns a fault of the query task lasting an hour from faultStart, ramping in and out over 10 minutes
+func newQueryFault(kind Kind) Fault {
+	start := faultStart()
+	return Fault{
+		Service: "frontend",
+		Task:    "query",
+		Kind:    kind,
+		Rate:    1,
+		Latency: 300 * time.Millisecond,
+		Start:   start,
+		End:     start.Add(time.Hour),
+		RampIn:  10 * time.Minute,
+		RampOut: 10 * time.Minute,
+	}
+}
+
+func faultStart() time.Time {
+	return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func NewAbsoluteDurationDelay(duration time.Duration) task.Delay {
+	e, _ := taskduration.NewAbsoluteDuration(duration)
+	d, _ := task.NewDelay(e)
+	return *d
+}
+
+func NewAbsoluteDurationDuration(duration time.Duration) task.Duration {
+	e, _ := taskduration.NewAbsoluteDuration(duration)
+	d, _ := task.NewDuration(e)
+	return *d
+}
@@ -0,0 +1,85 @@
+package fault
+
+import (
+	"fmt"
+	"time"
+)
+
+// Kind defines what happens to the spans affected by a fault
+type Kind string
+
+const (
+	// KindError marks the affected spans as failed
+	KindError Kind = "error"
+	// KindLatency extends the affected spans by additional latency
+	KindLatency Kind = "latency"
+	// KindOutage stops emitting the affected spans together with their descendants, a root span takes its whole trace with it
+	KindOutage Kind = "outage"
+)
+
+// Fault is an incident affecting the tasks of a service during a time window.
+// The intensity of the fault ramps up linearly from Start over RampIn, and ramps down over RampOut until End.
+// At full intensity, each matching span is affected with the probability Rate.
+type Fault struct {
+	// Service is the name of the resource of the affected tasks
+	Service string
+	// Task is the name of the affected tasks, all tasks of the service when empty
+	Task string
+	Kind Kind
+	// Rate is the probability of a matching span to be affected at full intensity
+	Rate float64
+	// Latency is added to the affected spans of a latency fault
+	Latency time.Duration
+	// Message is the status message of the affected spans of an error fault
+	Message string
+	// Start and End bound the time window of the fault
+	Start time.Time
+	End   time.Time
+	// RampIn and RampOut are the durations over which the fault reaches and leaves its full intensity
+	RampIn  time.Duration
+	RampOut time.Duration
+}
+
+func (f Fault) validate() error {
+	if f.Service == "" {
+		return fmt.Errorf("fault requires a service")
+	}
+	switch f.Kind {
+	case KindError, KindOutage:
+	case KindLatency:
+		if f.Latency <= 0 {
+			return fmt.Errorf("latency of a latency fault must be greater than 0, got %s", f.Latency)
+		}
+	default:
+		return fmt.Errorf("unknown fault kind %s", f.Kind)
+	}
+	if f.Rate < 0 || f.Rate > 1 {
+		return fmt.Errorf("fault rate must be between 0 and 1, got %f", f.Rate)
+	}
+	if !f.End.After(f.Start) {
+		return fmt.Errorf("fault must end after it starts")
+	}
+	if f.RampIn < 0 || f.RampOut < 0 || f.RampIn+f.RampOut > f.End.Sub(f.Start) {
+		return fmt.Errorf("ramps of a fault must fit within its time window")
+	}
+	return nil
+}
+
+// intensity returns how strongly the fault applies at the given time, between 0 and 1
+func (f Fault) intensity(now time.Time) float64 {
+	if now.Before(f.Start) || !now.Before(f.End) {
+		return 0
+	}
+	if f.RampIn > 0 && now.Sub(f.Start) < f.RampIn {
+		return float64(now.Sub(f.Start)) / float64(f.RampIn)
+	}
+	if f.RampOut > 0 && f.End.Sub(now) < f.RampOut {
+		return float64(f.End.Sub(now)) / float64(f.RampOut)
+	}
+	return 1
+}
+
+// matches reports whether the fault affects the task of the service
+func (f Fault) matches(service string, task string) bool {
+	return f.Service == service && (f.Task == "" || f.Task == task)
+}
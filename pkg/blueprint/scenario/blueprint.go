@@ -0,0 +1,107 @@
+package scenario
+
+import (
+	"fmt"
+	"github.com/k4ji/tracesimulator/pkg/blueprint"
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"time"
+)
+
+// Blueprint implements `TimedBlueprint` interface
+var _ blueprint.TimedBlueprint = (*Blueprint)(nil)
+
+// Scenario represents a blueprint picked with a relative weight, such as "browse" or "checkout"
+type Scenario struct {
+	Name      string
+	Weight    float64
+	Blueprint blueprint.Blueprint
+}
+
+// Blueprint represents a blueprint that mixes multiple scenarios by probability
+type Blueprint struct {
+	scenarios []Scenario
+	// picks is the number of distinct scenarios picked on each interpretation
+	picks int
+	// randomness is a function that returns a random value between 0 and 1
+	randomness func() float64
+}
+
+// NewScenarioBlueprint creates a new scenario blueprint that picks the given number of distinct scenarios on each interpretation
+func NewScenarioBlueprint(scenarios []Scenario, picks int, randomness func() float64) (Blueprint, error) {
+	if len(scenarios) == 0 {
+		return Blueprint{}, fmt.Errorf("at least one scenario is required")
+	}
+	if picks < 1 || picks > len(scenarios) {
+		return Blueprint{}, fmt.Errorf("number of picks must be between 1 and %d, got %d", len(scenarios), picks)
+	}
+	for _, s := range scenarios {
+		if s.Weight <= 0 {
+			return Blueprint{}, fmt.Errorf("weight of scenario %s must be greater than 0, got %f", s.Name, s.Weight)
+		}
+		if s.Blueprint == nil {
+			return Blueprint{}, fmt.Errorf("scenario %s requires a blueprint", s.Name)
+		}
+	}
+	if randomness == nil {
+		return Blueprint{}, fmt.Errorf("scenario blueprint requires a randomness function")
+	}
+	return Blueprint{
+		scenarios:  scenarios,
+		picks:      picks,
+		randomness: randomness,
+	}, nil
+}
+
+// Interpret interprets the picked scenarios
+func (b *Blueprint) Interpret() ([]*task.TreeNode, error) {
+	return b.interpret(func(bp blueprint.Blueprint) ([]*task.TreeNode, error) {
+		return bp.Interpret()
+	})
+}
+
+// InterpretAt interprets the picked scenarios as of the given time, passed to those which are TimedBlueprint
+func (b *Blueprint) InterpretAt(now time.Time) ([]*task.TreeNode, error) {
+	return b.interpret(func(bp blueprint.Blueprint) ([]*task.TreeNode, error) {
+		if timed, ok := bp.(blueprint.TimedBlueprint); ok {
+			return timed.InterpretAt(now)
+		}
+		return bp.Interpret()
+	})
+}
+
+func (b *Blueprint) interpret(interpretScenario func(bp blueprint.Blueprint) ([]*task.TreeNode, error)) ([]*task.TreeNode, error) {
+	// Scenarios are picked without replacement since interpreting the same blueprint twice
+	// in one simulation would produce duplicate ExternalIDs
+	remaining := make([]Scenario, len(b.scenarios))
+	copy(remaining, b.scenarios)
+
+	traceRootTaskNodes := make([]*task.TreeNode, 0)
+	for i := 0; i < b.picks; i++ {
+		index := b.pick(remaining)
+		picked := remaining[index]
+		remaining = append(remaining[:index], remaining[index+1:]...)
+
+		rootTaskNodes, err := interpretScenario(picked.Blueprint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to interpret scenario %s: %w", picked.Name, err)
+		}
+		traceRootTaskNodes = append(traceRootTaskNodes, rootTaskNodes...)
+	}
+	return traceRootTaskNodes, nil
+}
+
+// pick returns the index of a scenario chosen according to the weights
+func (b *Blueprint) pick(scenarios []Scenario) int {
+	total := 0.0
+	for _, s := range scenarios {
+		total += s.Weight
+	}
+	r := b.randomness() * total
+	for i, s := range scenarios {
+		if r < s.Weight {
+			return i
+		}
+		r -= s.Weight
+	}
+	return len(scenarios) - 1
+}
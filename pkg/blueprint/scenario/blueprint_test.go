@@ -0,0 +1,150 @@
+package scenario
+
+import (
+	"fmt"
+	"github.com/k4ji/tracesimulator/pkg/blueprint"
+	"github.com/k4ji/tracesimulator/pkg/blueprint/service"
+	"github.com/k4ji/tracesimulator/pkg/blueprint/service/model"
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/k4ji/tracesimulator/pkg/model/task/taskduration"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type failingBlueprint struct{}
+
+func (f *failingBlueprint) Interpret() ([]*task.TreeNode, error) {
+	return nil, fmt.Errorf("failed")
+}
+
+// timedBlueprint fails unless it is interpreted at a time
+type timedBlueprint struct {
+	failingBlueprint
+}
+
+func (t *timedBlueprint) InterpretAt(now time.Time) ([]*task.TreeNode, error) {
+	def, err := task.NewDefinition(now.Format(time.RFC3339), true, nil, nil, task.KindServer, nil, NewAbsoluteDurationDelay(0), NewAbsoluteDurationDuration(time.Second), nil, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return []*task.TreeNode{task.NewTreeNode(def)}, nil
+}
+
+func TestBlueprint_Interpret(t *testing.T) {
+	type testCase struct {
+		name        string
+		scenarios   []Scenario
+		count       int
+		random      float64
+		expected    []string
+		expectError bool
+	}
+
+	scenarios := newScenarios()
+	testCases := []testCase{
+		{name: "pick the first scenario with the lowest random value", scenarios: scenarios, count: 1, random: 0.0, expected: []string{"GET /products"}},
+		{name: "pick the first scenario up to its weight", scenarios: scenarios, count: 1, random: 0.69, expected: []string{"GET /products"}},
+		{name: "pick the second scenario from its weight", scenarios: scenarios, count: 1, random: 0.7, expected: []string{"POST /checkout"}},
+		{name: "pick the last scenario", scenarios: scenarios, count: 1, random: 0.95, expected: []string{"POST /login"}},
+		{name: "pick multiple distinct scenarios", scenarios: scenarios, count: 3, random: 0.0, expected: []string{"GET /products", "POST /checkout", "POST /login"}},
+		{
+			name:        "return error if the picked scenario fails",
+			scenarios:   []Scenario{{Name: "broken", Weight: 1, Blueprint: &failingBlueprint{}}},
+			count:       1,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			bp, err := NewScenarioBlueprint(tc.scenarios, tc.count, func() float64 { return tc.random })
+			assert.NoError(t, err)
+			roots, err := bp.Interpret()
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			names := make([]string, len(roots))
+			for i, n := range roots {
+				names[i] = n.Definition().Name()
+			}
+			assert.Equal(t, tc.expected, names)
+		})
+	}
+}
+
+func TestBlueprint_InterpretAt(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	scenarios := append(newScenarios(), Scenario{Name: "timed", Weight: 1, Blueprint: &timedBlueprint{}})
+	bp, err := NewScenarioBlueprint(scenarios, len(scenarios), func() float64 { return 0.0 })
+	assert.NoError(t, err)
+	roots, err := bp.InterpretAt(now)
+	assert.NoError(t, err)
+	names := make([]string, len(roots))
+	for i, n := range roots {
+		names[i] = n.Definition().Name()
+	}
+	assert.Equal(t, []string{"GET /products", "POST /checkout", "POST /login", "2025-01-01T00:00:00Z"}, names)
+}
+
+func TestNewScenarioBlueprintError(t *testing.T) {
+	type testCase struct {
+		name       string
+		scenarios  []Scenario
+		count      int
+		randomness func() float64
+	}
+
+	testCases := []testCase{
+		{name: "no scenario", scenarios: []Scenario{}, count: 1, randomness: func() float64 { return 0.0 }},
+		{name: "more picks than scenarios", scenarios: newScenarios(), count: 4, randomness: func() float64 { return 0.0 }},
+		{name: "scenario without weight", scenarios: []Scenario{{Name: "zero", Weight: 0, Blueprint: &failingBlueprint{}}}, count: 1, randomness: func() float64 { return 0.0 }},
+		{name: "no randomness", scenarios: newScenarios(), count: 1, randomness: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewScenarioBlueprint(tc.scenarios, tc.count, tc.randomness)
+			assert.Error(t, err)
+		})
+	}
+}
+
+// newScenarios returns three scenarios of the frontend weighted 7, 2 and 1
+func newScenarios() []Scenario {
+	newServiceBlueprint := func(taskName string) blueprint.Blueprint {
+		bp := service.NewServiceBlueprint([]model.Service{
+			{
+				Name: "frontend",
+				Tasks: []model.Task{
+					{
+						Name:     taskName,
+						Kind:     "server",
+						Delay:    NewAbsoluteDurationDelay(0),
+						Duration: NewAbsoluteDurationDuration(100 * time.Millisecond),
+					},
+				},
+			},
+		})
+		return &bp
+	}
+	return []Scenario{
+		{Name: "browse", Weight: 7, Blueprint: newServiceBlueprint("GET /products")},
+		{Name: "checkout", Weight: 2, Blueprint: newServiceBlueprint("POST /checkout")},
+		{Name: "login-failure", Weight: 1, Blueprint: newServiceBlueprint("POST /login")},
+	}
+}
+
+func NewAbsoluteDurationDelay(duration time.Duration) task.Delay {
+	e, _ := taskduration.NewAbsoluteDuration(duration)
+	d, _ := task.NewDelay(e)
+	return *d
+}
+
+func NewAbsoluteDurationDuration(duration time.Duration) task.Duration {
+	e, _ := taskduration.NewAbsoluteDuration(duration)
+	d, _ := task.NewDuration(e)
+	return *d
+}
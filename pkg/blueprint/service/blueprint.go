@@ -5,30 +5,68 @@ import (
 	"github.com/k4ji/tracesimulator/pkg/blueprint"
 	"github.com/k4ji/tracesimulator/pkg/blueprint/service/model"
 	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"time"
 )
 
-// Blueprint implements `Blueprint` interface
-var _ blueprint.Blueprint = (*Blueprint)(nil)
+// Blueprint implements `TimedBlueprint` interface
+var _ blueprint.TimedBlueprint = (*Blueprint)(nil)
 
 // Blueprint represents a blueprint based on tasks grouped by services
 type Blueprint struct {
-	services []model.Service
+	services  []model.Service
+	fragments *model.FragmentLibrary
+}
+
+// Option configures optional behaviors of a service blueprint
+type Option func(*Blueprint)
+
+// WithFragments makes the fragments of the library available to the includes of the tasks
+func WithFragments(library *model.FragmentLibrary) Option {
+	return func(b *Blueprint) {
+		b.fragments = library
+	}
 }
 
 // NewServiceBlueprint creates a new service blueprint
-func NewServiceBlueprint(services []model.Service) Blueprint {
-	return Blueprint{
+func NewServiceBlueprint(services []model.Service, opts ...Option) Blueprint {
+	b := Blueprint{
 		services: services,
 	}
+	for _, opt := range opts {
+		opt(&b)
+	}
+	return b
 }
 
+// Interpret converts the blueprint as of the start of the rollouts of the services
 func (sb *Blueprint) Interpret() ([]*task.TreeNode, error) {
+	return sb.InterpretAt(time.Time{})
+}
+
+// InterpretAt converts the blueprint as of the given time, which picks the versions of the rollouts of the services
+func (sb *Blueprint) InterpretAt(now time.Time) ([]*task.TreeNode, error) {
 	rootTaskNodes := make([]*task.TreeNode, 0)
 	TasksByExternalID := make(map[task.ExternalID]*task.TreeNode)
 
+	// Replace includes with their fragments
+	services := sb.services
+	if sb.fragments != nil {
+		var err error
+		services, err = sb.fragments.ResolveIncludes(services)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Expand calls into client and server tasks
+	services, err := model.ExpandCalls(services)
+	if err != nil {
+		return nil, err
+	}
+
 	// Convert each service to trees of tasks
-	for _, service := range sb.services {
-		serviceRootTaskNodes, err := service.To()
+	for _, service := range services {
+		serviceRootTaskNodes, err := service.ToAt(now)
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert service %s to task tree: %w", service.Name, err)
 		}
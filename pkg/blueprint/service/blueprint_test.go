@@ -289,6 +289,123 @@ func TestBlueprint_Interpret(t *testing.T) {
 		assert.Contains(t, err.Error(), "duplicate ExternalID detected")
 	})
 
+	t.Run("include fragments with namespaced ExternalIDs", func(t *testing.T) {
+		cacheID, _ := task.NewExternalID("cache")
+		library, _ := model.NewFragmentLibrary(model.Fragment{
+			Name: "cache-lookup",
+			Task: model.Task{
+				Name:       "GET cache",
+				ExternalID: cacheID,
+				Kind:       "client",
+				Delay:      NewAbsoluteDurationDelay(0),
+				Duration:   NewAbsoluteDurationDuration(5 * time.Millisecond),
+			},
+		})
+		services := []model.Service{
+			{
+				Name: "service-a",
+				Tasks: []model.Task{
+					{
+						Name:     "task-a",
+						Delay:    NewAbsoluteDurationDelay(0),
+						Duration: NewAbsoluteDurationDuration(time.Second),
+						Children: []model.Task{
+							{Include: &model.Include{Fragment: "cache-lookup"}},
+							{Include: &model.Include{Fragment: "cache-lookup"}},
+						},
+					},
+				},
+			},
+		}
+
+		withoutLibrary := NewServiceBlueprint(services)
+		_, err := withoutLibrary.Interpret()
+		assert.Error(t, err)
+
+		blueprint := NewServiceBlueprint(services, WithFragments(library))
+		roots, err := blueprint.Interpret()
+		assert.NoError(t, err)
+		children := roots[0].Children()
+		assert.Len(t, children, 2)
+		assert.Equal(t, "cache-lookup-0-cache", children[0].Definition().ExternalID().Value())
+		assert.Equal(t, "cache-lookup-1-cache", children[1].Definition().ExternalID().Value())
+	})
+
+	t.Run("expand calls into client and server tasks across services", func(t *testing.T) {
+		services := []model.Service{
+			{
+				Name: "frontend",
+				Tasks: []model.Task{
+					{
+						Name:     "GET /checkout",
+						Kind:     "server",
+						Delay:    NewAbsoluteDurationDelay(0),
+						Duration: NewAbsoluteDurationDuration(time.Second),
+						Children: []model.Task{
+							{
+								Delay: NewAbsoluteDurationDelay(0),
+								Call:  &model.Call{Service: "cart", Endpoint: "GetCart", Latency: time.Millisecond},
+							},
+						},
+					},
+				},
+			},
+			{
+				Name: "cart",
+				Endpoints: []model.Task{
+					{
+						Name:     "GetCart",
+						Duration: NewAbsoluteDurationDuration(20 * time.Millisecond),
+					},
+				},
+			},
+		}
+		blueprint := NewServiceBlueprint(services)
+
+		roots, err := blueprint.Interpret()
+		assert.NoError(t, err)
+		assert.Len(t, roots, 1)
+		client := roots[0].Children()[0]
+		assert.Equal(t, task.KindClient, client.Definition().Kind())
+		assert.Equal(t, "frontend", client.Definition().Resource().Name())
+		server := client.Children()[0]
+		assert.Equal(t, task.KindServer, server.Definition().Kind())
+		assert.Equal(t, "cart", server.Definition().Resource().Name())
+	})
+
+	t.Run("pick the versions of the rollouts at the time the blueprint is interpreted at", func(t *testing.T) {
+		start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		services := []model.Service{
+			{
+				Name: "checkout",
+				Tasks: []model.Task{
+					{
+						Name:     "GET /cart",
+						Kind:     "server",
+						Delay:    NewAbsoluteDurationDelay(0),
+						Duration: NewAbsoluteDurationDuration(time.Second),
+					},
+				},
+				Rollout: &model.Rollout{
+					Versions: []model.Version{
+						{Name: "1.0.0", Ramp: []model.WeightPoint{{After: 0, Weight: 1}, {After: time.Minute, Weight: 0}}},
+						{Name: "1.1.0", Ramp: []model.WeightPoint{{After: 0, Weight: 0}, {After: time.Minute, Weight: 1}}},
+					},
+					Start:      start,
+					Randomness: func() float64 { return 0.5 },
+				},
+			},
+		}
+		blueprint := NewServiceBlueprint(services)
+
+		roots, err := blueprint.Interpret()
+		assert.NoError(t, err)
+		assert.Equal(t, "1.0.0", roots[0].Definition().Resource().Attributes()["service.version"])
+		roots, err = blueprint.InterpretAt(start.Add(time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, "1.1.0", roots[0].Definition().Resource().Attributes()["service.version"])
+	})
+
 	t.Run("return error if cyclic dependencies are detected", func(t *testing.T) {
 		taskAID, _ := task.NewExternalID("task-a")
 		taskBID, _ := task.NewExternalID("task-b")
@@ -0,0 +1,218 @@
+package grpc
+
+import (
+	"fmt"
+	"github.com/k4ji/tracesimulator/pkg/blueprint/service"
+	"github.com/k4ji/tracesimulator/pkg/blueprint/service/internal/outcome"
+	"github.com/k4ji/tracesimulator/pkg/blueprint/service/model"
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/k4ji/tracesimulator/pkg/model/task/taskduration"
+	conventions "go.opentelemetry.io/collector/semconv/v1.27.0"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// DefaultDuration is the duration of server spans when the options do not specify one
+const DefaultDuration = 50 * time.Millisecond
+
+// Failure is a status code returned by the server with a probability
+type Failure struct {
+	Code        StatusCode
+	Probability float64
+	// Message is the status message of the failed spans, it defaults to the name of the code
+	Message string
+}
+
+// Options configures the blueprint generated from gRPC service definitions
+type Options struct {
+	// Client is the name of the service calling every RPC method
+	Client         string
+	ClientResource map[string]string
+	// ServiceNames maps the full names of gRPC services to the names of the services implementing them,
+	// the full name is used when it is not mapped
+	ServiceNames map[string]string
+	// Resources maps the names of the implementing services to their resource attributes
+	Resources map[string]map[string]string
+	// Duration is the duration of server spans, DefaultDuration if nil
+	Duration *task.Duration
+	// Latency is the network latency added on each side of the call, so that the client span covers the server span
+	Latency time.Duration
+	// Failures are the non-OK status codes returned by servers, which fail both the server and the client span
+	Failures []Failure
+	// Randomness returns a random value between 0 and 1
+	Randomness func() float64
+}
+
+// LoadProto reads a .proto file from the path and generates a blueprint from it
+func LoadProto(path string, options Options) (service.Blueprint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return service.Blueprint{}, fmt.Errorf("failed to read proto file: %w", err)
+	}
+	return FromProto(string(data), options)
+}
+
+// FromProto generates a blueprint from the services declared in the source of a .proto file
+func FromProto(source string, options Options) (service.Blueprint, error) {
+	methods, err := parseProto(source)
+	if err != nil {
+		return service.Blueprint{}, fmt.Errorf("failed to parse proto file: %w", err)
+	}
+	return fromMethods(methods, options)
+}
+
+// FromFileDescriptorSet generates a blueprint from the services of a serialized FileDescriptorSet,
+// such as the output of `protoc --descriptor_set_out`
+func FromFileDescriptorSet(data []byte, options Options) (service.Blueprint, error) {
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return service.Blueprint{}, fmt.Errorf("failed to parse file descriptor set: %w", err)
+	}
+	methods := make([]method, 0)
+	for _, file := range set.GetFile() {
+		for _, s := range file.GetService() {
+			for _, m := range s.GetMethod() {
+				methods = append(methods, method{service: qualifiedName(file.GetPackage(), s.GetName()), name: m.GetName()})
+			}
+		}
+	}
+	return fromMethods(methods, options)
+}
+
+// fromMethods generates a client task per method in the client service,
+// and a server task in the implementing service as the child of the client task
+func fromMethods(methods []method, options Options) (service.Blueprint, error) {
+	if options.Client == "" {
+		return service.Blueprint{}, fmt.Errorf("client service name is required")
+	}
+	if options.Latency < 0 {
+		return service.Blueprint{}, fmt.Errorf("latency cannot be negative, got %s", options.Latency)
+	}
+	if options.Randomness == nil {
+		return service.Blueprint{}, fmt.Errorf("randomness function is required")
+	}
+	serverDuration := options.Duration
+	if serverDuration == nil {
+		expr, err := taskduration.NewAbsoluteDuration(DefaultDuration)
+		if err != nil {
+			return service.Blueprint{}, err
+		}
+		if serverDuration, err = task.NewDuration(expr); err != nil {
+			return service.Blueprint{}, err
+		}
+	}
+	serverConditionalDefinitions, clientConditionalDefinitions, err := failureDefinitions(options)
+	if err != nil {
+		return service.Blueprint{}, err
+	}
+	delay, err := zeroDelay()
+	if err != nil {
+		return service.Blueprint{}, err
+	}
+	auto, err := taskduration.NewAutoDuration(options.Latency, options.Latency)
+	if err != nil {
+		return service.Blueprint{}, err
+	}
+	clientDuration, err := task.NewDuration(auto)
+	if err != nil {
+		return service.Blueprint{}, err
+	}
+
+	client := model.Service{Name: options.Client, Resource: options.ClientResource}
+	servers := make([]model.Service, 0)
+	serverIndex := make(map[string]int)
+	for _, m := range methods {
+		serviceName := m.service
+		if mapped, ok := options.ServiceNames[m.service]; ok {
+			serviceName = mapped
+		}
+		externalID, err := task.NewExternalID(sanitizeExternalID(fmt.Sprintf("%s-%s-%s", options.Client, m.service, m.name)))
+		if err != nil {
+			return service.Blueprint{}, err
+		}
+		client.Tasks = append(client.Tasks, model.Task{
+			Name:                  m.service + "/" + m.name,
+			ExternalID:            externalID,
+			Delay:                 *delay,
+			Duration:              *clientDuration,
+			Kind:                  "client",
+			Attributes:            rpcAttributes(m),
+			ConditionalDefinition: clientConditionalDefinitions,
+		})
+
+		i, ok := serverIndex[serviceName]
+		if !ok {
+			i = len(servers)
+			serverIndex[serviceName] = i
+			servers = append(servers, model.Service{Name: serviceName, Resource: options.Resources[serviceName]})
+		}
+		servers[i].Tasks = append(servers[i].Tasks, model.Task{
+			Name:                  m.service + "/" + m.name,
+			Delay:                 *delay,
+			Duration:              *serverDuration,
+			Kind:                  "server",
+			Attributes:            rpcAttributes(m),
+			ChildOf:               externalID,
+			ConditionalDefinition: serverConditionalDefinitions,
+		})
+	}
+	return service.NewServiceBlueprint(append([]model.Service{client}, servers...)), nil
+}
+
+// failureDefinitions returns the conditional definitions drawing the failures on servers,
+// and those propagating the status code of the server to the client
+func failureDefinitions(options Options) ([]*task.ConditionalDefinition, []*task.ConditionalDefinition, error) {
+	outcomes := make([]outcome.Outcome, len(options.Failures))
+	clientDefinitions := make([]*task.ConditionalDefinition, 0, len(options.Failures))
+	for i, f := range options.Failures {
+		if !f.Code.isValid() || f.Code == StatusCodeOK {
+			return nil, nil, fmt.Errorf("invalid failure status code %d", f.Code)
+		}
+		message := f.Message
+		if message == "" {
+			message = f.Code.String()
+		}
+		code := strconv.Itoa(int(f.Code))
+		attributes := map[string]string{conventions.AttributeRPCGRPCStatusCode: code}
+		outcomes[i] = outcome.Outcome{Attributes: attributes, Failed: true, Message: message, Probability: f.Probability}
+		clientDefinitions = append(clientDefinitions, task.NewConditionalDefinition(
+			task.NewAtLeastCondition(1, task.NewChildCondition(task.NewHasAttributeValueCondition(conventions.AttributeRPCGRPCStatusCode, code))),
+			[]task.Effect{
+				task.FromAnnotateEffect(task.NewAnnotateEffect(attributes)),
+				task.FromMarkAsFailedEffect(task.NewMarkAsFailedEffect(&message)),
+			},
+		))
+	}
+	serverDefinitions, err := outcome.ConditionalDefinitions(outcomes, options.Randomness)
+	if err != nil {
+		return nil, nil, err
+	}
+	return serverDefinitions, clientDefinitions, nil
+}
+
+func rpcAttributes(m method) map[string]string {
+	return map[string]string{
+		conventions.AttributeRPCSystem:         "grpc",
+		conventions.AttributeRPCService:        m.service,
+		conventions.AttributeRPCMethod:         m.name,
+		conventions.AttributeRPCGRPCStatusCode: strconv.Itoa(int(StatusCodeOK)),
+	}
+}
+
+var invalidExternalIDCharacters = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+func sanitizeExternalID(id string) string {
+	return invalidExternalIDCharacters.ReplaceAllString(id, "-")
+}
+
+func zeroDelay() (*task.Delay, error) {
+	expr, err := taskduration.NewAbsoluteDuration(0)
+	if err != nil {
+		return nil, err
+	}
+	return task.NewDelay(expr)
+}
@@ -0,0 +1,197 @@
+package grpc
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/model/span"
+	"github.com/stretchr/testify/assert"
+	conventions "go.opentelemetry.io/collector/semconv/v1.27.0"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"testing"
+	"time"
+)
+
+const checkoutProto = `
+syntax = "proto3";
+
+// Checkout service of the shop
+package shop.checkout.v1;
+
+import "google/api/annotations.proto";
+
+option go_package = "example.com/shop/checkout";
+
+/* The request of PlaceOrder,
+   which refers to a service by name */
+message PlaceOrderRequest {
+  string service = 1;
+  message Item { string id = 1; }
+  repeated Item items = 2;
+}
+
+message PlaceOrderResponse {}
+
+service CheckoutService {
+  option deprecated = false;
+  rpc PlaceOrder(PlaceOrderRequest) returns (PlaceOrderResponse) {
+    option (google.api.http) = { post: "/v1/orders" body: "*" };
+  }
+  rpc WatchOrders (stream PlaceOrderRequest) returns (stream .shop.checkout.v1.PlaceOrderResponse);
+}
+
+service PaymentService {
+  rpc Charge(PlaceOrderRequest) returns (PlaceOrderResponse);
+}
+`
+
+func TestFromProto(t *testing.T) {
+	type testCase struct {
+		name     string
+		failures []Failure
+		// expected are the status of every client and server span
+		expectedStatus     span.StatusCode
+		expectedMessage    *string
+		expectedStatusCode string
+	}
+
+	unavailable := "UNAVAILABLE"
+	testCases := []testCase{
+		{
+			name:               "set the OK status code on successful calls",
+			expectedStatus:     span.StatusCodeOK,
+			expectedStatusCode: "0",
+		},
+		{
+			name:               "propagate the status code of failed servers to clients",
+			failures:           []Failure{{Code: StatusCodeUnavailable, Probability: 1}},
+			expectedStatus:     span.StatusCodeError,
+			expectedMessage:    &unavailable,
+			expectedStatusCode: "14",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spans := toSpans(t, Options{Client: "frontend", Failures: tc.failures, Randomness: always})
+			assert.Len(t, spans, 3)
+			for _, client := range spans {
+				server := client.Children()[0]
+				for _, s := range []*span.TreeNode{client, server} {
+					status := s.Status()
+					assert.Equal(t, tc.expectedStatus, status.Code())
+					assert.Equal(t, tc.expectedMessage, status.Message())
+					assert.Equal(t, tc.expectedStatusCode, s.Attributes()[conventions.AttributeRPCGRPCStatusCode])
+				}
+			}
+		})
+	}
+
+	t.Run("generate client and server span pairs for each method", func(t *testing.T) {
+		spans := toSpans(t, Options{
+			Client:       "frontend",
+			ServiceNames: map[string]string{"shop.checkout.v1.PaymentService": "payment"},
+			Latency:      time.Millisecond,
+			Randomness:   always,
+		})
+		assert.Len(t, spans, 3)
+
+		client := spans[0]
+		assert.Equal(t, "shop.checkout.v1.CheckoutService/PlaceOrder", client.Name())
+		assert.Equal(t, span.KindClient, client.Kind())
+		assert.Equal(t, "frontend", client.Resource().Name())
+		assert.Equal(t, "grpc", client.Attributes()[conventions.AttributeRPCSystem])
+		assert.Equal(t, "shop.checkout.v1.CheckoutService", client.Attributes()[conventions.AttributeRPCService])
+		assert.Equal(t, "PlaceOrder", client.Attributes()[conventions.AttributeRPCMethod])
+		assert.Equal(t, DefaultDuration+2*time.Millisecond, client.EndTime().Sub(client.StartTime()))
+
+		server := client.Children()[0]
+		assert.Equal(t, span.KindServer, server.Kind())
+		assert.Equal(t, "shop.checkout.v1.CheckoutService", server.Resource().Name())
+		assert.Equal(t, "WatchOrders", spans[1].Attributes()[conventions.AttributeRPCMethod])
+		assert.Equal(t, "payment", spans[2].Children()[0].Resource().Name())
+	})
+}
+
+func TestFromProtoError(t *testing.T) {
+	type testCase struct {
+		name          string
+		source        string
+		options       Options
+		expectedError string
+	}
+
+	testCases := []testCase{
+		{
+			name:          "return error with the line of a syntax error",
+			source:        "syntax = \"proto3\";\nservice Broken {\n  rpc Call(Request) (Response);\n}\n",
+			options:       Options{Client: "frontend", Randomness: always},
+			expectedError: `line 3: expected "returns", got "("`,
+		},
+		{
+			name:          "return error without a client",
+			source:        checkoutProto,
+			options:       Options{Randomness: always},
+			expectedError: "client service name is required",
+		},
+		{
+			name:          "return error without randomness",
+			source:        checkoutProto,
+			options:       Options{Client: "frontend"},
+			expectedError: "randomness function is required",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := FromProto(tc.source, tc.options)
+			assert.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestFromFileDescriptorSet(t *testing.T) {
+	set := &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			{
+				Name:    proto.String("greeter.proto"),
+				Package: proto.String("helloworld"),
+				Service: []*descriptorpb.ServiceDescriptorProto{
+					{
+						Name: proto.String("Greeter"),
+						Method: []*descriptorpb.MethodDescriptorProto{
+							{Name: proto.String("SayHello"), InputType: proto.String(".helloworld.HelloRequest"), OutputType: proto.String(".helloworld.HelloReply")},
+						},
+					},
+				},
+			},
+		},
+	}
+	data, err := proto.Marshal(set)
+	assert.NoError(t, err)
+
+	b, err := FromFileDescriptorSet(data, Options{Client: "frontend", Randomness: always})
+	assert.NoError(t, err)
+	roots, err := b.Interpret()
+	assert.NoError(t, err)
+	assert.Len(t, roots, 1)
+	assert.Equal(t, "helloworld.Greeter/SayHello", roots[0].Definition().Name())
+	assert.Equal(t, "helloworld.Greeter", roots[0].Children()[0].Definition().Resource().Name())
+}
+
+// toSpans interprets the checkout proto into one span tree per method
+func toSpans(t *testing.T, options Options) []*span.TreeNode {
+	b, err := FromProto(checkoutProto, options)
+	assert.NoError(t, err)
+	roots, err := b.Interpret()
+	assert.NoError(t, err)
+	spans := make([]*span.TreeNode, len(roots))
+	for i, root := range roots {
+		s, err := span.FromTaskTree(root, span.NewTraceID([16]byte{0x01}), time.Now(), func() span.ID { return span.NewSpanID([8]byte{0x01}) })
+		assert.NoError(t, err)
+		spans[i] = s
+	}
+	return spans
+}
+
+func always() float64 {
+	return 0.0
+}
@@ -0,0 +1,255 @@
+package grpc
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// method is an RPC method of a gRPC service
+type method struct {
+	// service is the full name of the service, including the package
+	service string
+	name    string
+}
+
+type protoToken struct {
+	text string
+	line int
+}
+
+// parseProto extracts the RPC methods of the services declared in a .proto file.
+// Everything other than the package and the service declarations is skipped.
+func parseProto(source string) ([]method, error) {
+	tokens, err := tokenizeProto(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &protoParser{tokens: tokens}
+	methods := make([]method, 0)
+	pkg := ""
+	for !p.done() {
+		t := p.next()
+		switch {
+		case t.text == "package" && p.depth == 0:
+			name, err := p.expectIdent("package name")
+			if err != nil {
+				return nil, err
+			}
+			pkg = name
+		case t.text == "service" && p.depth == 0:
+			name, err := p.expectIdent("service name")
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("{"); err != nil {
+				return nil, err
+			}
+			serviceMethods, err := p.parseServiceBody(qualifiedName(pkg, name))
+			if err != nil {
+				return nil, err
+			}
+			methods = append(methods, serviceMethods...)
+		case t.text == "{":
+			p.depth++
+		case t.text == "}":
+			p.depth--
+		}
+	}
+	return methods, nil
+}
+
+type protoParser struct {
+	tokens []protoToken
+	pos    int
+	// depth is the nesting level of the blocks being skipped
+	depth int
+}
+
+func (p *protoParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *protoParser) next() protoToken {
+	t := p.tokens[p.pos]
+	p.pos++
+	return t
+}
+
+func (p *protoParser) lastLine() int {
+	if len(p.tokens) == 0 {
+		return 1
+	}
+	return p.tokens[len(p.tokens)-1].line
+}
+
+func (p *protoParser) expect(text string) error {
+	if p.done() {
+		return fmt.Errorf("line %d: expected %q, got end of file", p.lastLine(), text)
+	}
+	if t := p.next(); t.text != text {
+		return fmt.Errorf("line %d: expected %q, got %q", t.line, text, t.text)
+	}
+	return nil
+}
+
+func (p *protoParser) expectIdent(what string) (string, error) {
+	if p.done() {
+		return "", fmt.Errorf("line %d: expected %s, got end of file", p.lastLine(), what)
+	}
+	t := p.next()
+	if !isProtoIdent(t.text) {
+		return "", fmt.Errorf("line %d: expected %s, got %q", t.line, what, t.text)
+	}
+	return t.text, nil
+}
+
+// parseServiceBody parses the rpc declarations of a service up to its closing brace
+func (p *protoParser) parseServiceBody(service string) ([]method, error) {
+	methods := make([]method, 0)
+	for {
+		if p.done() {
+			return nil, fmt.Errorf("line %d: service %s is not closed", p.lastLine(), service)
+		}
+		t := p.next()
+		switch t.text {
+		case "}":
+			return methods, nil
+		case "rpc":
+			name, err := p.expectIdent("rpc name")
+			if err != nil {
+				return nil, err
+			}
+			if err := p.skipType(); err != nil {
+				return nil, err
+			}
+			if err := p.expect("returns"); err != nil {
+				return nil, err
+			}
+			if err := p.skipType(); err != nil {
+				return nil, err
+			}
+			if err := p.skipDeclarationEnd(); err != nil {
+				return nil, err
+			}
+			methods = append(methods, method{service: service, name: name})
+		case "option":
+			if err := p.skipDeclarationEnd(); err != nil {
+				return nil, err
+			}
+		case ";":
+		default:
+			return nil, fmt.Errorf("line %d: unexpected %q in service %s", t.line, t.text, service)
+		}
+	}
+}
+
+// skipType skips a parenthesized message type such as (stream HelloRequest)
+func (p *protoParser) skipType() error {
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	if !p.done() && p.tokens[p.pos].text == "stream" {
+		p.next()
+	}
+	if _, err := p.expectIdent("message type"); err != nil {
+		return err
+	}
+	return p.expect(")")
+}
+
+// skipDeclarationEnd skips up to the end of a declaration, either a semicolon or a block of options
+func (p *protoParser) skipDeclarationEnd() error {
+	depth := 0
+	for !p.done() {
+		t := p.next()
+		switch t.text {
+		case ";":
+			if depth == 0 {
+				return nil
+			}
+		case "{":
+			depth++
+		case "}":
+			depth--
+			if depth == 0 {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("line %d: unexpected end of file", p.lastLine())
+}
+
+func isProtoIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if !(unicode.IsLetter(r) || r == '_' || r == '.' || (i > 0 && unicode.IsDigit(r))) {
+			return false
+		}
+	}
+	return true
+}
+
+func tokenizeProto(source string) ([]protoToken, error) {
+	tokens := make([]protoToken, 0)
+	line := 1
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == '\n':
+			line++
+			i++
+		case unicode.IsSpace(r):
+			i++
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			start := line
+			i += 2
+			for i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/') {
+				if runes[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			if i+1 >= len(runes) {
+				return nil, fmt.Errorf("line %d: unterminated comment", start)
+			}
+			i += 2
+		case r == '"' || r == '\'':
+			start := i
+			i++
+			for i < len(runes) && runes[i] != r {
+				if runes[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("line %d: unterminated string", line)
+			}
+			i++
+			tokens = append(tokens, protoToken{text: string(runes[start:i]), line: line})
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, protoToken{text: string(runes[start:i]), line: line})
+		default:
+			tokens = append(tokens, protoToken{text: string(r), line: line})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// qualifiedName joins the package and the name of a service
+func qualifiedName(pkg string, name string) string {
+	return strings.TrimPrefix(pkg+"."+name, ".")
+}
@@ -0,0 +1,58 @@
+package grpc
+
+import "strconv"
+
+// StatusCode is a gRPC status code
+type StatusCode int
+
+const (
+	StatusCodeOK                 StatusCode = 0
+	StatusCodeCanceled           StatusCode = 1
+	StatusCodeUnknown            StatusCode = 2
+	StatusCodeInvalidArgument    StatusCode = 3
+	StatusCodeDeadlineExceeded   StatusCode = 4
+	StatusCodeNotFound           StatusCode = 5
+	StatusCodeAlreadyExists      StatusCode = 6
+	StatusCodePermissionDenied   StatusCode = 7
+	StatusCodeResourceExhausted  StatusCode = 8
+	StatusCodeFailedPrecondition StatusCode = 9
+	StatusCodeAborted            StatusCode = 10
+	StatusCodeOutOfRange         StatusCode = 11
+	StatusCodeUnimplemented      StatusCode = 12
+	StatusCodeInternal           StatusCode = 13
+	StatusCodeUnavailable        StatusCode = 14
+	StatusCodeDataLoss           StatusCode = 15
+	StatusCodeUnauthenticated    StatusCode = 16
+)
+
+var statusCodeNames = map[StatusCode]string{
+	StatusCodeOK:                 "OK",
+	StatusCodeCanceled:           "CANCELLED",
+	StatusCodeUnknown:            "UNKNOWN",
+	StatusCodeInvalidArgument:    "INVALID_ARGUMENT",
+	StatusCodeDeadlineExceeded:   "DEADLINE_EXCEEDED",
+	StatusCodeNotFound:           "NOT_FOUND",
+	StatusCodeAlreadyExists:      "ALREADY_EXISTS",
+	StatusCodePermissionDenied:   "PERMISSION_DENIED",
+	StatusCodeResourceExhausted:  "RESOURCE_EXHAUSTED",
+	StatusCodeFailedPrecondition: "FAILED_PRECONDITION",
+	StatusCodeAborted:            "ABORTED",
+	StatusCodeOutOfRange:         "OUT_OF_RANGE",
+	StatusCodeUnimplemented:      "UNIMPLEMENTED",
+	StatusCodeInternal:           "INTERNAL",
+	StatusCodeUnavailable:        "UNAVAILABLE",
+	StatusCodeDataLoss:           "DATA_LOSS",
+	StatusCodeUnauthenticated:    "UNAUTHENTICATED",
+}
+
+func (c StatusCode) String() string {
+	if name, ok := statusCodeNames[c]; ok {
+		return name
+	}
+	return "CODE(" + strconv.Itoa(int(c)) + ")"
+}
+
+func (c StatusCode) isValid() bool {
+	_, ok := statusCodeNames[c]
+	return ok
+}
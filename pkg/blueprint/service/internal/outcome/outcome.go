@@ -0,0 +1,65 @@
+package outcome
+
+import (
+	"fmt"
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+)
+
+// Outcome is an alternative result of an operation, such as a response status code,
+// applied instead of the default result with a probability
+type Outcome struct {
+	// Attributes overwrite those of the span when the outcome is picked
+	Attributes map[string]string
+	// Failed marks the span as failed with the message when the outcome is picked
+	Failed  bool
+	Message string
+	// Probability is the chance that the outcome is picked
+	Probability float64
+}
+
+// ConditionalDefinitions returns conditional definitions picking at most one of the outcomes with their probabilities.
+//
+// The definitions are applied in order and a later one overwrites the attributes set by an earlier one,
+// so the i-th outcome is applied with the probability q_i = p_i / (1 - sum_{j>i} p_j),
+// which makes it the final outcome with the probability p_i.
+// Outcomes marking the span as failed must come after those that do not, so that the status is never left failed
+// by an outcome that has been overwritten.
+func ConditionalDefinitions(outcomes []Outcome, randomness func() float64) ([]*task.ConditionalDefinition, error) {
+	total := 0.0
+	seenFailed := false
+	for _, o := range outcomes {
+		if o.Probability < 0 {
+			return nil, fmt.Errorf("probability of an outcome cannot be negative, got %f", o.Probability)
+		}
+		if seenFailed && !o.Failed {
+			return nil, fmt.Errorf("outcomes marking the span as failed must come last")
+		}
+		seenFailed = seenFailed || o.Failed
+		total += o.Probability
+	}
+	if total > 1+1e-9 {
+		return nil, fmt.Errorf("probabilities of outcomes sum to more than 1, got %f", total)
+	}
+
+	definitions := make([]*task.ConditionalDefinition, 0, len(outcomes))
+	later := 0.0
+	thresholds := make([]float64, len(outcomes))
+	for i := len(outcomes) - 1; i >= 0; i-- {
+		if remaining := 1 - later; remaining > 0 {
+			thresholds[i] = outcomes[i].Probability / remaining
+		}
+		later += outcomes[i].Probability
+	}
+	for i, o := range outcomes {
+		if o.Probability == 0 {
+			continue
+		}
+		effects := []task.Effect{task.FromAnnotateEffect(task.NewAnnotateEffect(o.Attributes))}
+		if o.Failed {
+			message := o.Message
+			effects = append(effects, task.FromMarkAsFailedEffect(task.NewMarkAsFailedEffect(&message)))
+		}
+		definitions = append(definitions, task.NewConditionalDefinition(task.NewProbabilisticCondition(thresholds[i], randomness), effects))
+	}
+	return definitions, nil
+}
@@ -0,0 +1,84 @@
+package outcome
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/model/span"
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/k4ji/tracesimulator/pkg/model/task/taskduration"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestConditionalDefinitions(t *testing.T) {
+	outcomes := []Outcome{
+		{Attributes: map[string]string{"code": "404"}, Probability: 0.2},
+		{Attributes: map[string]string{"code": "409"}, Probability: 0.1},
+		{Attributes: map[string]string{"code": "500"}, Failed: true, Message: "internal error", Probability: 0.3},
+	}
+
+	t.Run("pick each outcome with its probability", func(t *testing.T) {
+		random := rand.New(rand.NewSource(1))
+		definitions, err := ConditionalDefinitions(outcomes, random.Float64)
+		assert.NoError(t, err)
+
+		delay, _ := taskduration.NewAbsoluteDuration(0)
+		duration, _ := taskduration.NewAbsoluteDuration(time.Millisecond)
+		d, _ := task.NewDelay(delay)
+		dd, _ := task.NewDuration(duration)
+		def, _ := task.NewDefinition("op", true, task.NewResource("service", nil), map[string]string{"code": "200"}, task.KindServer, nil, *d, *dd, nil, []*task.ExternalID{}, []task.Event{}, definitions)
+		node := task.NewTreeNode(def)
+
+		const n = 20000
+		counts := make(map[string]int)
+		failed := 0
+		for i := 0; i < n; i++ {
+			s, err := span.FromTaskTree(node, span.NewTraceID([16]byte{0x01}), time.Now(), func() span.ID { return span.NewSpanID([8]byte{0x01}) })
+			assert.NoError(t, err)
+			counts[s.Attributes()["code"]]++
+			status := s.Status()
+			if status.Code() == span.StatusCodeError {
+				failed++
+			}
+		}
+		assert.InDelta(t, 0.4, float64(counts["200"])/n, 0.02)
+		assert.InDelta(t, 0.2, float64(counts["404"])/n, 0.02)
+		assert.InDelta(t, 0.1, float64(counts["409"])/n, 0.02)
+		assert.InDelta(t, 0.3, float64(counts["500"])/n, 0.02)
+		assert.Equal(t, counts["500"], failed)
+	})
+
+}
+
+func TestConditionalDefinitionsError(t *testing.T) {
+	type testCase struct {
+		name          string
+		outcomes      []Outcome
+		expectedError string
+	}
+
+	testCases := []testCase{
+		{
+			name:          "return error when a probability is negative",
+			outcomes:      []Outcome{{Probability: -0.1}},
+			expectedError: "probability of an outcome cannot be negative",
+		},
+		{
+			name:          "return error when probabilities sum to more than 1",
+			outcomes:      []Outcome{{Probability: 0.6}, {Probability: 0.6}},
+			expectedError: "probabilities of outcomes sum to more than 1",
+		},
+		{
+			name:          "return error when a failed outcome comes before a successful one",
+			outcomes:      []Outcome{{Failed: true, Probability: 0.1}, {Probability: 0.1}},
+			expectedError: "outcomes marking the span as failed must come last",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ConditionalDefinitions(tc.outcomes, rand.Float64)
+			assert.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
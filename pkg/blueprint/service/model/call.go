@@ -0,0 +1,194 @@
+package model
+
+import (
+	"fmt"
+	domainTask "github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/k4ji/tracesimulator/pkg/model/task/taskduration"
+	conventions "go.opentelemetry.io/collector/semconv/v1.27.0"
+	"strings"
+	"time"
+)
+
+// PeerServiceAttributeKey is the attribute of client spans naming the called service
+const PeerServiceAttributeKey = "peer.service"
+
+// maxCallDepth limits nested calls so that endpoints calling each other are reported instead of expanded forever
+const maxCallDepth = 32
+
+// Call describes a call from a task to an endpoint of another service.
+// The calling task becomes a client span, and a copy of the endpoint becomes the server span in the callee as its child.
+// Unless the calling task sets its Duration, the client span covers the server span plus the latency on each side.
+type Call struct {
+	// Service is the name of the called service
+	Service string
+	// Endpoint is the name of the called endpoint of the service
+	Endpoint string
+	// Latency is the network latency between the client span and the server span, on each side
+	Latency time.Duration
+	// Address is the server.address attribute of the client span, it defaults to the name of the called service
+	Address string
+}
+
+// ExpandCalls returns copies of the services in which every call is expanded into a client task in the caller
+// and a server task in the callee, connected by ChildOf
+func ExpandCalls(services []Service) ([]Service, error) {
+	e := &callExpander{
+		services: make([]Service, len(services)),
+		index:    make(map[string]int, len(services)),
+		declared: make(map[domainTask.ExternalID]struct{}),
+	}
+	for i, s := range services {
+		s.Tasks = append([]Task(nil), s.Tasks...)
+		e.services[i] = s
+		e.index[s.Name] = i
+		for _, t := range append(append([]Task(nil), s.Tasks...), s.Endpoints...) {
+			t.definedExternalIDs(e.declared)
+		}
+	}
+	for i := range services {
+		for j := range services[i].Tasks {
+			t, err := e.task(services[i].Tasks[j], 0)
+			if err != nil {
+				return nil, fmt.Errorf("failed to expand calls of service %s: %w", services[i].Name, err)
+			}
+			e.services[i].Tasks[j] = t
+		}
+	}
+	return e.services, nil
+}
+
+type callExpander struct {
+	services []Service
+	index    map[string]int
+	// declared holds the ExternalIDs of the services so that the generated ones do not collide with them
+	declared map[domainTask.ExternalID]struct{}
+	// calls counts the expanded calls to generate unique ExternalIDs
+	calls int
+}
+
+// nextExternalID returns an ExternalID for a calling task without one, skipping those which are declared
+// or which would namespace the ExternalIDs of a server task into declared ones
+func (e *callExpander) nextExternalID() (*domainTask.ExternalID, error) {
+	for {
+		e.calls++
+		candidate := fmt.Sprintf("call-%d", e.calls)
+		taken := false
+		for id := range e.declared {
+			if id.Value() == candidate || strings.HasPrefix(id.Value(), candidate+"-") {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			return domainTask.NewExternalID(candidate)
+		}
+	}
+}
+
+// task expands the calls in the subtree of the task
+func (e *callExpander) task(t Task, depth int) (Task, error) {
+	if t.Children != nil {
+		// repeats are expanded first so that every iteration of a call gets its own server span
+		expanded, err := expandRepeats(t.Children)
+		if err != nil {
+			return Task{}, err
+		}
+		children := make([]Task, len(expanded))
+		for i, child := range expanded {
+			c, err := e.task(child, depth)
+			if err != nil {
+				return Task{}, err
+			}
+			children[i] = c
+		}
+		t.Children = children
+	}
+	if t.Call == nil {
+		return t, nil
+	}
+	if depth >= maxCallDepth {
+		return Task{}, fmt.Errorf("calls are nested more than %d times, endpoints may be calling each other", maxCallDepth)
+	}
+
+	call := t.Call
+	i, ok := e.index[call.Service]
+	if !ok {
+		return Task{}, fmt.Errorf("called service %s not found", call.Service)
+	}
+	var endpoint *Task
+	for _, ep := range e.services[i].Endpoints {
+		if ep.Name == call.Endpoint {
+			endpoint = &ep
+			break
+		}
+	}
+	if endpoint == nil {
+		return Task{}, fmt.Errorf("endpoint %s of service %s not found", call.Endpoint, call.Service)
+	}
+	if call.Latency < 0 {
+		return Task{}, fmt.Errorf("latency of call to %s %s cannot be negative", call.Service, call.Endpoint)
+	}
+
+	// client span in the caller
+	if t.ExternalID == nil {
+		id, err := e.nextExternalID()
+		if err != nil {
+			return Task{}, err
+		}
+		t.ExternalID = id
+	}
+	if t.Name == "" {
+		t.Name = endpoint.Name
+	}
+	t.Kind = "client"
+	if t.Duration.Expression() == nil {
+		auto, err := taskduration.NewAutoDuration(call.Latency, call.Latency)
+		if err != nil {
+			return Task{}, err
+		}
+		d, err := domainTask.NewDuration(auto)
+		if err != nil {
+			return Task{}, err
+		}
+		t.Duration = *d
+	}
+	address := call.Address
+	if address == "" {
+		address = call.Service
+	}
+	attributes := make(map[string]string, len(t.Attributes)+2)
+	for k, v := range t.Attributes {
+		attributes[k] = v
+	}
+	attributes[PeerServiceAttributeKey] = call.Service
+	attributes[conventions.AttributeServerAddress] = address
+	t.Attributes = attributes
+	t.Call = nil
+
+	// server span in the callee, whose ExternalIDs are namespaced by the call
+	server, err := endpoint.withNamespacedExternalIDs(t.ExternalID.Value(), endpoint.definedExternalIDs(map[domainTask.ExternalID]struct{}{}))
+	if err != nil {
+		return Task{}, err
+	}
+	server.ChildOf = t.ExternalID
+	if server.Kind == "" {
+		server.Kind = "server"
+	}
+	if server.Delay.Expression() == nil {
+		zero, err := taskduration.NewAbsoluteDuration(0)
+		if err != nil {
+			return Task{}, err
+		}
+		d, err := domainTask.NewDelay(zero)
+		if err != nil {
+			return Task{}, err
+		}
+		server.Delay = *d
+	}
+	server, err = e.task(server, depth+1)
+	if err != nil {
+		return Task{}, fmt.Errorf("failed to expand call to %s %s: %w", call.Service, call.Endpoint, err)
+	}
+	e.services[i].Tasks = append(e.services[i].Tasks, server)
+	return t, nil
+}
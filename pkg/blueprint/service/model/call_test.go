@@ -0,0 +1,162 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	conventions "go.opentelemetry.io/collector/semconv/v1.27.0"
+	"testing"
+	"time"
+)
+
+func TestExpandCalls(t *testing.T) {
+	type testCase struct {
+		name            string
+		call            Task
+		expectedClients int
+		expectedServers int
+	}
+
+	testCases := []testCase{
+		{
+			name: "expand a call into client and server tasks",
+			call: Task{
+				Delay: NewAbsoluteDurationDelay(10 * time.Millisecond),
+				Call:  &Call{Service: "cart", Endpoint: "GetCart"},
+			},
+			expectedClients: 1,
+			expectedServers: 1,
+		},
+		{
+			name: "expand each iteration of a repeated call",
+			call: Task{
+				Delay:  NewAbsoluteDurationDelay(0),
+				Call:   &Call{Service: "cart", Endpoint: "GetCart"},
+				Repeat: &Repeat{Count: 3},
+			},
+			expectedClients: 3,
+			expectedServers: 3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			services, err := ExpandCalls(newCallingServices(tc.call))
+			assert.NoError(t, err)
+
+			clients := services[0].Tasks[0].Children
+			assert.Len(t, clients, tc.expectedClients)
+			assert.Len(t, services[1].Tasks, tc.expectedServers)
+			for i, client := range clients {
+				assert.Nil(t, client.Call)
+				assert.Equal(t, "client", client.Kind)
+				assert.Equal(t, *client.ExternalID, *services[1].Tasks[i].ChildOf)
+			}
+		})
+	}
+
+	t.Run("set the attributes and the latencies of the expanded call", func(t *testing.T) {
+		services, err := ExpandCalls(newCallingServices(Task{
+			Delay: NewAbsoluteDurationDelay(10 * time.Millisecond),
+			Call:  &Call{Service: "cart", Endpoint: "GetCart", Latency: 2 * time.Millisecond, Address: "cart.internal"},
+		}))
+		assert.NoError(t, err)
+
+		client := services[0].Tasks[0].Children[0]
+		assert.Equal(t, "GetCart", client.Name)
+		assert.Equal(t, "cart", client.Attributes[PeerServiceAttributeKey])
+		assert.Equal(t, "cart.internal", client.Attributes[conventions.AttributeServerAddress])
+		auto, ok := client.Duration.Auto()
+		assert.True(t, ok)
+		assert.Equal(t, 2*time.Millisecond, auto.Before())
+		assert.Equal(t, 2*time.Millisecond, auto.After())
+
+		server := services[1].Tasks[0]
+		assert.Equal(t, "server", server.Kind)
+		assert.Equal(t, client.ExternalID.Value()+"-query", server.Children[0].ExternalID.Value())
+	})
+
+	t.Run("generate ExternalIDs that do not collide with the declared ones", func(t *testing.T) {
+		services := newCallingServices(Task{
+			Delay: NewAbsoluteDurationDelay(0),
+			Call:  &Call{Service: "cart", Endpoint: "GetCart"},
+		})
+		services[0].Tasks[0].ExternalID = newExternalID("call-1")
+		services[1].Endpoints[0].ExternalID = newExternalID("call-2-audit")
+		expanded, err := ExpandCalls(services)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "call-3", expanded[0].Tasks[0].Children[0].ExternalID.Value())
+	})
+}
+
+func TestExpandCallsError(t *testing.T) {
+	type testCase struct {
+		name          string
+		services      []Service
+		expectedError string
+	}
+
+	cyclic := []Service{
+		{Name: "a", Endpoints: []Task{{Name: "ping", Children: []Task{{Call: &Call{Service: "b", Endpoint: "pong"}}}}}},
+		{Name: "b", Endpoints: []Task{{Name: "pong", Children: []Task{{Call: &Call{Service: "a", Endpoint: "ping"}}}}}},
+	}
+	cyclic[0].Tasks = []Task{{Name: "start", Children: []Task{{Call: &Call{Service: "b", Endpoint: "pong"}}}}}
+	testCases := []testCase{
+		{
+			name:          "return error when the service is not found",
+			services:      newCallingServices(Task{Call: &Call{Service: "payment", Endpoint: "Charge"}}),
+			expectedError: "called service payment not found",
+		},
+		{
+			name:          "return error when the endpoint is not found",
+			services:      newCallingServices(Task{Call: &Call{Service: "cart", Endpoint: "DeleteCart"}}),
+			expectedError: "endpoint DeleteCart of service cart not found",
+		},
+		{
+			name:          "return error when the latency is negative",
+			services:      newCallingServices(Task{Call: &Call{Service: "cart", Endpoint: "GetCart", Latency: -time.Millisecond}}),
+			expectedError: "latency of call to cart GetCart cannot be negative",
+		},
+		{
+			name:          "return error when endpoints call each other",
+			services:      cyclic,
+			expectedError: "endpoints may be calling each other",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ExpandCalls(tc.services)
+			assert.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
+
+// newCallingServices returns a frontend making the given call and a cart whose GetCart endpoint runs a query
+func newCallingServices(call Task) []Service {
+	return []Service{
+		{
+			Name: "frontend",
+			Tasks: []Task{{
+				Name:     "GET /checkout",
+				Kind:     "server",
+				Delay:    NewAbsoluteDurationDelay(0),
+				Duration: NewAbsoluteDurationDuration(time.Second),
+				Children: []Task{call},
+			}},
+		},
+		{
+			Name: "cart",
+			Endpoints: []Task{{
+				Name:     "GetCart",
+				Duration: NewAbsoluteDurationDuration(20 * time.Millisecond),
+				Children: []Task{{
+					Name:       "SELECT cart",
+					ExternalID: newExternalID("query"),
+					Kind:       "client",
+					Delay:      NewAbsoluteDurationDelay(0),
+					Duration:   NewAbsoluteDurationDuration(5 * time.Millisecond),
+				}},
+			}},
+		},
+	}
+}
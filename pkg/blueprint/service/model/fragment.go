@@ -0,0 +1,205 @@
+package model
+
+import (
+	"fmt"
+	domainTask "github.com/k4ji/tracesimulator/pkg/model/task"
+	"strings"
+)
+
+// Include replaces a task with a fragment from a FragmentLibrary.
+// The Delay, LayoutGroup and Repeat of the including task, if set, replace those of the fragment.
+type Include struct {
+	// Fragment is the name of the fragment to include
+	Fragment string
+	// Namespace prefixes the ExternalIDs defined in the fragment,
+	// it defaults to the fragment name followed by the index of the inclusion
+	Namespace string
+}
+
+// Fragment is a named task subtree that can be included in many places
+type Fragment struct {
+	Name string
+	Task Task
+}
+
+// FragmentLibrary holds the fragments available to includes
+type FragmentLibrary struct {
+	fragments map[string]Task
+}
+
+// NewFragmentLibrary creates a library from fragments with unique names
+func NewFragmentLibrary(fragments ...Fragment) (*FragmentLibrary, error) {
+	l := &FragmentLibrary{fragments: make(map[string]Task, len(fragments))}
+	for _, f := range fragments {
+		if f.Name == "" {
+			return nil, fmt.Errorf("fragment name cannot be empty")
+		}
+		if _, exists := l.fragments[f.Name]; exists {
+			return nil, fmt.Errorf("duplicate fragment %s", f.Name)
+		}
+		l.fragments[f.Name] = f.Task
+	}
+	return l, nil
+}
+
+// Import adds the fragments of another library, such as one defined in another file
+func (l *FragmentLibrary) Import(other *FragmentLibrary) error {
+	for name, t := range other.fragments {
+		if _, exists := l.fragments[name]; exists {
+			return fmt.Errorf("duplicate fragment %s", name)
+		}
+		l.fragments[name] = t
+	}
+	return nil
+}
+
+// ResolveIncludes returns copies of the services in which every include of their tasks and endpoints is replaced by its fragment
+func (l *FragmentLibrary) ResolveIncludes(services []Service) ([]Service, error) {
+	r := &includeResolver{library: l, inclusions: make(map[string]int)}
+	resolved := make([]Service, len(services))
+	for i, s := range services {
+		tasks, err := r.tasks(s.Tasks)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve includes of service %s: %w", s.Name, err)
+		}
+		endpoints, err := r.tasks(s.Endpoints)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve includes of endpoints of service %s: %w", s.Name, err)
+		}
+		s.Tasks = tasks
+		s.Endpoints = endpoints
+		resolved[i] = s
+	}
+	return resolved, nil
+}
+
+type includeResolver struct {
+	library *FragmentLibrary
+	// inclusions counts the inclusions of each fragment to generate default namespaces
+	inclusions map[string]int
+	// stack holds the fragments being resolved to detect cycles
+	stack []string
+}
+
+func (r *includeResolver) tasks(tasks []Task) ([]Task, error) {
+	if tasks == nil {
+		return nil, nil
+	}
+	resolved := make([]Task, len(tasks))
+	for i, t := range tasks {
+		rt, err := r.task(t)
+		if err != nil {
+			return nil, err
+		}
+		resolved[i] = rt
+	}
+	return resolved, nil
+}
+
+func (r *includeResolver) task(t Task) (Task, error) {
+	if t.Include == nil {
+		children, err := r.tasks(t.Children)
+		if err != nil {
+			return Task{}, err
+		}
+		t.Children = children
+		return t, nil
+	}
+
+	name := t.Include.Fragment
+	for i, f := range r.stack {
+		if f == name {
+			return Task{}, fmt.Errorf("fragment cycle detected: %s", strings.Join(append(r.stack[i:], name), " -> "))
+		}
+	}
+	fragment, ok := r.library.fragments[name]
+	if !ok {
+		return Task{}, fmt.Errorf("fragment %s not found", name)
+	}
+
+	r.stack = append(r.stack, name)
+	body, err := r.task(fragment)
+	r.stack = r.stack[:len(r.stack)-1]
+	if err != nil {
+		return Task{}, fmt.Errorf("failed to include fragment %s: %w", name, err)
+	}
+
+	namespace := t.Include.Namespace
+	if namespace == "" {
+		namespace = fmt.Sprintf("%s-%d", name, r.inclusions[name])
+	}
+	r.inclusions[name]++
+	body, err = body.withNamespacedExternalIDs(namespace, body.definedExternalIDs(map[domainTask.ExternalID]struct{}{}))
+	if err != nil {
+		return Task{}, fmt.Errorf("failed to include fragment %s: %w", name, err)
+	}
+
+	if t.Delay.Expression() != nil {
+		body.Delay = t.Delay
+	}
+	if t.LayoutGroup != "" {
+		body.LayoutGroup = t.LayoutGroup
+	}
+	if t.Repeat != nil {
+		body.Repeat = t.Repeat
+	}
+	return body, nil
+}
+
+// definedExternalIDs collects the ExternalIDs defined in the subtree of the task
+func (t Task) definedExternalIDs(ids map[domainTask.ExternalID]struct{}) map[domainTask.ExternalID]struct{} {
+	if t.ExternalID != nil {
+		ids[*t.ExternalID] = struct{}{}
+	}
+	for _, child := range t.Children {
+		child.definedExternalIDs(ids)
+	}
+	return ids
+}
+
+// withNamespacedExternalIDs returns a copy of the task in which the given ExternalIDs and the references to them are prefixed by the namespace
+func (t Task) withNamespacedExternalIDs(namespace string, ids map[domainTask.ExternalID]struct{}) (Task, error) {
+	return t.withRenamedExternalIDs(ids, func(id string) string {
+		return namespace + "-" + id
+	})
+}
+
+// withRenamedExternalIDs returns a copy of the task in which the given ExternalIDs and the references to them are renamed
+func (t Task) withRenamedExternalIDs(ids map[domainTask.ExternalID]struct{}, rename func(id string) string) (Task, error) {
+	renamed := func(id *domainTask.ExternalID) (*domainTask.ExternalID, error) {
+		if id == nil {
+			return nil, nil
+		}
+		if _, ok := ids[*id]; !ok {
+			return id, nil
+		}
+		return domainTask.NewExternalID(rename(id.Value()))
+	}
+
+	var err error
+	if t.ExternalID, err = renamed(t.ExternalID); err != nil {
+		return Task{}, err
+	}
+	if t.ChildOf, err = renamed(t.ChildOf); err != nil {
+		return Task{}, err
+	}
+	if t.LinkedTo != nil {
+		linkedTo := make([]*domainTask.ExternalID, len(t.LinkedTo))
+		for i, id := range t.LinkedTo {
+			if linkedTo[i], err = renamed(id); err != nil {
+				return Task{}, err
+			}
+		}
+		t.LinkedTo = linkedTo
+	}
+	if t.Children != nil {
+		children := make([]Task, len(t.Children))
+		for i, child := range t.Children {
+			if children[i], err = child.withRenamedExternalIDs(ids, rename); err != nil {
+				return Task{}, err
+			}
+		}
+		t.Children = children
+	}
+	return t, nil
+}
@@ -0,0 +1,222 @@
+package model
+
+import (
+	domainTask "github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestFragmentLibrary_ResolveIncludes(t *testing.T) {
+	type testCase struct {
+		name      string
+		fragments []Fragment
+		imported  []Fragment
+		services  []Service
+		// expectedExternalIDs and expectedLinks are those of the resolved tasks and endpoints in depth-first order
+		expectedExternalIDs []string
+		expectedLinks       []string
+	}
+
+	profile := newIncludingServices()
+	profile[0].Endpoints = []Task{{
+		Name:     "GET /profile",
+		Kind:     "server",
+		Delay:    NewAbsoluteDurationDelay(0),
+		Duration: NewAbsoluteDurationDuration(100 * time.Millisecond),
+		Children: []Task{{Include: &Include{Fragment: "auth-check", Namespace: "profile"}}},
+	}}
+	testCases := []testCase{
+		{
+			name:      "namespace the ExternalIDs of each inclusion",
+			fragments: []Fragment{newAuthCheckFragment()},
+			services: newIncludingServices(
+				&Include{Fragment: "auth-check", Namespace: "login"},
+				&Include{Fragment: "auth-check"},
+			),
+			expectedExternalIDs: []string{"login-auth", "login-token", "auth-check-1-auth", "auth-check-1-token"},
+			expectedLinks:       []string{"login-token", "outside", "auth-check-1-token", "outside"},
+		},
+		{
+			name: "include fragments from imported libraries and nested fragments",
+			fragments: []Fragment{{
+				Name: "checkout",
+				Task: Task{
+					Name:     "checkout",
+					Kind:     "internal",
+					Delay:    NewAbsoluteDurationDelay(0),
+					Duration: NewAbsoluteDurationDuration(100 * time.Millisecond),
+					Children: []Task{{Include: &Include{Fragment: "auth-check", Namespace: "inner"}}},
+				},
+			}},
+			imported:            []Fragment{newAuthCheckFragment()},
+			services:            newIncludingServices(&Include{Fragment: "checkout", Namespace: "outer"}),
+			expectedExternalIDs: []string{"outer-inner-auth", "outer-inner-token"},
+			expectedLinks:       []string{"outer-inner-token", "outside"},
+		},
+		{
+			name:                "resolve includes within endpoints",
+			fragments:           []Fragment{newAuthCheckFragment()},
+			services:            profile,
+			expectedExternalIDs: []string{"profile-auth", "profile-token"},
+			expectedLinks:       []string{"profile-token", "outside"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			library, err := NewFragmentLibrary(tc.fragments...)
+			assert.NoError(t, err)
+			if tc.imported != nil {
+				imported, err := NewFragmentLibrary(tc.imported...)
+				assert.NoError(t, err)
+				assert.NoError(t, library.Import(imported))
+			}
+
+			services, err := library.ResolveIncludes(tc.services)
+			assert.NoError(t, err)
+			externalIDs := make([]string, 0)
+			links := make([]string, 0)
+			var walk func(tasks []Task)
+			walk = func(tasks []Task) {
+				for _, task := range tasks {
+					assert.Nil(t, task.Include)
+					if task.ExternalID != nil {
+						externalIDs = append(externalIDs, task.ExternalID.Value())
+					}
+					for _, link := range task.LinkedTo {
+						links = append(links, link.Value())
+					}
+					walk(task.Children)
+				}
+			}
+			for _, s := range services {
+				walk(s.Tasks)
+				walk(s.Endpoints)
+			}
+			assert.Equal(t, tc.expectedExternalIDs, externalIDs)
+			assert.Equal(t, tc.expectedLinks, links)
+			// the includes of the given services are left unresolved
+			var unresolved func(tasks []Task) bool
+			unresolved = func(tasks []Task) bool {
+				for _, task := range tasks {
+					if task.Include != nil || unresolved(task.Children) {
+						return true
+					}
+				}
+				return false
+			}
+			assert.True(t, unresolved(tc.services[0].Tasks) || unresolved(tc.services[0].Endpoints))
+		})
+	}
+
+	t.Run("replace the delay of the fragment by that of the including task", func(t *testing.T) {
+		fragment := newAuthCheckFragment()
+		library, _ := NewFragmentLibrary(fragment)
+		services, err := library.ResolveIncludes(newIncludingServices(&Include{Fragment: "auth-check"}, &Include{Fragment: "auth-check"}))
+		assert.NoError(t, err)
+
+		included := services[0].Tasks[0].Children[1]
+		assert.Equal(t, "GET /auth", included.Name)
+		delay, _ := included.Delay.Resolve(nil)
+		assert.Equal(t, 50*time.Millisecond, *delay)
+		// the fragment itself is left unchanged
+		assert.Equal(t, "auth", fragment.Task.ExternalID.Value())
+	})
+}
+
+func TestFragmentLibrary_ResolveIncludesError(t *testing.T) {
+	type testCase struct {
+		name          string
+		fragments     []Fragment
+		imported      []Fragment
+		includes      []*Include
+		expectedError string
+	}
+
+	testCases := []testCase{
+		{
+			name:          "return error when importing a duplicate fragment",
+			fragments:     []Fragment{newAuthCheckFragment()},
+			imported:      []Fragment{newAuthCheckFragment()},
+			expectedError: "duplicate fragment auth-check",
+		},
+		{
+			name:          "return error when a fragment is not found",
+			includes:      []*Include{{Fragment: "auth-check"}},
+			expectedError: "fragment auth-check not found",
+		},
+		{
+			name: "return error when fragments include each other",
+			fragments: []Fragment{
+				{Name: "a", Task: Task{Name: "a", Children: []Task{{Include: &Include{Fragment: "b"}}}}},
+				{Name: "b", Task: Task{Name: "b", Children: []Task{{Include: &Include{Fragment: "a"}}}}},
+			},
+			includes:      []*Include{{Fragment: "a"}},
+			expectedError: "fragment cycle detected: a -> b -> a",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			library, err := NewFragmentLibrary(tc.fragments...)
+			assert.NoError(t, err)
+			if tc.imported != nil {
+				imported, _ := NewFragmentLibrary(tc.imported...)
+				err = library.Import(imported)
+			}
+			if err == nil {
+				_, err = library.ResolveIncludes(newIncludingServices(tc.includes...))
+			}
+			assert.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+
+	t.Run("return error when converting an unresolved include", func(t *testing.T) {
+		services := newIncludingServices(&Include{Fragment: "auth-check"})
+		_, err := services[0].To()
+		assert.Error(t, err)
+	})
+}
+
+// newAuthCheckFragment returns a fragment whose task links to its own child and to a task outside of the fragment
+func newAuthCheckFragment() Fragment {
+	return Fragment{
+		Name: "auth-check",
+		Task: Task{
+			Name:       "GET /auth",
+			ExternalID: newExternalID("auth"),
+			Kind:       "client",
+			Delay:      NewAbsoluteDurationDelay(0),
+			Duration:   NewAbsoluteDurationDuration(20 * time.Millisecond),
+			LinkedTo:   []*domainTask.ExternalID{newExternalID("token"), newExternalID("outside")},
+			Children: []Task{
+				{
+					Name:       "token lookup",
+					ExternalID: newExternalID("token"),
+					Kind:       "internal",
+					Delay:      NewAbsoluteDurationDelay(0),
+					Duration:   NewAbsoluteDurationDuration(5 * time.Millisecond),
+				},
+			},
+		},
+	}
+}
+
+// newIncludingServices returns a frontend service whose root task includes the fragments 50ms after each other
+func newIncludingServices(includes ...*Include) []Service {
+	children := make([]Task, len(includes))
+	for i, include := range includes {
+		children[i] = Task{Include: include, Delay: NewAbsoluteDurationDelay(time.Duration(i) * 50 * time.Millisecond)}
+	}
+	return []Service{{
+		Name: "frontend",
+		Tasks: []Task{{
+			Name:     "GET /",
+			Kind:     "server",
+			Delay:    NewAbsoluteDurationDelay(0),
+			Duration: NewAbsoluteDurationDuration(time.Second),
+			Children: children,
+		}},
+	}}
+}
@@ -0,0 +1,169 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	domainTask "github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/k4ji/tracesimulator/pkg/model/task/taskduration"
+	"text/template"
+	"time"
+)
+
+// RepeatLayout defines how the iterations of a repeated task are placed in time
+type RepeatLayout string
+
+const (
+	// RepeatLayoutParallel starts all iterations at the delay of the task
+	RepeatLayoutParallel RepeatLayout = "parallel"
+	// RepeatLayoutSequential starts each iteration after the previous one ends
+	RepeatLayoutSequential RepeatLayout = "sequential"
+)
+
+// Repeat describes how many times a task is repeated among its siblings, such as an N+1 query loop or a batch fan-out.
+// Attribute values of each iteration can refer to {{.Index}} (starting from 0) and {{.Count}},
+// and ExternalIDs within the repeated subtree, as well as the references to them, are suffixed with the index to keep them unique.
+type Repeat struct {
+	// Count is the fixed number of iterations, used when MaxCount is zero
+	Count int
+	// MinCount and MaxCount bound the sampled number of iterations
+	MinCount int
+	MaxCount int
+	// Randomness returns a random value between 0 and 1, used to sample the number of iterations
+	Randomness func() float64
+	// Layout defines how the iterations are placed in time
+	Layout RepeatLayout
+	// Gap is the wait time between sequential iterations
+	Gap time.Duration
+}
+
+// iterationData is the data available to attribute templates of a repeated task
+type iterationData struct {
+	Index int
+	Count int
+}
+
+func (r *Repeat) count() (int, error) {
+	if r.MaxCount == 0 {
+		if r.Count < 0 {
+			return 0, fmt.Errorf("repeat count cannot be negative, got %d", r.Count)
+		}
+		return r.Count, nil
+	}
+	if r.MinCount < 0 || r.MaxCount < r.MinCount {
+		return 0, fmt.Errorf("invalid repeat count range [%d, %d]", r.MinCount, r.MaxCount)
+	}
+	if r.Randomness == nil {
+		return 0, fmt.Errorf("sampled repeat count requires a randomness function")
+	}
+	n := r.MinCount + int(r.Randomness()*float64(r.MaxCount-r.MinCount+1))
+	if n > r.MaxCount {
+		n = r.MaxCount
+	}
+	return n, nil
+}
+
+// expandRepeats replaces each repeated task with its iterations
+func expandRepeats(tasks []Task) ([]Task, error) {
+	expanded := make([]Task, 0, len(tasks))
+	for i, t := range tasks {
+		if t.Repeat == nil {
+			expanded = append(expanded, t)
+			continue
+		}
+		iterations, err := t.iterations(fmt.Sprintf("repeat-%d", i))
+		if err != nil {
+			return nil, fmt.Errorf("failed to repeat task %s: %w", t.Name, err)
+		}
+		expanded = append(expanded, iterations...)
+	}
+	return expanded, nil
+}
+
+// iterations returns the iterations of the repeated task.
+// Sequential iterations share the layout sequence, so that each of them starts after the previous one ends
+// once their durations are resolved, and the delay of the task is replaced by the gap after the first one.
+func (t Task) iterations(sequence string) ([]Task, error) {
+	count, err := t.Repeat.count()
+	if err != nil {
+		return nil, err
+	}
+
+	var gap *domainTask.Delay
+	if t.Repeat.Layout == RepeatLayoutSequential {
+		expr, err := taskduration.NewAbsoluteDuration(t.Repeat.Gap)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gap: %w", err)
+		}
+		if gap, err = domainTask.NewDelay(expr); err != nil {
+			return nil, fmt.Errorf("invalid gap: %w", err)
+		}
+	}
+
+	// the ExternalIDs of the repeated subtree and the references to them are suffixed with the index of the iteration
+	ids := t.definedExternalIDs(map[domainTask.ExternalID]struct{}{})
+	iterations := make([]Task, 0, count)
+	for i := 0; i < count; i++ {
+		suffix := fmt.Sprintf("-%d", i)
+		iteration, err := t.withRenamedExternalIDs(ids, func(id string) string {
+			return id + suffix
+		})
+		if err != nil {
+			return nil, err
+		}
+		iteration.Repeat = nil
+		if iteration, err = iteration.withRenderedAttributes(iterationData{Index: i, Count: count}); err != nil {
+			return nil, err
+		}
+		if gap != nil {
+			iteration.layoutSequence = sequence
+			if i > 0 {
+				iteration.Delay = *gap
+			}
+		}
+		iterations = append(iterations, iteration)
+	}
+	return iterations, nil
+}
+
+// withRenderedAttributes returns a copy of the task whose attributes and those of its descendants are rendered with the iteration.
+// Repeated descendants are left to be rendered with their own iterations.
+func (t Task) withRenderedAttributes(data iterationData) (Task, error) {
+	var err error
+	if t.Attributes, err = renderAttributes(t.Attributes, data); err != nil {
+		return Task{}, err
+	}
+	if t.Children == nil {
+		return t, nil
+	}
+	children := make([]Task, len(t.Children))
+	for i, child := range t.Children {
+		if child.Repeat != nil {
+			children[i] = child
+			continue
+		}
+		if children[i], err = child.withRenderedAttributes(data); err != nil {
+			return Task{}, fmt.Errorf("failed to render attributes of task %s: %w", child.Name, err)
+		}
+	}
+	t.Children = children
+	return t, nil
+}
+
+func renderAttributes(attributes map[string]string, data iterationData) (map[string]string, error) {
+	if attributes == nil {
+		return nil, nil
+	}
+	rendered := make(map[string]string, len(attributes))
+	for k, v := range attributes {
+		tmpl, err := template.New(k).Option("missingkey=error").Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse attribute %s: %w", k, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render attribute %s: %w", k, err)
+		}
+		rendered[k] = buf.String()
+	}
+	return rendered, nil
+}
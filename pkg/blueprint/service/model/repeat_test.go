@@ -0,0 +1,183 @@
+package model
+
+import (
+	"fmt"
+	"github.com/k4ji/tracesimulator/pkg/model/span"
+	domainTask "github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/k4ji/tracesimulator/pkg/model/task/taskduration"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTask_ToRootNodeWithResource_Repeat(t *testing.T) {
+	type testCase struct {
+		name          string
+		repeat        *Repeat
+		expectedCount int
+		// expectedDelays are the delays of the first iterations, the last one applies to the remaining iterations
+		expectedDelays   []time.Duration
+		expectedSequence string
+	}
+
+	testCases := []testCase{
+		{
+			name:             "repeat a task sequentially",
+			repeat:           &Repeat{Count: 3, Layout: RepeatLayoutSequential, Gap: 5 * time.Millisecond},
+			expectedCount:    3,
+			expectedDelays:   []time.Duration{10 * time.Millisecond, 5 * time.Millisecond},
+			expectedSequence: "repeat-0",
+		},
+		{
+			name:           "repeat a task in parallel with a sampled count",
+			repeat:         &Repeat{MinCount: 1, MaxCount: 50, Randomness: func() float64 { return 0.5 }, Layout: RepeatLayoutParallel},
+			expectedCount:  26,
+			expectedDelays: []time.Duration{10 * time.Millisecond},
+		},
+		{
+			name:           "repeat a task without layout",
+			repeat:         &Repeat{Count: 2},
+			expectedCount:  2,
+			expectedDelays: []time.Duration{10 * time.Millisecond},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root := newRepeatedTask(tc.repeat)
+			node, err := root.ToRootNodeWithResource(domainTask.NewResource("service-a", nil))
+			assert.NoError(t, err)
+
+			children := node.Children()
+			assert.Len(t, children, tc.expectedCount)
+			for i, child := range children {
+				def := child.Definition()
+				delay, _ := def.Delay().Resolve(nil)
+				assert.Equal(t, tc.expectedDelays[min(i, len(tc.expectedDelays)-1)], *delay)
+				assert.Equal(t, tc.expectedSequence, def.LayoutSequence())
+				assert.Equal(t, fmt.Sprintf("SELECT * FROM items WHERE id = %d", i), def.Attributes()["db.query.text"])
+				assert.Equal(t, fmt.Sprintf("%d", tc.expectedCount), def.Attributes()["iterations"])
+				// ExternalIDs within the repeated subtree and the references to them are suffixed, others are kept
+				assert.Equal(t, fmt.Sprintf("query-%d", i), def.ExternalID().Value())
+				// the attributes of the descendants are rendered with the iteration as well
+				assert.Equal(t, fmt.Sprintf("%d", i), child.Children()[0].Definition().Attributes()["db.row"])
+				linkedTo := child.Children()[0].Definition().LinkedTo()
+				assert.Equal(t, fmt.Sprintf("query-%d", i), linkedTo[0].Value())
+				assert.Equal(t, "cache", linkedTo[1].Value())
+			}
+		})
+	}
+}
+
+func TestTask_ToRootNodeWithResource_SequentialRepeat(t *testing.T) {
+	type testCase struct {
+		name     string
+		duration taskduration.Expression
+	}
+
+	sampled, _ := taskduration.NewUniformDuration(10*time.Millisecond, 100*time.Millisecond, func() func() float64 {
+		values := []float64{0.2, 0.9, 0.5}
+		i := 0
+		return func() float64 {
+			v := values[i%len(values)]
+			i++
+			return v
+		}
+	}())
+	relative, _ := taskduration.NewRelativeDuration(0.1)
+	testCases := []testCase{
+		{name: "start each iteration of a sampled duration after the previous one ends", duration: sampled},
+		{name: "start each iteration of a relative duration after the previous one ends", duration: relative},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root := newRepeatedTask(&Repeat{Count: 3, Layout: RepeatLayoutSequential, Gap: 5 * time.Millisecond})
+			d, _ := domainTask.NewDuration(tc.duration)
+			root.Children[0].Duration = *d
+			node, err := root.ToRootNodeWithResource(domainTask.NewResource("service-a", nil))
+			assert.NoError(t, err)
+
+			baseTime := time.Now()
+			rootSpan, err := span.FromTaskTree(node, span.NewTraceID([16]byte{0x01}), baseTime, func() span.ID { return span.NewSpanID([8]byte{0x01}) })
+			assert.NoError(t, err)
+			children := rootSpan.Children()
+			assert.Len(t, children, 3)
+			assert.Equal(t, baseTime.Add(10*time.Millisecond), children[0].StartTime())
+			for i := 1; i < len(children); i++ {
+				assert.Equal(t, children[i-1].EndTime().Add(5*time.Millisecond), children[i].StartTime())
+			}
+		})
+	}
+}
+
+func TestTask_ToRootNodeWithResource_RepeatError(t *testing.T) {
+	type testCase struct {
+		name string
+		root Task
+	}
+
+	repeatedRoot := newRepeatedTask(nil)
+	repeatedRoot.Repeat = &Repeat{Count: 2}
+	testCases := []testCase{
+		{name: "return error when a root task is repeated", root: repeatedRoot},
+		{name: "return error when the count is negative", root: newRepeatedTask(&Repeat{Count: -1})},
+		{name: "return error when the count range is invalid", root: newRepeatedTask(&Repeat{MinCount: 3, MaxCount: 2, Randomness: func() float64 { return 0 }})},
+		{name: "return error when a sampled count has no randomness", root: newRepeatedTask(&Repeat{MinCount: 1, MaxCount: 2})},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.root.ToRootNodeWithResource(domainTask.NewResource("service-a", nil))
+			assert.Error(t, err)
+		})
+	}
+}
+
+// newRepeatedTask returns a handler whose query child is repeated, the child of the query links to the query and to a task outside
+func newRepeatedTask(repeat *Repeat) Task {
+	return Task{
+		Name:     "handler",
+		Kind:     "server",
+		Delay:    NewAbsoluteDurationDelay(0),
+		Duration: NewAbsoluteDurationDuration(time.Second),
+		Children: []Task{
+			{
+				Name:       "SELECT item",
+				ExternalID: newExternalID("query"),
+				Kind:       "client",
+				Delay:      NewAbsoluteDurationDelay(10 * time.Millisecond),
+				Duration:   NewAbsoluteDurationDuration(20 * time.Millisecond),
+				Attributes: map[string]string{"db.query.text": "SELECT * FROM items WHERE id = {{.Index}}", "iterations": "{{.Count}}"},
+				Repeat:     repeat,
+				Children: []Task{
+					{
+						Name:       "decode row",
+						Kind:       "internal",
+						Delay:      NewAbsoluteDurationDelay(0),
+						Duration:   NewAbsoluteDurationDuration(time.Millisecond),
+						Attributes: map[string]string{"db.row": "{{.Index}}"},
+						LinkedTo:   []*domainTask.ExternalID{newExternalID("query"), newExternalID("cache")},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newExternalID(id string) *domainTask.ExternalID {
+	e, _ := domainTask.NewExternalID(id)
+	return e
+}
+
+func NewAbsoluteDurationDelay(duration time.Duration) domainTask.Delay {
+	e, _ := taskduration.NewAbsoluteDuration(duration)
+	d, _ := domainTask.NewDelay(e)
+	return *d
+}
+
+func NewAbsoluteDurationDuration(duration time.Duration) domainTask.Duration {
+	e, _ := taskduration.NewAbsoluteDuration(duration)
+	d, _ := domainTask.NewDuration(e)
+	return *d
+}
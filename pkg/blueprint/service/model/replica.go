@@ -0,0 +1,136 @@
+package model
+
+import (
+	"fmt"
+	domainTask "github.com/k4ji/tracesimulator/pkg/model/task"
+	conventions "go.opentelemetry.io/collector/semconv/v1.27.0"
+	"strconv"
+)
+
+// LoadBalancing defines how the traces of a service are distributed across its replicas
+type LoadBalancing string
+
+const (
+	// LoadBalancingRoundRobin picks the replicas in turn
+	LoadBalancingRoundRobin LoadBalancing = "round-robin"
+	// LoadBalancingRandom picks a replica at random
+	LoadBalancingRandom LoadBalancing = "random"
+)
+
+// Replicas describes the instances a service runs on.
+// Each root task of the service runs on one replica, so all spans of the service within a trace share its resource.
+// The resource of a replica extends the resource of the service with service.instance.id, host.name, k8s.pod.name
+// and, when zones are given, cloud.availability_zone.
+type Replicas struct {
+	// Count is the number of replicas
+	Count int
+	// Zones are assigned to the replicas in turn
+	Zones []string
+	// Balancing defines how a replica is picked for each root task, round-robin by default
+	Balancing LoadBalancing
+	// Randomness returns a random value between 0 and 1, used by the random load balancing
+	Randomness func() float64
+	// Overrides change the behavior of specific replicas
+	Overrides []ReplicaOverride
+	// next is the replica picked next by the round-robin load balancing
+	next int
+}
+
+// ReplicaOverride changes the behavior of a single replica, such as one bad pod failing more often than the others.
+type ReplicaOverride struct {
+	// Replica is the index of the replica starting from 0
+	Replica int
+	// Resource attributes replace the generated ones of the replica
+	Resource map[string]string
+	// Task is the name of the tasks the conditional definitions are added to, all tasks of the replica when empty
+	Task string
+	// ConditionalDefinition is appended to the conditional definitions of the matching tasks
+	ConditionalDefinition []*domainTask.ConditionalDefinition
+}
+
+func (r *Replicas) validate() error {
+	if r.Count < 1 {
+		return fmt.Errorf("replica count must be at least 1, got %d", r.Count)
+	}
+	switch r.Balancing {
+	case "", LoadBalancingRoundRobin:
+	case LoadBalancingRandom:
+		if r.Randomness == nil {
+			return fmt.Errorf("random load balancing requires a randomness function")
+		}
+	default:
+		return fmt.Errorf("unknown load balancing %s", r.Balancing)
+	}
+	for _, override := range r.Overrides {
+		if override.Replica < 0 || override.Replica >= r.Count {
+			return fmt.Errorf("override of replica %d is out of range [0, %d)", override.Replica, r.Count)
+		}
+	}
+	return nil
+}
+
+// pick returns the index of the replica running the next root task
+func (r *Replicas) pick() int {
+	if r.Balancing == LoadBalancingRandom {
+		index := int(r.Randomness() * float64(r.Count))
+		if index >= r.Count {
+			index = r.Count - 1
+		}
+		return index
+	}
+	index := r.next % r.Count
+	r.next = index + 1
+	return index
+}
+
+// resource returns the resource attributes of the replica, based on the attributes of the service
+func (r *Replicas) resource(service string, attributes map[string]string, index int) map[string]string {
+	instance := service + "-" + strconv.Itoa(index)
+	resource := make(map[string]string, len(attributes)+4)
+	for k, v := range attributes {
+		resource[k] = v
+	}
+	resource[conventions.AttributeServiceInstanceID] = instance
+	resource[conventions.AttributeHostName] = instance
+	resource[conventions.AttributeK8SPodName] = instance
+	if len(r.Zones) > 0 {
+		resource[conventions.AttributeCloudAvailabilityZone] = r.Zones[index%len(r.Zones)]
+	}
+	for _, override := range r.Overrides {
+		if override.Replica != index {
+			continue
+		}
+		for k, v := range override.Resource {
+			resource[k] = v
+		}
+	}
+	return resource
+}
+
+// task returns a copy of the task with the conditional definitions of the replica overrides added
+func (r *Replicas) task(t Task, index int) Task {
+	for _, override := range r.Overrides {
+		if override.Replica == index {
+			t = withConditionalDefinitions(t, override.Task, override.ConditionalDefinition)
+		}
+	}
+	return t
+}
+
+// withConditionalDefinitions returns a copy of the task and its descendants in which the conditional definitions are
+// appended to the tasks with the given name, or to all tasks when the name is empty
+func withConditionalDefinitions(t Task, name string, definitions []*domainTask.ConditionalDefinition) Task {
+	if name == "" || name == t.Name {
+		merged := make([]*domainTask.ConditionalDefinition, 0, len(t.ConditionalDefinition)+len(definitions))
+		merged = append(merged, t.ConditionalDefinition...)
+		t.ConditionalDefinition = append(merged, definitions...)
+	}
+	if t.Children != nil {
+		children := make([]Task, len(t.Children))
+		for i, child := range t.Children {
+			children[i] = withConditionalDefinitions(child, name, definitions)
+		}
+		t.Children = children
+	}
+	return t
+}
@@ -0,0 +1,147 @@
+package model
+
+import (
+	domainTask "github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestService_ToWithReplicas(t *testing.T) {
+	type testCase struct {
+		name     string
+		replicas *Replicas
+		// conversions is the number of times the service is converted, the last of which is checked
+		conversions       int
+		expectedInstances []string
+	}
+
+	testCases := []testCase{
+		{
+			name:              "distribute root tasks across replicas in turn",
+			replicas:          &Replicas{Count: 2, Zones: []string{"zone-a", "zone-b"}},
+			conversions:       1,
+			expectedInstances: []string{"checkout-0", "checkout-1", "checkout-0"},
+		},
+		{
+			name:              "keep rotating across conversions",
+			replicas:          &Replicas{Count: 2},
+			conversions:       2,
+			expectedInstances: []string{"checkout-1", "checkout-0", "checkout-1"},
+		},
+		{
+			name:              "pick replicas at random",
+			replicas:          &Replicas{Count: 4, Balancing: LoadBalancingRandom, Randomness: func() float64 { return 0.5 }},
+			conversions:       1,
+			expectedInstances: []string{"checkout-2", "checkout-2", "checkout-2"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var nodes []*domainTask.TreeNode
+			var err error
+			for i := 0; i < tc.conversions; i++ {
+				nodes, err = newReplicatedService(tc.replicas).To()
+				assert.NoError(t, err)
+			}
+			instances := make([]string, len(nodes))
+			for i, n := range nodes {
+				instances[i] = n.Definition().Resource().Attributes()["service.instance.id"]
+				// descendants run on the replica of their root
+				assert.Equal(t, n.Definition().Resource(), n.Children()[0].Definition().Resource())
+			}
+			assert.Equal(t, tc.expectedInstances, instances)
+		})
+	}
+
+	t.Run("set the resource attributes of the replica", func(t *testing.T) {
+		nodes, err := newReplicatedService(&Replicas{Count: 2, Zones: []string{"zone-a", "zone-b"}}).To()
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"deployment.environment":  "production",
+			"service.instance.id":     "checkout-1",
+			"host.name":               "checkout-1",
+			"k8s.pod.name":            "checkout-1",
+			"cloud.availability_zone": "zone-b",
+		}, nodes[1].Definition().Resource().Attributes())
+	})
+
+	t.Run("apply overrides to a single replica", func(t *testing.T) {
+		failing := domainTask.NewConditionalDefinition(
+			domainTask.NewProbabilisticCondition(0.3, func() float64 { return 0 }),
+			[]domainTask.Effect{domainTask.FromMarkAsFailedEffect(domainTask.NewMarkAsFailedEffect(nil))},
+		)
+		nodes, err := newReplicatedService(&Replicas{
+			Count: 2,
+			Overrides: []ReplicaOverride{
+				{Replica: 0, Task: "query", ConditionalDefinition: []*domainTask.ConditionalDefinition{failing}},
+				{Replica: 1, Resource: map[string]string{"k8s.pod.name": "checkout-7f9c"}},
+			},
+		}).To()
+		assert.NoError(t, err)
+		assert.Equal(t, "checkout-0", nodes[0].Definition().Resource().Attributes()["k8s.pod.name"])
+		assert.Equal(t, "checkout-7f9c", nodes[1].Definition().Resource().Attributes()["k8s.pod.name"])
+		assert.Empty(t, nodes[0].Definition().ConditionalDefinitions())
+		assert.Equal(t, []*domainTask.ConditionalDefinition{failing}, nodes[0].Children()[0].Definition().ConditionalDefinitions())
+		assert.Empty(t, nodes[1].Definition().ConditionalDefinitions())
+	})
+}
+
+func TestService_ToWithReplicasError(t *testing.T) {
+	type testCase struct {
+		name          string
+		replicas      *Replicas
+		expectedError string
+	}
+
+	testCases := []testCase{
+		{
+			name:          "return error without replicas",
+			replicas:      &Replicas{Count: 0},
+			expectedError: "replica count must be at least 1, got 0",
+		},
+		{
+			name:          "return error for random load balancing without randomness",
+			replicas:      &Replicas{Count: 2, Balancing: LoadBalancingRandom},
+			expectedError: "random load balancing requires a randomness function",
+		},
+		{
+			name:          "return error for an override of an unknown replica",
+			replicas:      &Replicas{Count: 2, Overrides: []ReplicaOverride{{Replica: 2}}},
+			expectedError: "override of replica 2 is out of range [0, 2)",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newReplicatedService(tc.replicas).To()
+			assert.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
+
+// newReplicatedService returns a checkout service with three root tasks, each with a child
+func newReplicatedService(replicas *Replicas) Service {
+	return Service{
+		Name:     "checkout",
+		Resource: map[string]string{"deployment.environment": "production"},
+		Tasks: []Task{
+			newServerTask("GET /cart", newServerTask("query")),
+			newServerTask("GET /cart", newServerTask("query")),
+			newServerTask("GET /cart", newServerTask("query")),
+		},
+		Replicas: replicas,
+	}
+}
+
+// newServerTask returns a server task taking 100ms
+func newServerTask(name string, children ...Task) Task {
+	return Task{
+		Name:     name,
+		Kind:     "server",
+		Delay:    NewAbsoluteDurationDelay(0),
+		Duration: NewAbsoluteDurationDuration(100 * time.Millisecond),
+		Children: children,
+	}
+}
@@ -0,0 +1,147 @@
+package model
+
+import (
+	"fmt"
+	domainTask "github.com/k4ji/tracesimulator/pkg/model/task"
+	conventions "go.opentelemetry.io/collector/semconv/v1.27.0"
+	"sort"
+	"time"
+)
+
+// Rollout describes the versions of a service deployed side by side, such as a canary next to the stable version.
+// Each root task of the service runs on one version, picked according to the traffic weights at the time the service is converted at.
+// The resource of the service is extended with service.version.
+type Rollout struct {
+	// Versions are the deployed versions of the service
+	Versions []Version
+	// Start is the time the rollout begins, the weight ramps are relative to it
+	Start time.Time
+	// Randomness returns a random value between 0 and 1, used to pick a version
+	Randomness func() float64
+}
+
+// Version is a deployed version of a service with its share of the traffic
+type Version struct {
+	// Name is the service.version of the spans running on the version
+	Name string
+	// Weight is the share of the traffic when the version has no ramp
+	Weight float64
+	// Ramp changes the weight over time, interpolated linearly between the points and constant outside of them
+	Ramp []WeightPoint
+	// Overrides change the behavior of the tasks running on the version
+	Overrides []VersionOverride
+}
+
+// WeightPoint is the weight of a version at a time elapsed since the start of the rollout
+type WeightPoint struct {
+	After  time.Duration
+	Weight float64
+}
+
+// VersionOverride changes the behavior of the tasks running on a version, such as a regression in latency or errors
+type VersionOverride struct {
+	// Task is the name of the tasks the conditional definitions are added to, all tasks of the version when empty
+	Task string
+	// ConditionalDefinition is appended to the conditional definitions of the matching tasks
+	ConditionalDefinition []*domainTask.ConditionalDefinition
+}
+
+func (r *Rollout) validate() error {
+	if len(r.Versions) == 0 {
+		return fmt.Errorf("rollout requires at least one version")
+	}
+	if r.Randomness == nil {
+		return fmt.Errorf("rollout requires a randomness function")
+	}
+	names := make(map[string]struct{}, len(r.Versions))
+	for _, v := range r.Versions {
+		if v.Name == "" {
+			return fmt.Errorf("version name cannot be empty")
+		}
+		if _, exists := names[v.Name]; exists {
+			return fmt.Errorf("duplicate version %s", v.Name)
+		}
+		names[v.Name] = struct{}{}
+		if v.Weight < 0 {
+			return fmt.Errorf("weight of version %s cannot be negative", v.Name)
+		}
+		for _, p := range v.Ramp {
+			if p.Weight < 0 {
+				return fmt.Errorf("weight of version %s cannot be negative", v.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// pick returns the version running the next root task at the given time, the start of the rollout when zero
+func (r *Rollout) pick(now time.Time) (*Version, error) {
+	if now.IsZero() {
+		now = r.Start
+	}
+	elapsed := now.Sub(r.Start)
+	weights := make([]float64, len(r.Versions))
+	total := 0.0
+	for i, v := range r.Versions {
+		weights[i] = v.weightAt(elapsed)
+		total += weights[i]
+	}
+	if total <= 0 {
+		return nil, fmt.Errorf("no version receives traffic %s after the start of the rollout", elapsed)
+	}
+	target := r.Randomness() * total
+	for i := range r.Versions {
+		if target < weights[i] {
+			return &r.Versions[i], nil
+		}
+		target -= weights[i]
+	}
+	// the randomness returned 1, pick the last version receiving traffic
+	for i := len(r.Versions) - 1; i >= 0; i-- {
+		if weights[i] > 0 {
+			return &r.Versions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no version receives traffic")
+}
+
+// weightAt returns the weight of the version at the time elapsed since the start of the rollout
+func (v Version) weightAt(elapsed time.Duration) float64 {
+	if len(v.Ramp) == 0 {
+		return v.Weight
+	}
+	ramp := make([]WeightPoint, len(v.Ramp))
+	copy(ramp, v.Ramp)
+	sort.SliceStable(ramp, func(i, j int) bool {
+		return ramp[i].After < ramp[j].After
+	})
+	if elapsed <= ramp[0].After {
+		return ramp[0].Weight
+	}
+	for i := 1; i < len(ramp); i++ {
+		if elapsed < ramp[i].After {
+			from, to := ramp[i-1], ramp[i]
+			progress := float64(elapsed-from.After) / float64(to.After-from.After)
+			return from.Weight + (to.Weight-from.Weight)*progress
+		}
+	}
+	return ramp[len(ramp)-1].Weight
+}
+
+// resource returns the resource attributes of the version, based on the given attributes
+func (v Version) resource(attributes map[string]string) map[string]string {
+	resource := make(map[string]string, len(attributes)+1)
+	for k, val := range attributes {
+		resource[k] = val
+	}
+	resource[conventions.AttributeServiceVersion] = v.Name
+	return resource
+}
+
+// task returns a copy of the task with the conditional definitions of the version overrides added
+func (v Version) task(t Task) Task {
+	for _, override := range v.Overrides {
+		t = withConditionalDefinitions(t, override.Task, override.ConditionalDefinition)
+	}
+	return t
+}
@@ -0,0 +1,128 @@
+package model
+
+import (
+	domainTask "github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestService_ToWithRollout(t *testing.T) {
+	type testCase struct {
+		name            string
+		elapsed         time.Duration
+		random          float64
+		expectedVersion string
+		// expectedOverridden tells whether the overrides of the new version are applied
+		expectedOverridden bool
+	}
+
+	testCases := []testCase{
+		{name: "route to the old version at the start", elapsed: 0, random: 0.99, expectedVersion: "1.0.0"},
+		{name: "route to the old version early in the ramp", elapsed: 2 * time.Minute, random: 0.7, expectedVersion: "1.0.0"},
+		{name: "route to the new version early in the ramp", elapsed: 2 * time.Minute, random: 0.9, expectedVersion: "1.1.0", expectedOverridden: true},
+		{name: "route to the old version halfway through the ramp", elapsed: 5 * time.Minute, random: 0.49, expectedVersion: "1.0.0"},
+		{name: "route to the new version halfway through the ramp", elapsed: 5 * time.Minute, random: 0.51, expectedVersion: "1.1.0", expectedOverridden: true},
+		{name: "route to the new version after the ramp", elapsed: time.Hour, random: 0.0, expectedVersion: "1.1.0", expectedOverridden: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			nodes, err := newRolloutService(tc.random).ToAt(rolloutStart.Add(tc.elapsed))
+			assert.NoError(t, err)
+			def := nodes[0].Definition()
+			assert.Equal(t, tc.expectedVersion, def.Resource().Attributes()["service.version"])
+			assert.Equal(t, "production", def.Resource().Attributes()["deployment.environment"])
+			if tc.expectedOverridden {
+				assert.Len(t, def.ConditionalDefinitions(), 1)
+			} else {
+				assert.Empty(t, def.ConditionalDefinitions())
+			}
+		})
+	}
+
+	t.Run("route as of the start of the rollout without a time", func(t *testing.T) {
+		nodes, err := newRolloutService(0.99).To()
+		assert.NoError(t, err)
+		assert.Equal(t, "1.0.0", nodes[0].Definition().Resource().Attributes()["service.version"])
+	})
+
+	t.Run("combine with replicas", func(t *testing.T) {
+		s := newRolloutService(0.5)
+		s.Replicas = &Replicas{Count: 2}
+		nodes, err := s.To()
+		assert.NoError(t, err)
+		attributes := nodes[0].Definition().Resource().Attributes()
+		assert.Equal(t, "checkout-0", attributes["service.instance.id"])
+		assert.Equal(t, "1.0.0", attributes["service.version"])
+	})
+}
+
+func TestService_ToWithRolloutError(t *testing.T) {
+	type testCase struct {
+		name          string
+		rollout       func(r *Rollout)
+		expectedError string
+	}
+
+	testCases := []testCase{
+		{
+			name: "return error if no version receives traffic",
+			rollout: func(r *Rollout) {
+				r.Versions = []Version{{Name: "1.0.0", Weight: 0}}
+			},
+			expectedError: "no version receives traffic",
+		},
+		{
+			name: "return error for a duplicate version",
+			rollout: func(r *Rollout) {
+				r.Versions = append(r.Versions, Version{Name: "1.0.0", Weight: 1})
+			},
+			expectedError: "duplicate version 1.0.0",
+		},
+		{
+			name: "return error without randomness",
+			rollout: func(r *Rollout) {
+				r.Randomness = nil
+			},
+			expectedError: "rollout requires a randomness function",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newRolloutService(0.5)
+			tc.rollout(s.Rollout)
+			_, err := s.To()
+			assert.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
+
+// rolloutStart is the start of the rollout of newRolloutService
+var rolloutStart = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// newRolloutService returns a checkout service ramping from 1.0.0 to a failing 1.1.0 over 10 minutes from rolloutStart
+func newRolloutService(random float64) Service {
+	failing := domainTask.NewConditionalDefinition(
+		domainTask.NewProbabilisticCondition(1.0, func() float64 { return 0 }),
+		[]domainTask.Effect{domainTask.FromMarkAsFailedEffect(domainTask.NewMarkAsFailedEffect(nil))},
+	)
+	return Service{
+		Name:     "checkout",
+		Resource: map[string]string{"deployment.environment": "production"},
+		Tasks:    []Task{newServerTask("GET /cart")},
+		Rollout: &Rollout{
+			Versions: []Version{
+				{Name: "1.0.0", Ramp: []WeightPoint{{After: 0, Weight: 1}, {After: 10 * time.Minute, Weight: 0}}},
+				{
+					Name:      "1.1.0",
+					Ramp:      []WeightPoint{{After: 0, Weight: 0}, {After: 10 * time.Minute, Weight: 1}},
+					Overrides: []VersionOverride{{Task: "GET /cart", ConditionalDefinition: []*domainTask.ConditionalDefinition{failing}}},
+				},
+			},
+			Start:      rolloutStart,
+			Randomness: func() float64 { return random },
+		},
+	}
+}
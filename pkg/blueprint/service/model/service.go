@@ -3,6 +3,7 @@ package model
 import (
 	"fmt"
 	domainTask "github.com/k4ji/tracesimulator/pkg/model/task"
+	"time"
 )
 
 // Service represents a service that executes tasks
@@ -10,13 +11,48 @@ type Service struct {
 	Name     string
 	Resource map[string]string
 	Tasks    []Task
+	// Endpoints are tasks that only run when another task calls them (see Call)
+	Endpoints []Task
+	// Replicas spreads the root tasks of the service across multiple instances, a single instance when nil
+	Replicas *Replicas
+	// Rollout spreads the root tasks of the service across multiple versions, a single version when nil
+	Rollout *Rollout
 }
 
-// To converts the Service to a slice of task.TreeNode
+// To converts the Service to a slice of task.TreeNode as of the start of its rollout
 func (s Service) To() ([]*domainTask.TreeNode, error) {
+	return s.ToAt(time.Time{})
+}
+
+// ToAt converts the Service to a slice of task.TreeNode as of the given time, which picks the versions of its rollout
+func (s Service) ToAt(now time.Time) ([]*domainTask.TreeNode, error) {
+	if s.Replicas != nil {
+		if err := s.Replicas.validate(); err != nil {
+			return nil, fmt.Errorf("invalid replicas of service %s: %w", s.Name, err)
+		}
+	}
+	if s.Rollout != nil {
+		if err := s.Rollout.validate(); err != nil {
+			return nil, fmt.Errorf("invalid rollout of service %s: %w", s.Name, err)
+		}
+	}
 	rootTaskNodes := make([]*domainTask.TreeNode, 0)
 	for _, task := range s.Tasks {
-		resource := domainTask.NewResource(s.Name, s.Resource)
+		attributes := s.Resource
+		if s.Replicas != nil {
+			replica := s.Replicas.pick()
+			attributes = s.Replicas.resource(s.Name, attributes, replica)
+			task = s.Replicas.task(task, replica)
+		}
+		if s.Rollout != nil {
+			version, err := s.Rollout.pick(now)
+			if err != nil {
+				return nil, fmt.Errorf("failed to pick a version of service %s: %w", s.Name, err)
+			}
+			attributes = version.resource(attributes)
+			task = version.task(task)
+		}
+		resource := domainTask.NewResource(s.Name, attributes)
 		rootTaskNode, err := task.ToRootNodeWithResource(resource)
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert task %s to root node: %w", task.Name, err)
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"fmt"
 	domainTask "github.com/k4ji/tracesimulator/pkg/model/task"
 )
 
@@ -17,10 +18,38 @@ type Task struct {
 	LinkedTo              []*domainTask.ExternalID
 	Events                []domainTask.Event
 	ConditionalDefinition []*domainTask.ConditionalDefinition
+	// RetryPolicy generates additional attempt spans when a client task is marked as failed
+	RetryPolicy *domainTask.RetryPolicy
+	// Timeout cuts the task and marks it as failed when its duration exceeds the timeout
+	Timeout *domainTask.Timeout
+	// Repeat expands the task into multiple iterations among its siblings
+	Repeat *Repeat
+	// ChildLayout defines how the children are placed in time, parallel by default
+	ChildLayout domainTask.ChildLayout
+	// LayoutGroup makes consecutive siblings with the same group run in parallel within a sequential layout
+	LayoutGroup string
+	// Include replaces the task with a fragment of the FragmentLibrary given to the blueprint
+	Include *Include
+	// Call makes the task a client span calling an endpoint of another service
+	Call *Call
+	// Publish makes the task publish a message to the named queue when it ends
+	Publish string
+	// Consume makes the task start after the dwell time of the messages it consumes from a queue, only on root tasks
+	Consume *domainTask.Consumption
+	// TraceContext sets the W3C trace flags and tracestate of the task, inherited by its descendants
+	TraceContext *domainTask.TraceContext
+	// layoutSequence chains the iterations of a sequential repeat
+	layoutSequence string
 }
 
 // ToRootNodeWithResource converts the Task to a root node with the given resource
 func (t *Task) ToRootNodeWithResource(resource *domainTask.Resource) (*domainTask.TreeNode, error) {
+	if t.Repeat != nil {
+		return nil, fmt.Errorf("repeat is only supported on child tasks")
+	}
+	if t.Include != nil {
+		return nil, fmt.Errorf("include of fragment %s is not resolved", t.Include.Fragment)
+	}
 	def, err := domainTask.NewDefinition(
 		t.Name,
 		true,
@@ -34,12 +63,17 @@ func (t *Task) ToRootNodeWithResource(resource *domainTask.Resource) (*domainTas
 		t.LinkedTo,
 		t.Events,
 		t.ConditionalDefinition,
+		t.definitionOptions()...,
 	)
 	if err != nil {
 		return nil, err
 	}
 	node := domainTask.NewTreeNode(def)
-	for _, child := range t.Children {
+	children, err := expandRepeats(t.Children)
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range children {
 		childNode, err := child.toChildNodeWithResource(resource)
 		if err != nil {
 			return nil, err
@@ -52,6 +86,9 @@ func (t *Task) ToRootNodeWithResource(resource *domainTask.Resource) (*domainTas
 }
 
 func (t *Task) toChildNodeWithResource(resource *domainTask.Resource) (*domainTask.TreeNode, error) {
+	if t.Include != nil {
+		return nil, fmt.Errorf("include of fragment %s is not resolved", t.Include.Fragment)
+	}
 	def, err := domainTask.NewDefinition(
 		t.Name,
 		false,
@@ -65,12 +102,17 @@ func (t *Task) toChildNodeWithResource(resource *domainTask.Resource) (*domainTa
 		t.LinkedTo,
 		t.Events,
 		t.ConditionalDefinition,
+		t.definitionOptions()...,
 	)
 	if err != nil {
 		return nil, err
 	}
 	node := domainTask.NewTreeNode(def)
-	for _, child := range t.Children {
+	children, err := expandRepeats(t.Children)
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range children {
 		childNode, err := child.toChildNodeWithResource(resource)
 		if err != nil {
 			return nil, err
@@ -81,3 +123,32 @@ func (t *Task) toChildNodeWithResource(resource *domainTask.Resource) (*domainTa
 	}
 	return node, nil
 }
+
+func (t *Task) definitionOptions() []domainTask.DefinitionOption {
+	opts := make([]domainTask.DefinitionOption, 0)
+	if t.RetryPolicy != nil {
+		opts = append(opts, domainTask.WithRetryPolicy(t.RetryPolicy))
+	}
+	if t.Timeout != nil {
+		opts = append(opts, domainTask.WithTimeout(t.Timeout))
+	}
+	if t.ChildLayout != "" {
+		opts = append(opts, domainTask.WithChildLayout(t.ChildLayout))
+	}
+	if t.LayoutGroup != "" {
+		opts = append(opts, domainTask.WithLayoutGroup(t.LayoutGroup))
+	}
+	if t.layoutSequence != "" {
+		opts = append(opts, domainTask.WithLayoutSequence(t.layoutSequence))
+	}
+	if t.Publish != "" {
+		opts = append(opts, domainTask.WithPublish(t.Publish))
+	}
+	if t.Consume != nil {
+		opts = append(opts, domainTask.WithConsumption(t.Consume))
+	}
+	if t.TraceContext != nil {
+		opts = append(opts, domainTask.WithTraceContext(t.TraceContext))
+	}
+	return opts
+}
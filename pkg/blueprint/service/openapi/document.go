@@ -0,0 +1,33 @@
+package openapi
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// document is the subset of an OpenAPI 3 document used to generate a blueprint
+type document struct {
+	OpenAPI string `yaml:"openapi"`
+	Info    struct {
+		Title string `yaml:"title"`
+	} `yaml:"info"`
+	Servers []struct {
+		URL string `yaml:"url"`
+	} `yaml:"servers"`
+	// Paths maps each path to its path item, whose method keys hold operations
+	Paths map[string]map[string]yaml.Node `yaml:"paths"`
+}
+
+type operation struct {
+	Responses map[string]response `yaml:"responses"`
+	// Duration is a duration formula (see taskduration.Parse) overriding the default duration of the operation
+	Duration string `yaml:"x-duration"`
+}
+
+type response struct {
+	Description string `yaml:"description"`
+	// Probability is the chance that the response is returned, overriding the default error rates
+	Probability *float64 `yaml:"x-probability"`
+}
+
+// methods are the HTTP methods of a path item, in the order operations are generated
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
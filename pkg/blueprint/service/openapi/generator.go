@@ -0,0 +1,263 @@
+package openapi
+
+import (
+	"fmt"
+	"github.com/k4ji/tracesimulator/pkg/blueprint/service"
+	"github.com/k4ji/tracesimulator/pkg/blueprint/service/internal/outcome"
+	"github.com/k4ji/tracesimulator/pkg/blueprint/service/model"
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/k4ji/tracesimulator/pkg/model/task/taskduration"
+	conventions "go.opentelemetry.io/collector/semconv/v1.27.0"
+	"gopkg.in/yaml.v3"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDuration is the duration of operations when neither the options nor the document specify one
+const DefaultDuration = 100 * time.Millisecond
+
+// DefaultClientErrorRate is the probability of each declared 4xx response without an x-probability,
+// so that operations declaring more client errors return them more often
+const DefaultClientErrorRate = 0.02
+
+// DefaultServerErrorRate is the probability of each declared 5xx response without an x-probability
+const DefaultServerErrorRate = 0.01
+
+// Options configures the blueprint generated from an OpenAPI document
+type Options struct {
+	// ServiceName defaults to the title of the document
+	ServiceName string
+	Resource    map[string]string
+	// Duration is the duration of every operation without an x-duration, DefaultDuration if nil
+	Duration *task.Duration
+	// ClientErrorRate is split evenly across the declared 4xx responses without an x-probability,
+	// each of which is drawn with DefaultClientErrorRate if nil
+	ClientErrorRate *float64
+	// ServerErrorRate is split evenly across the declared 5xx responses without an x-probability,
+	// each of which is drawn with DefaultServerErrorRate if nil
+	ServerErrorRate *float64
+	// Randomness returns a random value between 0 and 1
+	Randomness func() float64
+}
+
+// Load reads an OpenAPI 3 document in JSON or YAML from the path and generates a blueprint from it
+func Load(path string, options Options) (service.Blueprint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return service.Blueprint{}, fmt.Errorf("failed to read OpenAPI document: %w", err)
+	}
+	return FromDocument(data, options)
+}
+
+// FromDocument generates a blueprint with one server task per operation of an OpenAPI 3 document in JSON or YAML.
+// Each task gets the HTTP method, route and status code attributes, where the status code is the first declared 2xx response
+// unless one of the declared 4xx or 5xx responses is drawn, which marks the span as failed for 5xx.
+// The error rate of an operation is derived from its declared 4xx and 5xx responses, see DefaultClientErrorRate.
+func FromDocument(data []byte, options Options) (service.Blueprint, error) {
+	var doc document
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return service.Blueprint{}, fmt.Errorf("failed to parse OpenAPI document: %w", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return service.Blueprint{}, fmt.Errorf("unsupported OpenAPI version %q, expected 3.x", doc.OpenAPI)
+	}
+	if options.Randomness == nil {
+		return service.Blueprint{}, fmt.Errorf("randomness function is required")
+	}
+	name := options.ServiceName
+	if name == "" {
+		name = doc.Info.Title
+	}
+	if name == "" {
+		return service.Blueprint{}, fmt.Errorf("service name is required when the document has no title")
+	}
+
+	var scheme string
+	if len(doc.Servers) > 0 {
+		if u, err := url.Parse(doc.Servers[0].URL); err == nil {
+			scheme = u.Scheme
+		}
+	}
+
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	tasks := make([]model.Task, 0)
+	for _, path := range paths {
+		item := doc.Paths[path]
+		for _, method := range methods {
+			node, ok := item[method]
+			if !ok {
+				continue
+			}
+			var op operation
+			if err := node.Decode(&op); err != nil {
+				return service.Blueprint{}, fmt.Errorf("failed to parse operation %s %s: %w", strings.ToUpper(method), path, err)
+			}
+			t, err := newTask(strings.ToUpper(method), path, scheme, op, options)
+			if err != nil {
+				return service.Blueprint{}, fmt.Errorf("failed to generate task for %s %s: %w", strings.ToUpper(method), path, err)
+			}
+			tasks = append(tasks, t)
+		}
+	}
+
+	return service.NewServiceBlueprint([]model.Service{
+		{
+			Name:     name,
+			Resource: options.Resource,
+			Tasks:    tasks,
+		},
+	}), nil
+}
+
+func newTask(method string, route string, scheme string, op operation, options Options) (model.Task, error) {
+	delayExpr, err := taskduration.NewAbsoluteDuration(0)
+	if err != nil {
+		return model.Task{}, err
+	}
+	delay, err := task.NewDelay(delayExpr)
+	if err != nil {
+		return model.Task{}, err
+	}
+	duration, err := operationDuration(op, options)
+	if err != nil {
+		return model.Task{}, err
+	}
+
+	success, outcomes, err := responseOutcomes(op.Responses, options)
+	if err != nil {
+		return model.Task{}, err
+	}
+	conditionalDefinitions, err := outcome.ConditionalDefinitions(outcomes, options.Randomness)
+	if err != nil {
+		return model.Task{}, err
+	}
+
+	attributes := map[string]string{
+		conventions.AttributeHTTPRequestMethod:      method,
+		conventions.AttributeHTTPRoute:              route,
+		conventions.AttributeHTTPResponseStatusCode: success,
+	}
+	if scheme != "" {
+		attributes[conventions.AttributeURLScheme] = scheme
+	}
+	return model.Task{
+		Name:                  method + " " + route,
+		Delay:                 *delay,
+		Duration:              *duration,
+		Kind:                  "server",
+		Attributes:            attributes,
+		ConditionalDefinition: conditionalDefinitions,
+	}, nil
+}
+
+func operationDuration(op operation, options Options) (*task.Duration, error) {
+	if op.Duration != "" {
+		formula, err := taskduration.Parse(op.Duration, options.Randomness)
+		if err != nil {
+			return nil, err
+		}
+		return task.NewDuration(formula)
+	}
+	if options.Duration != nil {
+		return options.Duration, nil
+	}
+	expr, err := taskduration.NewAbsoluteDuration(DefaultDuration)
+	if err != nil {
+		return nil, err
+	}
+	return task.NewDuration(expr)
+}
+
+// responseOutcomes returns the status code of successful responses and the outcomes of the declared 4xx and 5xx responses,
+// with the 4xx responses first so that a drawn 5xx is never overwritten by a 4xx
+func responseOutcomes(responses map[string]response, options Options) (string, []outcome.Outcome, error) {
+	success := ""
+	var clientErrors, serverErrors []string
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		status, err := statusCode(code)
+		if err != nil {
+			return "", nil, err
+		}
+		switch {
+		case status == "":
+			// the default response documents unexpected errors and is not drawn
+		case status[0] == '2' && success == "":
+			success = status
+		case status[0] == '4':
+			clientErrors = append(clientErrors, code)
+		case status[0] == '5':
+			serverErrors = append(serverErrors, code)
+		}
+	}
+	if success == "" {
+		success = "200"
+	}
+
+	outcomes := make([]outcome.Outcome, 0, len(clientErrors)+len(serverErrors))
+	for _, group := range []struct {
+		codes       []string
+		rate        *float64
+		defaultRate float64
+		failed      bool
+	}{
+		{codes: clientErrors, rate: options.ClientErrorRate, defaultRate: DefaultClientErrorRate, failed: false},
+		{codes: serverErrors, rate: options.ServerErrorRate, defaultRate: DefaultServerErrorRate, failed: true},
+	} {
+		implicit := 0
+		for _, code := range group.codes {
+			if responses[code].Probability == nil {
+				implicit++
+			}
+		}
+		rate := group.defaultRate * float64(implicit)
+		if group.rate != nil {
+			rate = *group.rate
+		}
+		for _, code := range group.codes {
+			status, _ := statusCode(code)
+			probability := 0.0
+			if p := responses[code].Probability; p != nil {
+				probability = *p
+			} else {
+				probability = rate / float64(implicit)
+			}
+			message := responses[code].Description
+			if message == "" {
+				message = "HTTP " + status
+			}
+			outcomes = append(outcomes, outcome.Outcome{
+				Attributes:  map[string]string{conventions.AttributeHTTPResponseStatusCode: status},
+				Failed:      group.failed,
+				Message:     message,
+				Probability: probability,
+			})
+		}
+	}
+	return success, outcomes, nil
+}
+
+// statusCode returns the status code of a response key, the lowest code of a range such as 4XX, or empty for the default response
+func statusCode(key string) (string, error) {
+	if key == "default" {
+		return "", nil
+	}
+	normalized := strings.ReplaceAll(strings.ToUpper(key), "X", "0")
+	if n, err := strconv.Atoi(normalized); err != nil || n < 100 || n > 599 || len(normalized) != 3 {
+		return "", fmt.Errorf("invalid response status code %q", key)
+	}
+	return normalized, nil
+}
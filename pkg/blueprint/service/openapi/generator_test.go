@@ -0,0 +1,197 @@
+package openapi
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/model/span"
+	"github.com/stretchr/testify/assert"
+	conventions "go.opentelemetry.io/collector/semconv/v1.27.0"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const petstore = `
+openapi: 3.0.3
+info:
+  title: petstore
+servers:
+  - url: https://petstore.example.com/v1
+paths:
+  /pets/{petId}:
+    get:
+      operationId: showPetById
+      x-duration: 20ms + 5ms
+      responses:
+        "200":
+          description: Expected response to a valid request
+        "404":
+          description: Pet not found
+        "5XX":
+          description: Unexpected server error
+        default:
+          description: unexpected error
+  /pets:
+    post:
+      responses:
+        "201":
+          description: Pet created
+        "400":
+          description: Invalid pet
+          x-probability: 0.5
+        "409":
+          description: Pet already exists
+    get:
+      responses:
+        "200":
+          description: A paged array of pets
+`
+
+func TestFromDocument(t *testing.T) {
+	type testCase struct {
+		name    string
+		options Options
+		// expectedCodes are the response status codes of GET /pets, POST /pets and GET /pets/{petId}
+		expectedCodes []string
+	}
+
+	rate := 0.1
+	testCases := []testCase{
+		{
+			name:          "return the successful responses",
+			options:       Options{Randomness: never},
+			expectedCodes: []string{"200", "201", "200"},
+		},
+		{
+			name:          "draw declared error responses by the given error rates",
+			options:       Options{Randomness: always, ClientErrorRate: &rate, ServerErrorRate: &rate},
+			expectedCodes: []string{"200", "409", "500"},
+		},
+		// GET /pets/{petId} draws 404 and 5XX with 0.02 and 0.01, POST /pets draws 400 and 409 with 0.5 and 0.02
+		{
+			name:          "draw the declared server errors by the derived error rates",
+			options:       Options{Randomness: func() float64 { return 0.005 }},
+			expectedCodes: []string{"200", "409", "500"},
+		},
+		{
+			name:          "draw the declared client errors by the derived error rates",
+			options:       Options{Randomness: func() float64 { return 0.015 }},
+			expectedCodes: []string{"200", "409", "404"},
+		},
+		{
+			name:          "return the successful responses above the derived error rates",
+			options:       Options{Randomness: func() float64 { return 0.6 }},
+			expectedCodes: []string{"200", "201", "200"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spans := toSpans(t, tc.options)
+			codes := make([]string, len(spans))
+			for i, s := range spans {
+				codes[i] = s.Attributes()[conventions.AttributeHTTPResponseStatusCode]
+			}
+			assert.Equal(t, tc.expectedCodes, codes)
+		})
+	}
+
+	t.Run("generate a server task per operation", func(t *testing.T) {
+		spans := toSpans(t, Options{Randomness: never})
+		assert.Len(t, spans, 3)
+		assert.Equal(t, "GET /pets", spans[0].Name())
+		assert.Equal(t, "POST /pets", spans[1].Name())
+		assert.Equal(t, "GET /pets/{petId}", spans[2].Name())
+
+		get := spans[2]
+		assert.Equal(t, span.KindServer, get.Kind())
+		assert.Equal(t, "petstore", get.Resource().Name())
+		assert.Equal(t, "GET", get.Attributes()[conventions.AttributeHTTPRequestMethod])
+		assert.Equal(t, "/pets/{petId}", get.Attributes()[conventions.AttributeHTTPRoute])
+		assert.Equal(t, "https", get.Attributes()[conventions.AttributeURLScheme])
+		assert.Equal(t, 25*time.Millisecond, get.EndTime().Sub(get.StartTime()))
+		assert.Equal(t, DefaultDuration, spans[0].EndTime().Sub(spans[0].StartTime()))
+	})
+
+	t.Run("set the status of the drawn responses", func(t *testing.T) {
+		spans := toSpans(t, Options{Randomness: always, ClientErrorRate: &rate, ServerErrorRate: &rate})
+
+		status := spans[2].Status()
+		assert.Equal(t, span.StatusCodeError, status.Code())
+		assert.Equal(t, "Unexpected server error", *status.Message())
+		// client errors are not errors of the server span
+		status = spans[1].Status()
+		assert.Equal(t, span.StatusCodeOK, status.Code())
+	})
+
+	t.Run("load a document from disk", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "petstore.json")
+		doc := `{"openapi": "3.1.0", "info": {"title": "petstore"}, "paths": {"/pets": {"get": {"responses": {"200": {"description": "ok"}}}}}}`
+		assert.NoError(t, os.WriteFile(path, []byte(doc), 0o600))
+
+		b, err := Load(path, Options{ServiceName: "pets", Randomness: never})
+		assert.NoError(t, err)
+		roots, err := b.Interpret()
+		assert.NoError(t, err)
+		assert.Len(t, roots, 1)
+		assert.Equal(t, "pets", roots[0].Definition().Resource().Name())
+	})
+}
+
+func TestFromDocumentError(t *testing.T) {
+	type testCase struct {
+		name          string
+		document      string
+		options       Options
+		expectedError string
+	}
+
+	testCases := []testCase{
+		{
+			name:          "return error for an unsupported version",
+			document:      `swagger: "2.0"`,
+			options:       Options{Randomness: never},
+			expectedError: "unsupported OpenAPI version",
+		},
+		{
+			name:          "return error for an invalid status code",
+			document:      `{"openapi": "3.0.0", "info": {"title": "petstore"}, "paths": {"/pets": {"get": {"responses": {"OK": {}}}}}}`,
+			options:       Options{Randomness: never},
+			expectedError: `invalid response status code "OK"`,
+		},
+		{
+			name:          "return error without randomness",
+			document:      petstore,
+			expectedError: "randomness function is required",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := FromDocument([]byte(tc.document), tc.options)
+			assert.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
+
+// toSpans interprets the petstore document into one span tree per operation
+func toSpans(t *testing.T, options Options) []*span.TreeNode {
+	b, err := FromDocument([]byte(petstore), options)
+	assert.NoError(t, err)
+	roots, err := b.Interpret()
+	assert.NoError(t, err)
+	spans := make([]*span.TreeNode, len(roots))
+	for i, root := range roots {
+		s, err := span.FromTaskTree(root, span.NewTraceID([16]byte{0x01}), time.Now(), func() span.ID { return span.NewSpanID([8]byte{0x01}) })
+		assert.NoError(t, err)
+		spans[i] = s
+	}
+	return spans
+}
+
+func always() float64 {
+	return 0.0
+}
+
+func never() float64 {
+	return 0.99
+}
@@ -0,0 +1,57 @@
+package template
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ParameterType defines the values a parameter accepts
+type ParameterType string
+
+const (
+	ParameterTypeString   ParameterType = "string"
+	ParameterTypeInt      ParameterType = "int"
+	ParameterTypeFloat    ParameterType = "float"
+	ParameterTypeBool     ParameterType = "bool"
+	ParameterTypeDuration ParameterType = "duration"
+)
+
+func (t ParameterType) isValid() bool {
+	switch t {
+	case ParameterTypeString, ParameterTypeInt, ParameterTypeFloat, ParameterTypeBool, ParameterTypeDuration:
+		return true
+	default:
+		return false
+	}
+}
+
+// Parameter is a value declared by a template and referenced as ${Name}
+type Parameter struct {
+	Name string
+	Type ParameterType
+	// Default is used when the parameter is not bound, nil makes the parameter required
+	Default *string
+}
+
+// validate returns an error if the value is not of the type of the parameter
+func (p Parameter) validate(value string) error {
+	var err error
+	switch p.Type {
+	case ParameterTypeString:
+	case ParameterTypeInt:
+		_, err = strconv.Atoi(value)
+	case ParameterTypeFloat:
+		_, err = strconv.ParseFloat(value, 64)
+	case ParameterTypeBool:
+		_, err = strconv.ParseBool(value)
+	case ParameterTypeDuration:
+		_, err = time.ParseDuration(value)
+	default:
+		return fmt.Errorf("parameter %s has unsupported type %q", p.Name, p.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("parameter %s expects a %s, got %q", p.Name, p.Type, value)
+	}
+	return nil
+}
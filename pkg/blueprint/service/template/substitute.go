@@ -0,0 +1,223 @@
+package template
+
+import (
+	"fmt"
+	"github.com/k4ji/tracesimulator/pkg/blueprint/service/model"
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/k4ji/tracesimulator/pkg/model/task/taskduration"
+	"regexp"
+)
+
+var placeholderPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// substituter rewrites every templated string of the services
+type substituter func(s string) (string, error)
+
+func (sub substituter) services(services []model.Service) ([]model.Service, error) {
+	result := make([]model.Service, len(services))
+	for i, service := range services {
+		var err error
+		if service.Name, err = sub(service.Name); err != nil {
+			return nil, err
+		}
+		if service.Resource, err = sub.attributes(service.Resource); err != nil {
+			return nil, fmt.Errorf("service %s: %w", service.Name, err)
+		}
+		if service.Tasks, err = sub.tasks(service.Tasks); err != nil {
+			return nil, fmt.Errorf("service %s: %w", service.Name, err)
+		}
+		if service.Endpoints, err = sub.tasks(service.Endpoints); err != nil {
+			return nil, fmt.Errorf("service %s: %w", service.Name, err)
+		}
+		result[i] = service
+	}
+	return result, nil
+}
+
+func (sub substituter) tasks(tasks []model.Task) ([]model.Task, error) {
+	if tasks == nil {
+		return nil, nil
+	}
+	result := make([]model.Task, len(tasks))
+	for i, t := range tasks {
+		var err error
+		if t.Name, err = sub(t.Name); err != nil {
+			return nil, err
+		}
+		if t.Kind, err = sub(t.Kind); err != nil {
+			return nil, fmt.Errorf("task %s: %w", t.Name, err)
+		}
+		if t.ExternalID, err = sub.externalID(t.ExternalID); err != nil {
+			return nil, fmt.Errorf("task %s: %w", t.Name, err)
+		}
+		if t.ChildOf, err = sub.externalID(t.ChildOf); err != nil {
+			return nil, fmt.Errorf("task %s: child of: %w", t.Name, err)
+		}
+		if t.LinkedTo, err = sub.externalIDs(t.LinkedTo); err != nil {
+			return nil, fmt.Errorf("task %s: linked to: %w", t.Name, err)
+		}
+		if t.LayoutGroup, err = sub(t.LayoutGroup); err != nil {
+			return nil, fmt.Errorf("task %s: %w", t.Name, err)
+		}
+		if t.Include != nil {
+			include := *t.Include
+			if include.Namespace, err = sub(include.Namespace); err != nil {
+				return nil, fmt.Errorf("task %s: %w", t.Name, err)
+			}
+			t.Include = &include
+		}
+		if t.Call != nil {
+			call := *t.Call
+			if call.Service, err = sub(call.Service); err != nil {
+				return nil, fmt.Errorf("task %s: %w", t.Name, err)
+			}
+			if call.Endpoint, err = sub(call.Endpoint); err != nil {
+				return nil, fmt.Errorf("task %s: %w", t.Name, err)
+			}
+			if call.Address, err = sub(call.Address); err != nil {
+				return nil, fmt.Errorf("task %s: %w", t.Name, err)
+			}
+			t.Call = &call
+		}
+		if t.Publish, err = sub(t.Publish); err != nil {
+			return nil, fmt.Errorf("task %s: %w", t.Name, err)
+		}
+		if t.Consume != nil {
+			if t.Consume, err = sub.consumption(*t.Consume); err != nil {
+				return nil, fmt.Errorf("task %s: consume: %w", t.Name, err)
+			}
+		}
+		if t.Attributes, err = sub.attributes(t.Attributes); err != nil {
+			return nil, fmt.Errorf("task %s: %w", t.Name, err)
+		}
+		if t.Delay, err = sub.delay(t.Delay); err != nil {
+			return nil, fmt.Errorf("task %s: delay: %w", t.Name, err)
+		}
+		if t.Duration, err = sub.duration(t.Duration); err != nil {
+			return nil, fmt.Errorf("task %s: duration: %w", t.Name, err)
+		}
+		if t.Events, err = sub.events(t.Events); err != nil {
+			return nil, fmt.Errorf("task %s: %w", t.Name, err)
+		}
+		if t.Children, err = sub.tasks(t.Children); err != nil {
+			return nil, err
+		}
+		result[i] = t
+	}
+	return result, nil
+}
+
+func (sub substituter) externalID(id *task.ExternalID) (*task.ExternalID, error) {
+	if id == nil {
+		return nil, nil
+	}
+	value, err := sub(id.Value())
+	if err != nil || value == id.Value() {
+		return id, err
+	}
+	return task.NewExternalID(value)
+}
+
+func (sub substituter) externalIDs(ids []*task.ExternalID) ([]*task.ExternalID, error) {
+	if ids == nil {
+		return nil, nil
+	}
+	result := make([]*task.ExternalID, len(ids))
+	for i, id := range ids {
+		var err error
+		if result[i], err = sub.externalID(id); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (sub substituter) attributes(attributes map[string]string) (map[string]string, error) {
+	if attributes == nil {
+		return nil, nil
+	}
+	result := make(map[string]string, len(attributes))
+	for k, v := range attributes {
+		key, err := sub(k)
+		if err != nil {
+			return nil, err
+		}
+		if result[key], err = sub(v); err != nil {
+			return nil, fmt.Errorf("attribute %s: %w", key, err)
+		}
+	}
+	return result, nil
+}
+
+func (sub substituter) events(events []task.Event) ([]task.Event, error) {
+	if events == nil {
+		return nil, nil
+	}
+	result := make([]task.Event, len(events))
+	for i, event := range events {
+		name, err := sub(event.Name())
+		if err != nil {
+			return nil, err
+		}
+		delay, err := sub.delay(event.Delay())
+		if err != nil {
+			return nil, fmt.Errorf("event %s: %w", name, err)
+		}
+		attributes, err := sub.attributes(event.Attributes())
+		if err != nil {
+			return nil, fmt.Errorf("event %s: %w", name, err)
+		}
+		result[i] = task.NewEvent(name, delay, attributes)
+	}
+	return result, nil
+}
+
+func (sub substituter) delay(delay task.Delay) (task.Delay, error) {
+	expr, err := sub.expression(delay.Expression())
+	if err != nil || expr == delay.Expression() {
+		return delay, err
+	}
+	d, err := task.NewDelay(expr)
+	if err != nil {
+		return delay, err
+	}
+	return *d, nil
+}
+
+func (sub substituter) consumption(consumption task.Consumption) (*task.Consumption, error) {
+	queue, err := sub(consumption.Queue())
+	if err != nil {
+		return nil, err
+	}
+	dwell, err := sub.delay(consumption.Dwell())
+	if err != nil {
+		return nil, err
+	}
+	return task.NewConsumption(queue, dwell, consumption.BatchSize())
+}
+
+func (sub substituter) duration(duration task.Duration) (task.Duration, error) {
+	expr, err := sub.expression(duration.Expression())
+	if err != nil || expr == duration.Expression() {
+		return duration, err
+	}
+	d, err := task.NewDuration(expr)
+	if err != nil {
+		return duration, err
+	}
+	return *d, nil
+}
+
+// expression substitutes the source of a formula and parses it again with the same randomness,
+// other expressions are kept as they are
+func (sub substituter) expression(expr taskduration.Expression) (taskduration.Expression, error) {
+	formula, ok := expr.(*taskduration.Formula)
+	if !ok {
+		return expr, nil
+	}
+	source, err := sub(formula.Source())
+	if err != nil || source == formula.Source() {
+		return expr, err
+	}
+	return taskduration.Parse(source, formula.Randomness())
+}
@@ -0,0 +1,109 @@
+package template
+
+import (
+	"fmt"
+	"github.com/k4ji/tracesimulator/pkg/blueprint/service"
+	"github.com/k4ji/tracesimulator/pkg/blueprint/service/model"
+	"sort"
+	"strings"
+)
+
+// Template is a service blueprint parameterized by ${name} placeholders,
+// which can appear in service and task names, kinds, external IDs and references to them, layout groups,
+// include namespaces, call targets, resource and task attributes, events and duration formulas (see taskduration.Parse).
+type Template struct {
+	parameters map[string]Parameter
+	services   []model.Service
+}
+
+// NewTemplate creates a template after checking that every placeholder refers to a declared parameter
+func NewTemplate(parameters []Parameter, services []model.Service) (*Template, error) {
+	declared := make(map[string]Parameter, len(parameters))
+	for _, p := range parameters {
+		if !placeholderPattern.MatchString("${" + p.Name + "}") {
+			return nil, fmt.Errorf("invalid parameter name %q", p.Name)
+		}
+		if _, exists := declared[p.Name]; exists {
+			return nil, fmt.Errorf("duplicate parameter %s", p.Name)
+		}
+		if !p.Type.isValid() {
+			return nil, fmt.Errorf("parameter %s has unsupported type %q", p.Name, p.Type)
+		}
+		if p.Default != nil {
+			if err := p.validate(*p.Default); err != nil {
+				return nil, fmt.Errorf("invalid default: %w", err)
+			}
+		}
+		declared[p.Name] = p
+	}
+
+	undeclared := make(map[string]struct{})
+	collect := substituter(func(s string) (string, error) {
+		for _, match := range placeholderPattern.FindAllStringSubmatch(s, -1) {
+			if _, ok := declared[match[1]]; !ok {
+				undeclared[match[1]] = struct{}{}
+			}
+		}
+		return s, nil
+	})
+	if _, err := collect.services(services); err != nil {
+		return nil, err
+	}
+	if len(undeclared) > 0 {
+		return nil, fmt.Errorf("undeclared parameters referenced: %s", joinSorted(undeclared))
+	}
+
+	return &Template{parameters: declared, services: services}, nil
+}
+
+// Instantiate creates a service blueprint by binding the parameters to the given values.
+// It returns an error if a value is given for an undeclared parameter, has the wrong type,
+// or if a parameter without a default is not bound.
+// The options are passed to the created blueprint.
+func (t *Template) Instantiate(values map[string]string, opts ...service.Option) (service.Blueprint, error) {
+	bound := make(map[string]string, len(t.parameters))
+	for name, v := range values {
+		p, ok := t.parameters[name]
+		if !ok {
+			return service.Blueprint{}, fmt.Errorf("unknown parameter %s", name)
+		}
+		if err := p.validate(v); err != nil {
+			return service.Blueprint{}, err
+		}
+		bound[name] = v
+	}
+	unbound := make(map[string]struct{})
+	for name, p := range t.parameters {
+		if _, ok := bound[name]; ok {
+			continue
+		}
+		if p.Default == nil {
+			unbound[name] = struct{}{}
+			continue
+		}
+		bound[name] = *p.Default
+	}
+	if len(unbound) > 0 {
+		return service.Blueprint{}, fmt.Errorf("parameters not bound: %s", joinSorted(unbound))
+	}
+
+	substitute := substituter(func(s string) (string, error) {
+		return placeholderPattern.ReplaceAllStringFunc(s, func(placeholder string) string {
+			return bound[placeholderPattern.FindStringSubmatch(placeholder)[1]]
+		}), nil
+	})
+	services, err := substitute.services(t.services)
+	if err != nil {
+		return service.Blueprint{}, fmt.Errorf("failed to instantiate template: %w", err)
+	}
+	return service.NewServiceBlueprint(services, opts...), nil
+}
+
+func joinSorted(names map[string]struct{}) string {
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return strings.Join(sorted, ", ")
+}
@@ -0,0 +1,234 @@
+package template
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/blueprint/service/model"
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/k4ji/tracesimulator/pkg/model/task/taskduration"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTemplate_Instantiate(t *testing.T) {
+	type testCase struct {
+		name     string
+		service  func(s *model.Service)
+		bindings map[string]string
+		// expectedResource are expected among the resource attributes of the root task
+		expectedResource map[string]string
+		expectedShard    string
+		expectedEvent    string
+	}
+
+	testCases := []testCase{
+		{
+			name:             "substitute parameters into names, attributes and durations",
+			bindings:         map[string]string{"region": "eu-west-1", "shard": "3"},
+			expectedResource: map[string]string{"cloud.region": "eu-west-1", "env": "production"},
+			expectedShard:    "eu-west-1-3",
+			expectedEvent:    "entered eu-west-1",
+		},
+		{
+			name:             "substitute the defaults of unbound parameters",
+			bindings:         map[string]string{"region": "us-east-1", "env": "staging"},
+			expectedResource: map[string]string{"cloud.region": "us-east-1", "env": "staging"},
+			expectedShard:    "us-east-1-0",
+			expectedEvent:    "entered us-east-1",
+		},
+		{
+			name: "keep the replicas of the services",
+			service: func(s *model.Service) {
+				s.Replicas = &model.Replicas{Count: 2}
+			},
+			bindings:         map[string]string{"region": "eu-west-1"},
+			expectedResource: map[string]string{"cloud.region": "eu-west-1", "service.instance.id": "checkout-eu-west-1-0"},
+			expectedShard:    "eu-west-1-0",
+			expectedEvent:    "entered eu-west-1",
+		},
+		{
+			name: "keep the rollout of the services",
+			service: func(s *model.Service) {
+				s.Rollout = &model.Rollout{
+					Versions:   []model.Version{{Name: "v2", Weight: 1}},
+					Randomness: func() float64 { return 0.5 },
+				}
+			},
+			bindings:         map[string]string{"region": "eu-west-1"},
+			expectedResource: map[string]string{"cloud.region": "eu-west-1", "service.version": "v2"},
+			expectedShard:    "eu-west-1-0",
+			expectedEvent:    "entered eu-west-1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			services := newServices()
+			if tc.service != nil {
+				tc.service(&services[0])
+			}
+			tmpl, err := NewTemplate(newParameters(), services)
+			assert.NoError(t, err)
+
+			bp, err := tmpl.Instantiate(tc.bindings)
+			assert.NoError(t, err)
+			roots, err := bp.Interpret()
+			assert.NoError(t, err)
+			assert.Len(t, roots, 1)
+
+			def := roots[0].Definition()
+			assert.Equal(t, "checkout-"+tc.bindings["region"], def.Resource().Name())
+			for k, v := range tc.expectedResource {
+				assert.Equal(t, v, def.Resource().Attributes()[k])
+			}
+			assert.Equal(t, tc.expectedShard, def.Attributes()["shard"])
+			duration, err := def.Duration().Resolve(nil)
+			assert.NoError(t, err)
+			assert.Equal(t, 150*time.Millisecond, *duration)
+			event := def.Events()[0]
+			assert.Equal(t, tc.expectedEvent, event.Name())
+			assert.Equal(t, tc.bindings["region"], event.Attributes()["region"])
+		})
+	}
+
+	t.Run("keep the template unchanged across instantiations", func(t *testing.T) {
+		tmpl, _ := NewTemplate(newParameters(), newServices())
+		_, _ = tmpl.Instantiate(map[string]string{"region": "eu-west-1"})
+		bp, err := tmpl.Instantiate(map[string]string{"region": "us-east-1"})
+		assert.NoError(t, err)
+		roots, _ := bp.Interpret()
+		assert.Equal(t, "checkout-us-east-1", roots[0].Definition().Resource().Name())
+	})
+
+	t.Run("substitute the external IDs of each stamp of the template", func(t *testing.T) {
+		externalID := func(id string) *task.ExternalID {
+			e, _ := task.NewExternalID(id)
+			return e
+		}
+		services := newServices()
+		root := &services[0].Tasks[0]
+		root.ExternalID = externalID("checkout-${region}")
+		root.Children = []model.Task{{
+			Name:       "record",
+			ExternalID: externalID("record-${region}"),
+			Delay:      root.Delay,
+			Duration:   root.Duration,
+			LinkedTo:   []*task.ExternalID{externalID("checkout-${region}")},
+		}}
+		tmpl, err := NewTemplate(newParameters(), services)
+		assert.NoError(t, err)
+
+		for _, region := range []string{"eu-west-1", "us-east-1"} {
+			bp, err := tmpl.Instantiate(map[string]string{"region": region})
+			assert.NoError(t, err)
+			roots, err := bp.Interpret()
+			assert.NoError(t, err)
+			child := roots[0].Children()[0].Definition()
+			assert.Equal(t, "checkout-"+region, roots[0].Definition().ExternalID().Value())
+			assert.Equal(t, "record-"+region, child.ExternalID().Value())
+			assert.Equal(t, "checkout-"+region, child.LinkedTo()[0].Value())
+		}
+		assert.Equal(t, "checkout-${region}", root.ExternalID.Value())
+	})
+}
+
+func TestTemplate_InstantiateError(t *testing.T) {
+	type testCase struct {
+		name          string
+		parameters    []Parameter
+		bindings      map[string]string
+		expectedError string
+	}
+
+	testCases := []testCase{
+		{
+			name:          "return error when a required parameter is not bound",
+			parameters:    newParameters(),
+			bindings:      map[string]string{},
+			expectedError: "parameters not bound: region",
+		},
+		{
+			name:          "return error when a value has the wrong type",
+			parameters:    newParameters(),
+			bindings:      map[string]string{"region": "eu-west-1", "latency": "fast"},
+			expectedError: "parameter latency expects a duration",
+		},
+		{
+			name:          "return error when binding an unknown parameter",
+			parameters:    newParameters(),
+			bindings:      map[string]string{"region": "eu-west-1", "zone": "a"},
+			expectedError: "unknown parameter zone",
+		},
+		{
+			name:          "return error when a placeholder refers to an undeclared parameter",
+			parameters:    newParameters()[:1],
+			expectedError: "undeclared parameters referenced: env, factor, latency, shard",
+		},
+		{
+			name:          "return error when a default has the wrong type",
+			parameters:    append(newParameters()[:4], Parameter{Name: "shard", Type: ParameterTypeInt, Default: ptrString("first")}),
+			expectedError: "invalid default: parameter shard expects a int",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl, err := NewTemplate(tc.parameters, newServices())
+			if err == nil {
+				_, err = tmpl.Instantiate(tc.bindings)
+			}
+			assert.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
+
+// newServices returns a checkout service templated by the parameters of newParameters
+func newServices() []model.Service {
+	formulaDuration := func(source string) task.Duration {
+		f, _ := taskduration.Parse(source, nil)
+		d, _ := task.NewDuration(f)
+		return *d
+	}
+	absoluteDelay := func(duration time.Duration) task.Delay {
+		e, _ := taskduration.NewAbsoluteDuration(duration)
+		d, _ := task.NewDelay(e)
+		return *d
+	}
+	return []model.Service{
+		{
+			Name: "checkout-${region}",
+			Resource: map[string]string{
+				"cloud.region": "${region}",
+				"env":          "${env}",
+			},
+			Tasks: []model.Task{
+				{
+					Name:     "POST /checkout",
+					Kind:     "server",
+					Delay:    absoluteDelay(0),
+					Duration: formulaDuration("${latency} * ${factor}"),
+					Attributes: map[string]string{
+						"http.route": "/checkout",
+						"shard":      "${region}-${shard}",
+					},
+					Events: []task.Event{
+						task.NewEvent("entered ${region}", absoluteDelay(0), map[string]string{"region": "${region}"}),
+					},
+				},
+			},
+		},
+	}
+}
+
+func newParameters() []Parameter {
+	return []Parameter{
+		{Name: "region", Type: ParameterTypeString},
+		{Name: "env", Type: ParameterTypeString, Default: ptrString("production")},
+		{Name: "latency", Type: ParameterTypeDuration, Default: ptrString("100ms")},
+		{Name: "factor", Type: ParameterTypeFloat, Default: ptrString("1.5")},
+		{Name: "shard", Type: ParameterTypeInt, Default: ptrString("0")},
+	}
+}
+
+func ptrString(s string) *string {
+	return &s
+}
@@ -0,0 +1,252 @@
+package session
+
+import (
+	"fmt"
+	"github.com/k4ji/tracesimulator/pkg/blueprint"
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/k4ji/tracesimulator/pkg/model/task/taskduration"
+	"math"
+	"time"
+)
+
+// IDAttributeKey is the attribute shared by all spans generated for the steps of a session
+const IDAttributeKey = "session.id"
+
+// StepIndexAttributeKey is the attribute holding the position of the step in the session, starting from 0
+const StepIndexAttributeKey = "session.step"
+
+// Mode defines how the steps of a session are turned into traces
+type Mode string
+
+const (
+	// ModeTracePerStep generates one trace per step, each linking to the trace of the previous step
+	ModeTracePerStep Mode = "tracePerStep"
+	// ModeSessionTrace generates a single session trace whose children are the steps, each linking to the previous step
+	ModeSessionTrace Mode = "sessionTrace"
+)
+
+// Blueprint implements `Blueprint` interface
+var _ blueprint.Blueprint = (*Blueprint)(nil)
+
+// Blueprint generates a user session by walking a Markov chain of steps
+type Blueprint struct {
+	steps       map[string]Step
+	transitions map[string][]Transition
+	start       string
+	maxSteps    int
+	mode        Mode
+	randomness  func() float64
+}
+
+// NewSessionBlueprint creates a blueprint from a chain.
+// randomness returns a random value between 0 and 1.
+func NewSessionBlueprint(chain Chain, mode Mode, randomness func() float64) (Blueprint, error) {
+	if randomness == nil {
+		return Blueprint{}, fmt.Errorf("session blueprint requires a randomness function")
+	}
+	if mode != ModeTracePerStep && mode != ModeSessionTrace {
+		return Blueprint{}, fmt.Errorf("unsupported session mode %q", mode)
+	}
+	if chain.MaxSteps < 0 {
+		return Blueprint{}, fmt.Errorf("max steps cannot be negative, got %d", chain.MaxSteps)
+	}
+	b := Blueprint{
+		steps:       make(map[string]Step, len(chain.Steps)),
+		transitions: make(map[string][]Transition),
+		start:       chain.Start,
+		maxSteps:    chain.MaxSteps,
+		mode:        mode,
+		randomness:  randomness,
+	}
+	for _, s := range chain.Steps {
+		if _, exists := b.steps[s.Name]; exists {
+			return Blueprint{}, fmt.Errorf("duplicate step %s", s.Name)
+		}
+		if s.Service == "" {
+			return Blueprint{}, fmt.Errorf("service of step %s cannot be empty", s.Name)
+		}
+		b.steps[s.Name] = s
+	}
+	if _, ok := b.steps[chain.Start]; !ok {
+		return Blueprint{}, fmt.Errorf("start step %s not found", chain.Start)
+	}
+	total := make(map[string]float64)
+	for _, t := range chain.Transitions {
+		if _, ok := b.steps[t.From]; !ok {
+			return Blueprint{}, fmt.Errorf("step %s of transition %s -> %s not found", t.From, t.From, t.To)
+		}
+		if _, ok := b.steps[t.To]; !ok {
+			return Blueprint{}, fmt.Errorf("step %s of transition %s -> %s not found", t.To, t.From, t.To)
+		}
+		if t.Probability < 0 {
+			return Blueprint{}, fmt.Errorf("probability of transition %s -> %s cannot be negative", t.From, t.To)
+		}
+		total[t.From] += t.Probability
+		if total[t.From] > 1+1e-9 {
+			return Blueprint{}, fmt.Errorf("probabilities of transitions from %s sum to more than 1", t.From)
+		}
+		b.transitions[t.From] = append(b.transitions[t.From], t)
+	}
+	return b, nil
+}
+
+// newSessionID returns a random 64-bit session ID in hex.
+// The randomness is kept below 1 as scaling 1 to 64 bits would overflow.
+func (b *Blueprint) newSessionID() string {
+	r := math.Min(math.Max(b.randomness(), 0), math.Nextafter(1, 0))
+	return fmt.Sprintf("%016x", uint64(r*(1<<64)))
+}
+
+func (b *Blueprint) Interpret() ([]*task.TreeNode, error) {
+	sessionID := b.newSessionID()
+	path := b.walk()
+
+	var offset time.Duration
+	var previous *task.ExternalID
+	stepNodes := make([]*task.TreeNode, len(path))
+	for i, step := range path {
+		externalID, err := task.NewExternalID(fmt.Sprintf("session-%s-step-%d", sessionID, i))
+		if err != nil {
+			return nil, err
+		}
+		// the duration is resolved once so that the following steps start after this one ends
+		duration, err := step.Duration.Resolve(nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve duration of step %s: %w", step.Name, err)
+		}
+		node, err := b.newStepNode(step, i, sessionID, externalID, previous, offset, *duration)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate step %s: %w", step.Name, err)
+		}
+		stepNodes[i] = node
+		offset += *duration
+		if step.ThinkTime.Expression() != nil {
+			thinkTime, err := step.ThinkTime.Resolve(nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve think time of step %s: %w", step.Name, err)
+			}
+			offset += *thinkTime
+		}
+		previous = externalID
+	}
+
+	if b.mode == ModeTracePerStep {
+		return stepNodes, nil
+	}
+	root, err := b.newSessionNode(path[0], sessionID)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range stepNodes {
+		if err := root.AddChild(node); err != nil {
+			return nil, err
+		}
+	}
+	return []*task.TreeNode{root}, nil
+}
+
+// walk samples the steps of a session
+func (b *Blueprint) walk() []Step {
+	path := []Step{b.steps[b.start]}
+	for b.maxSteps == 0 || len(path) < b.maxSteps {
+		r := b.randomness()
+		var next *Transition
+		for _, t := range b.transitions[path[len(path)-1].Name] {
+			if r < t.Probability {
+				next = &t
+				break
+			}
+			r -= t.Probability
+		}
+		if next == nil {
+			break
+		}
+		path = append(path, b.steps[next.To])
+	}
+	return path
+}
+
+func (b *Blueprint) newStepNode(step Step, index int, sessionID string, externalID *task.ExternalID, previous *task.ExternalID, offset time.Duration, duration time.Duration) (*task.TreeNode, error) {
+	attributes := make(map[string]string, len(step.Attributes)+2)
+	for k, v := range step.Attributes {
+		attributes[k] = v
+	}
+	attributes[IDAttributeKey] = sessionID
+	attributes[StepIndexAttributeKey] = fmt.Sprintf("%d", index)
+	linkedTo := []*task.ExternalID{}
+	if previous != nil {
+		linkedTo = append(linkedTo, previous)
+	}
+	delay, err := absoluteDelay(offset)
+	if err != nil {
+		return nil, err
+	}
+	durationExpr, err := taskduration.NewAbsoluteDuration(duration)
+	if err != nil {
+		return nil, err
+	}
+	resolvedDuration, err := task.NewDuration(durationExpr)
+	if err != nil {
+		return nil, err
+	}
+	def, err := task.NewDefinition(
+		step.Name,
+		true,
+		task.NewResource(step.Service, step.Resource),
+		attributes,
+		task.KindServer,
+		externalID,
+		*delay,
+		*resolvedDuration,
+		nil,
+		linkedTo,
+		[]task.Event{},
+		[]*task.ConditionalDefinition{},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return task.NewTreeNode(def), nil
+}
+
+// newSessionNode creates the root span covering all steps of a session trace
+func (b *Blueprint) newSessionNode(first Step, sessionID string) (*task.TreeNode, error) {
+	delay, err := absoluteDelay(0)
+	if err != nil {
+		return nil, err
+	}
+	auto, err := taskduration.NewAutoDuration(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	duration, err := task.NewDuration(auto)
+	if err != nil {
+		return nil, err
+	}
+	def, err := task.NewDefinition(
+		"session",
+		true,
+		task.NewResource(first.Service, first.Resource),
+		map[string]string{IDAttributeKey: sessionID},
+		task.KindInternal,
+		nil,
+		*delay,
+		*duration,
+		nil,
+		[]*task.ExternalID{},
+		[]task.Event{},
+		[]*task.ConditionalDefinition{},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return task.NewTreeNode(def), nil
+}
+
+func absoluteDelay(d time.Duration) (*task.Delay, error) {
+	expr, err := taskduration.NewAbsoluteDuration(d)
+	if err != nil {
+		return nil, err
+	}
+	return task.NewDelay(expr)
+}
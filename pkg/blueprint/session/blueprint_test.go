@@ -0,0 +1,178 @@
+package session
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/k4ji/tracesimulator/pkg/model/task/taskduration"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBlueprint_Interpret(t *testing.T) {
+	type testCase struct {
+		name     string
+		maxSteps int
+		mode     Mode
+		// draws are the values of the randomness, the first of which generates the session ID
+		draws         []float64
+		expectedRoots []string
+		// expectedSteps are the steps under the root of a session trace
+		expectedSteps []string
+	}
+
+	testCases := []testCase{
+		{
+			name: "generate one trace per step",
+			mode: ModeTracePerStep,
+			// GET / -> GET /product, GET /product -> GET /product, GET /product -> POST /cart, end
+			draws:         []float64{0.5, 0.1, 0.2, 0.4, 0.9},
+			expectedRoots: []string{"GET /", "GET /product", "GET /product", "POST /cart"},
+		},
+		{
+			name:          "generate a single session trace",
+			mode:          ModeSessionTrace,
+			draws:         []float64{0.5, 0.1, 0.6},
+			expectedRoots: []string{"session"},
+			expectedSteps: []string{"GET /", "GET /product", "POST /cart"},
+		},
+		{
+			name:          "end the session after max steps",
+			maxSteps:      2,
+			mode:          ModeTracePerStep,
+			draws:         []float64{0.5, 0.1},
+			expectedRoots: []string{"GET /", "GET /product"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			chain := newChain()
+			chain.MaxSteps = tc.maxSteps
+			b, err := NewSessionBlueprint(chain, tc.mode, sequence(tc.draws...))
+			assert.NoError(t, err)
+
+			roots, err := b.Interpret()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedRoots, names(roots))
+			if tc.expectedSteps != nil {
+				assert.Equal(t, tc.expectedSteps, names(roots[0].Children()))
+			}
+		})
+	}
+
+	t.Run("link each step to the previous one after its think time", func(t *testing.T) {
+		b, _ := NewSessionBlueprint(newChain(), ModeTracePerStep, sequence(0.5, 0.1, 0.2, 0.4, 0.9))
+		roots, err := b.Interpret()
+		assert.NoError(t, err)
+
+		sessionID := roots[0].Definition().Attributes()[IDAttributeKey]
+		assert.NotEmpty(t, sessionID)
+		expectedDelays := []time.Duration{0, 2100 * time.Millisecond, 3300 * time.Millisecond, 4500 * time.Millisecond}
+		for i, root := range roots {
+			assert.Equal(t, sessionID, root.Definition().Attributes()[IDAttributeKey])
+			delay, _ := root.Definition().Delay().Resolve(nil)
+			assert.Equal(t, expectedDelays[i], *delay)
+			if i == 0 {
+				assert.Len(t, root.Definition().LinkedTo(), 0)
+			} else {
+				assert.Equal(t, *roots[i-1].Definition().ExternalID(), *root.Definition().LinkedTo()[0])
+			}
+		}
+	})
+
+	t.Run("generate the session ID when randomness returns 1", func(t *testing.T) {
+		b, _ := NewSessionBlueprint(newChain(), ModeTracePerStep, sequence(1.0, 0.9))
+		roots, err := b.Interpret()
+		assert.NoError(t, err)
+		assert.Equal(t, "fffffffffffff800", roots[0].Definition().Attributes()[IDAttributeKey])
+	})
+}
+
+func TestNewSessionBlueprintError(t *testing.T) {
+	type testCase struct {
+		name          string
+		chain         func(c *Chain)
+		randomness    func() float64
+		expectedError string
+	}
+
+	testCases := []testCase{
+		{
+			name: "return error when transition probabilities sum to more than 1",
+			chain: func(c *Chain) {
+				c.Transitions = append(c.Transitions, Transition{From: "GET /", To: "POST /cart", Probability: 0.5})
+			},
+			randomness:    sequence(0.5),
+			expectedError: "probabilities of transitions from GET / sum to more than 1",
+		},
+		{
+			name: "return error when the start step is not found",
+			chain: func(c *Chain) {
+				c.Start = "GET /login"
+			},
+			randomness:    sequence(0.5),
+			expectedError: "start step GET /login not found",
+		},
+		{
+			name:          "return error without randomness",
+			chain:         func(c *Chain) {},
+			expectedError: "session blueprint requires a randomness function",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			chain := newChain()
+			tc.chain(&chain)
+			_, err := NewSessionBlueprint(chain, ModeTracePerStep, tc.randomness)
+			assert.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
+
+// newChain returns a shopping session browsing products before adding one to the cart
+func newChain() Chain {
+	return Chain{
+		Steps: []Step{
+			{Name: "GET /", Service: "frontend", Duration: NewAbsoluteDurationDuration(100 * time.Millisecond), ThinkTime: NewAbsoluteDurationDelay(2 * time.Second)},
+			{Name: "GET /product", Service: "frontend", Duration: NewAbsoluteDurationDuration(200 * time.Millisecond), ThinkTime: NewAbsoluteDurationDelay(time.Second)},
+			{Name: "POST /cart", Service: "cart", Duration: NewAbsoluteDurationDuration(50 * time.Millisecond)},
+		},
+		Transitions: []Transition{
+			{From: "GET /", To: "GET /product", Probability: 0.8},
+			{From: "GET /product", To: "GET /product", Probability: 0.3},
+			{From: "GET /product", To: "POST /cart", Probability: 0.5},
+		},
+		Start: "GET /",
+	}
+}
+
+// sequence returns a randomness cycling through the given values
+func sequence(values ...float64) func() float64 {
+	i := 0
+	return func() float64 {
+		v := values[i%len(values)]
+		i++
+		return v
+	}
+}
+
+func names(nodes []*task.TreeNode) []string {
+	r := make([]string, len(nodes))
+	for i, n := range nodes {
+		r[i] = n.Definition().Name()
+	}
+	return r
+}
+
+func NewAbsoluteDurationDelay(duration time.Duration) task.Delay {
+	e, _ := taskduration.NewAbsoluteDuration(duration)
+	d, _ := task.NewDelay(e)
+	return *d
+}
+
+func NewAbsoluteDurationDuration(duration time.Duration) task.Duration {
+	e, _ := taskduration.NewAbsoluteDuration(duration)
+	d, _ := task.NewDuration(e)
+	return *d
+}
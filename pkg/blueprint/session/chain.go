@@ -0,0 +1,34 @@
+package session
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+)
+
+// Step is a state of the chain, a page view or API operation performed by the user
+type Step struct {
+	Name       string
+	Service    string
+	Resource   map[string]string
+	Attributes map[string]string
+	Duration   task.Duration
+	// ThinkTime is the time the user waits after the step before the next one
+	ThinkTime task.Delay
+}
+
+// Transition is the probability of moving from one step to another.
+// The remainder of the probabilities leaving a step is the probability that the session ends there.
+type Transition struct {
+	From        string
+	To          string
+	Probability float64
+}
+
+// Chain describes the user session as a Markov chain of steps
+type Chain struct {
+	Steps       []Step
+	Transitions []Transition
+	// Start is the name of the first step of every session
+	Start string
+	// MaxSteps ends the session after the given number of steps, zero means no limit
+	MaxSteps int
+}
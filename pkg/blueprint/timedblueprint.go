@@ -0,0 +1,14 @@
+package blueprint
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"time"
+)
+
+// TimedBlueprint is a Blueprint whose interpretation depends on the time of the simulation, such as a schedule of faults.
+// The simulator interprets it at the base end time of the run instead of calling Interpret.
+type TimedBlueprint interface {
+	Blueprint
+	// InterpretAt converts the blueprint into a slice of task.TreeNode as of the given time
+	InterpretAt(now time.Time) ([]*task.TreeNode, error)
+}
@@ -0,0 +1,235 @@
+package topology
+
+import (
+	"fmt"
+	"github.com/k4ji/tracesimulator/pkg/blueprint"
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/k4ji/tracesimulator/pkg/model/task/taskduration"
+	"time"
+)
+
+// PeerServiceAttributeKey is the attribute of client spans naming the called service
+const PeerServiceAttributeKey = "peer.service"
+
+// Blueprint implements `Blueprint` interface
+var _ blueprint.Blueprint = (*Blueprint)(nil)
+
+// Blueprint generates a trace by walking a call graph of services from an entrypoint.
+// Every visited service produces a server span, and every call a client span in the caller covering the server span of the callee.
+type Blueprint struct {
+	services   map[string]Service
+	edges      map[string][]Edge
+	entrypoint string
+	maxDepth   int
+	randomness func() float64
+}
+
+// NewTopologyBlueprint creates a blueprint from services and the edges between them.
+// maxDepth limits the number of nested calls, zero means no limit, which is only allowed for acyclic graphs.
+// randomness returns a random value between 0 and 1.
+func NewTopologyBlueprint(services []Service, edges []Edge, entrypoint string, maxDepth int, randomness func() float64) (Blueprint, error) {
+	if randomness == nil {
+		return Blueprint{}, fmt.Errorf("topology blueprint requires a randomness function")
+	}
+	b := Blueprint{
+		services:   make(map[string]Service, len(services)),
+		edges:      make(map[string][]Edge),
+		entrypoint: entrypoint,
+		maxDepth:   maxDepth,
+		randomness: randomness,
+	}
+	if maxDepth < 0 {
+		return Blueprint{}, fmt.Errorf("max depth cannot be negative, got %d", maxDepth)
+	}
+	for _, s := range services {
+		if s.Name == "" {
+			return Blueprint{}, fmt.Errorf("service name cannot be empty")
+		}
+		if _, exists := b.services[s.Name]; exists {
+			return Blueprint{}, fmt.Errorf("duplicate service %s", s.Name)
+		}
+		if s.SelfTime <= 0 {
+			return Blueprint{}, fmt.Errorf("self time of service %s must be greater than 0, got %s", s.Name, s.SelfTime)
+		}
+		if s.Operation == "" {
+			s.Operation = s.Name
+		}
+		b.services[s.Name] = s
+	}
+	if _, ok := b.services[entrypoint]; !ok {
+		return Blueprint{}, fmt.Errorf("entrypoint service %s not found", entrypoint)
+	}
+	for _, e := range edges {
+		if _, ok := b.services[e.From]; !ok {
+			return Blueprint{}, fmt.Errorf("service %s of edge %s -> %s not found", e.From, e.From, e.To)
+		}
+		if _, ok := b.services[e.To]; !ok {
+			return Blueprint{}, fmt.Errorf("service %s of edge %s -> %s not found", e.To, e.From, e.To)
+		}
+		if e.Probability < 0 || e.Probability > 1 {
+			return Blueprint{}, fmt.Errorf("probability of edge %s -> %s must be between 0 and 1, got %f", e.From, e.To, e.Probability)
+		}
+		if e.ErrorRate < 0 || e.ErrorRate > 1 {
+			return Blueprint{}, fmt.Errorf("error rate of edge %s -> %s must be between 0 and 1, got %f", e.From, e.To, e.ErrorRate)
+		}
+		if e.Latency < 0 {
+			return Blueprint{}, fmt.Errorf("latency of edge %s -> %s cannot be negative", e.From, e.To)
+		}
+		b.edges[e.From] = append(b.edges[e.From], e)
+	}
+	if maxDepth == 0 {
+		if cycle := b.findCycle(); cycle != nil {
+			return Blueprint{}, fmt.Errorf("call graph has a cycle through %v, a max depth is required", cycle)
+		}
+	}
+	return b, nil
+}
+
+// findCycle returns the services forming a cycle reachable from the entrypoint, if any
+func (b *Blueprint) findCycle() []string {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+	var path []string
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		state[name] = visiting
+		path = append(path, name)
+		for _, e := range b.edges[name] {
+			switch state[e.To] {
+			case visiting:
+				for i, n := range path {
+					if n == e.To {
+						return append(append([]string{}, path[i:]...), e.To)
+					}
+				}
+			case 0:
+				if cycle := visit(e.To); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+	return visit(b.entrypoint)
+}
+
+func (b *Blueprint) Interpret() ([]*task.TreeNode, error) {
+	root, err := b.visit(b.services[b.entrypoint], nil, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate trace from %s: %w", b.entrypoint, err)
+	}
+	return []*task.TreeNode{root}, nil
+}
+
+// visit generates the server span of the service and the calls it makes
+func (b *Blueprint) visit(service Service, via *Edge, depth int) (*task.TreeNode, error) {
+	resource := task.NewResource(service.Name, service.Resource)
+	layout := task.ChildLayoutParallel
+	if service.SequentialCalls {
+		layout = task.ChildLayoutSequential
+	}
+	var conditionalDefinitions []*task.ConditionalDefinition
+	if via != nil && via.ErrorRate > 0 {
+		conditionalDefinitions = append(conditionalDefinitions, b.failWith(task.NewProbabilisticCondition(via.ErrorRate, b.randomness), via))
+	}
+	server, err := b.newNode(service.Operation, true, resource, task.KindServer, nil, service.SelfTime/2, service.SelfTime-service.SelfTime/2, conditionalDefinitions, task.WithChildLayout(layout))
+	if err != nil {
+		return nil, err
+	}
+
+	if b.maxDepth > 0 && depth >= b.maxDepth {
+		return server, nil
+	}
+	for _, e := range b.edges[service.Name] {
+		if b.randomness() >= e.Probability {
+			continue
+		}
+		callee, err := b.visit(b.services[e.To], &e, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		operation := e.Operation
+		if operation == "" {
+			operation = b.services[e.To].Operation
+		}
+		var clientConditionalDefinitions []*task.ConditionalDefinition
+		if e.ErrorRate > 0 {
+			clientConditionalDefinitions = append(clientConditionalDefinitions, b.failWith(task.NewAtLeastCondition(1, task.NewChildCondition(task.NewMarkedAsFailedCondition())), &e))
+		}
+		client, err := b.newNode(operation, false, resource, task.KindClient, map[string]string{PeerServiceAttributeKey: e.To}, e.Latency, e.Latency, clientConditionalDefinitions)
+		if err != nil {
+			return nil, err
+		}
+		if err := client.AddChild(callee); err != nil {
+			return nil, err
+		}
+		if err := server.AddChild(client); err != nil {
+			return nil, err
+		}
+	}
+	return server, nil
+}
+
+func (b *Blueprint) failWith(condition task.Condition, e *Edge) *task.ConditionalDefinition {
+	message := e.ErrorMessage
+	if message == "" {
+		message = fmt.Sprintf("call from %s to %s failed", e.From, e.To)
+	}
+	return task.NewConditionalDefinition(condition, []task.Effect{task.FromMarkAsFailedEffect(task.NewMarkAsFailedEffect(&message))})
+}
+
+func (b *Blueprint) newNode(
+	name string,
+	isResourceEntryPoint bool,
+	resource *task.Resource,
+	kind task.Kind,
+	attributes map[string]string,
+	before time.Duration,
+	after time.Duration,
+	conditionalDefinitions []*task.ConditionalDefinition,
+	opts ...task.DefinitionOption,
+) (*task.TreeNode, error) {
+	zero, err := taskduration.NewAbsoluteDuration(0)
+	if err != nil {
+		return nil, err
+	}
+	delay, err := task.NewDelay(zero)
+	if err != nil {
+		return nil, err
+	}
+	auto, err := taskduration.NewAutoDuration(before, after)
+	if err != nil {
+		return nil, err
+	}
+	duration, err := task.NewDuration(auto)
+	if err != nil {
+		return nil, err
+	}
+	if attributes == nil {
+		attributes = make(map[string]string)
+	}
+	def, err := task.NewDefinition(
+		name,
+		isResourceEntryPoint,
+		resource,
+		attributes,
+		kind,
+		nil,
+		*delay,
+		*duration,
+		nil,
+		[]*task.ExternalID{},
+		[]task.Event{},
+		conditionalDefinitions,
+		opts...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return task.NewTreeNode(def), nil
+}
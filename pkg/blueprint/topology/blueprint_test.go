@@ -0,0 +1,193 @@
+package topology
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/model/span"
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBlueprint_Interpret(t *testing.T) {
+	type testCase struct {
+		name       string
+		edges      []Edge
+		maxDepth   int
+		randomness func() float64
+		// expectedCalls are the names of the calls made by the entrypoint
+		expectedCalls []string
+		// expectedDuration covers the calls, the latencies and the self time of the entrypoint
+		expectedDuration time.Duration
+	}
+
+	baseTime := time.Now()
+	testCases := []testCase{
+		{
+			name:          "walk the call graph from the entrypoint",
+			edges:         newEdges(),
+			randomness:    always,
+			expectedCalls: []string{"GetCart", "Charge"},
+			// cart: 2 + 4 (db call) + 2 = 8, its call: 10; payment: 6, its call: 8; frontend: 5 + 10 + 8 + 5
+			expectedDuration: 28 * time.Millisecond,
+		},
+		{
+			name:          "skip calls by probability",
+			edges:         newEdges(),
+			randomness:    never,
+			expectedCalls: []string{"GetCart"},
+			// frontend: 5 + 10 + 5
+			expectedDuration: 20 * time.Millisecond,
+		},
+		{
+			name:          "stop the walk at the max depth",
+			edges:         newEdges(),
+			maxDepth:      1,
+			randomness:    always,
+			expectedCalls: []string{"GetCart", "Charge"},
+			// cart: 4, its call: 6; payment: 6, its call: 8; frontend: 5 + 6 + 8 + 5
+			expectedDuration: 24 * time.Millisecond,
+		},
+		{
+			name:          "walk a cyclic graph up to the max depth",
+			edges:         append(newEdges(), Edge{From: "db", To: "cart", Probability: 1}),
+			maxDepth:      3,
+			randomness:    always,
+			expectedCalls: []string{"GetCart", "Charge"},
+			// cart: 2 + 8 (db call, with db calling cart at the max depth) + 2 = 12, its call: 14; payment call: 8; frontend: 5 + 14 + 8 + 5
+			expectedDuration: 32 * time.Millisecond,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := NewTopologyBlueprint(newServices(), tc.edges, "frontend", tc.maxDepth, tc.randomness)
+			assert.NoError(t, err)
+			roots, err := b.Interpret()
+			assert.NoError(t, err)
+			assert.Len(t, roots, 1)
+
+			calls := make([]string, 0)
+			for _, call := range roots[0].Children() {
+				calls = append(calls, call.Definition().Name())
+			}
+			assert.Equal(t, tc.expectedCalls, calls)
+
+			rootSpan, err := span.FromTaskTree(roots[0], span.NewTraceID([16]byte{0x01}), baseTime, sameSpanID)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedDuration, rootSpan.EndTime().Sub(rootSpan.StartTime()))
+			assert.NoError(t, rootSpan.ValidateChildrenWithinParent())
+		})
+	}
+
+	t.Run("model calls as client spans of the caller followed by server spans of the callee", func(t *testing.T) {
+		b, _ := NewTopologyBlueprint(newServices(), newEdges(), "frontend", 0, always)
+		roots, err := b.Interpret()
+		assert.NoError(t, err)
+		root := roots[0]
+		assert.Equal(t, "GET /checkout", root.Definition().Name())
+		assert.Equal(t, task.KindServer, root.Definition().Kind())
+
+		call := root.Children()[0]
+		assert.Equal(t, task.KindClient, call.Definition().Kind())
+		assert.Equal(t, "frontend", call.Definition().Resource().Name())
+		assert.Equal(t, "cart", call.Definition().Attributes()[PeerServiceAttributeKey])
+		server := call.Children()[0]
+		assert.Equal(t, "cart", server.Definition().Resource().Name())
+		assert.True(t, server.Definition().IsResourceEntryPoint())
+		assert.Equal(t, "SELECT cart", server.Children()[0].Definition().Name())
+		assert.Equal(t, "db", server.Children()[0].Children()[0].Definition().Name())
+	})
+
+	t.Run("fail the callee and the call by error rate", func(t *testing.T) {
+		failing := []Edge{{From: "frontend", To: "db", Probability: 1, ErrorRate: 1, ErrorMessage: "deadlock"}}
+		b, _ := NewTopologyBlueprint(newServices(), failing, "frontend", 0, always)
+		roots, err := b.Interpret()
+		assert.NoError(t, err)
+
+		rootSpan, err := span.FromTaskTree(roots[0], span.NewTraceID([16]byte{0x01}), baseTime, sameSpanID)
+		assert.NoError(t, err)
+		call := rootSpan.Children()[0]
+		callStatus := call.Status()
+		assert.Equal(t, span.StatusCodeError, callStatus.Code())
+		serverStatus := call.Children()[0].Status()
+		assert.Equal(t, "deadlock", *serverStatus.Message())
+	})
+}
+
+func TestNewTopologyBlueprintError(t *testing.T) {
+	type testCase struct {
+		name          string
+		edges         []Edge
+		entrypoint    string
+		randomness    func() float64
+		expectedError string
+	}
+
+	testCases := []testCase{
+		{
+			name:          "return error for a cyclic graph without max depth",
+			edges:         append(newEdges(), Edge{From: "db", To: "cart", Probability: 1}),
+			entrypoint:    "frontend",
+			randomness:    always,
+			expectedError: "cycle",
+		},
+		{
+			name:          "return error for an unknown callee",
+			edges:         []Edge{{From: "frontend", To: "search", Probability: 1}},
+			entrypoint:    "frontend",
+			randomness:    always,
+			expectedError: "search",
+		},
+		{
+			name:          "return error for an unknown entrypoint",
+			edges:         newEdges(),
+			entrypoint:    "search",
+			randomness:    always,
+			expectedError: "search",
+		},
+		{
+			name:          "return error without randomness",
+			edges:         newEdges(),
+			entrypoint:    "frontend",
+			expectedError: "randomness",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewTopologyBlueprint(newServices(), tc.edges, tc.entrypoint, 0, tc.randomness)
+			assert.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
+
+// newServices returns a frontend calling the cart sequentially before the payment, with the cart backed by a db
+func newServices() []Service {
+	return []Service{
+		{Name: "frontend", Operation: "GET /checkout", SelfTime: 10 * time.Millisecond, SequentialCalls: true},
+		{Name: "cart", Operation: "GetCart", SelfTime: 4 * time.Millisecond},
+		{Name: "payment", Operation: "Charge", SelfTime: 6 * time.Millisecond},
+		{Name: "db", SelfTime: 2 * time.Millisecond},
+	}
+}
+
+// newEdges returns the calls between newServices, each taking 1ms of latency
+func newEdges() []Edge {
+	return []Edge{
+		{From: "frontend", To: "cart", Probability: 1, Latency: time.Millisecond},
+		{From: "frontend", To: "payment", Probability: 0.5, Latency: time.Millisecond},
+		{From: "cart", To: "db", Probability: 1, Operation: "SELECT cart", Latency: time.Millisecond},
+	}
+}
+
+func always() float64 {
+	return 0.0
+}
+
+func never() float64 {
+	return 0.99
+}
+
+func sameSpanID() span.ID {
+	return span.NewSpanID([8]byte{0x01})
+}
@@ -0,0 +1,31 @@
+package topology
+
+import "time"
+
+// Service is a node of the call graph
+type Service struct {
+	Name     string
+	Resource map[string]string
+	// Operation is the name of the server span of the service, it defaults to the name of the service
+	Operation string
+	// SelfTime is the time the service spends on its own, split evenly before and after its calls
+	SelfTime time.Duration
+	// SequentialCalls makes the service call its dependencies one after another instead of in parallel
+	SequentialCalls bool
+}
+
+// Edge is a weighted call from one service to another
+type Edge struct {
+	From string
+	To   string
+	// Probability is the chance that the call is made each time the caller is visited
+	Probability float64
+	// Operation is the name of the client span, it defaults to the operation of the callee
+	Operation string
+	// Latency is the network latency added on each side of the call, so that the client span covers the server span
+	Latency time.Duration
+	// ErrorRate is the chance that the callee fails, which also fails the call
+	ErrorRate float64
+	// ErrorMessage is the status message of failed spans
+	ErrorMessage string
+}
@@ -0,0 +1,50 @@
+package idgen
+
+import (
+	"crypto/rand"
+	"fmt"
+	"github.com/k4ji/tracesimulator/pkg/model/span"
+	"io"
+)
+
+// Random generates random trace and span IDs from crypto/rand
+type Random struct {
+	source io.Reader
+}
+
+// NewRandom creates a new Random generator
+func NewRandom() *Random {
+	return &Random{source: rand.Reader}
+}
+
+func (r *Random) NewTraceID() span.TraceID {
+	var id [16]byte
+	fillNonZero(r.source, id[:])
+	return span.NewTraceID(id)
+}
+
+func (r *Random) NewSpanID() span.ID {
+	return newSpanID(r.source)
+}
+
+// newSpanID reads a span ID from the source
+func newSpanID(source io.Reader) span.ID {
+	var id [8]byte
+	fillNonZero(source, id[:])
+	return span.NewSpanID(id)
+}
+
+// fillNonZero fills the buffer from the source, reading again when all bytes are zero since all-zero IDs are invalid.
+// It panics if the source fails, since the generators cannot return an error and would otherwise retry forever.
+func fillNonZero(source io.Reader, buf []byte) {
+	for {
+		if _, err := io.ReadFull(source, buf); err != nil {
+			panic(fmt.Errorf("failed to read random bytes for an ID: %w", err))
+		}
+		for _, b := range buf {
+			if b != 0 {
+				return
+			}
+		}
+	}
+}
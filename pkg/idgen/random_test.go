@@ -0,0 +1,22 @@
+package idgen
+
+import (
+	"bytes"
+	"github.com/k4ji/tracesimulator/pkg/model/span"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRandom(t *testing.T) {
+	t.Run("read again when all bytes are zero", func(t *testing.T) {
+		gen := &Random{source: bytes.NewReader(append(make([]byte, 8), 1, 2, 3, 4, 5, 6, 7, 8))}
+		assert.Equal(t, span.NewSpanID([8]byte{1, 2, 3, 4, 5, 6, 7, 8}), gen.NewSpanID())
+	})
+
+	t.Run("panic when the source fails instead of reading forever", func(t *testing.T) {
+		gen := &Random{source: bytes.NewReader(make([]byte, 8))}
+		assert.PanicsWithError(t, "failed to read random bytes for an ID: EOF", func() {
+			gen.NewSpanID()
+		})
+	})
+}
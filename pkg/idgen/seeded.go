@@ -0,0 +1,26 @@
+package idgen
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/model/span"
+	"math/rand"
+)
+
+// Seeded generates pseudo-random trace and span IDs from a seed, so that repeated simulations produce the same IDs
+type Seeded struct {
+	source *rand.Rand
+}
+
+// NewSeeded creates a new Seeded generator with the given seed
+func NewSeeded(seed int64) *Seeded {
+	return &Seeded{source: rand.New(rand.NewSource(seed))}
+}
+
+func (s *Seeded) NewTraceID() span.TraceID {
+	var id [16]byte
+	fillNonZero(s.source, id[:])
+	return span.NewTraceID(id)
+}
+
+func (s *Seeded) NewSpanID() span.ID {
+	return newSpanID(s.source)
+}
@@ -0,0 +1,31 @@
+package idgen
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSeeded(t *testing.T) {
+	type testCase struct {
+		name string
+		seed int64
+		// expectedSame tells whether the IDs are the same as those generated with the seed 42
+		expectedSame bool
+	}
+
+	testCases := []testCase{
+		{name: "generate the same IDs with the same seed", seed: 42, expectedSame: true},
+		{name: "generate other IDs with another seed", seed: 7, expectedSame: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reference, gen := NewSeeded(42), NewSeeded(tc.seed)
+			for i := 0; i < 3; i++ {
+				traceID, spanID := gen.NewTraceID(), gen.NewSpanID()
+				assert.Equal(t, tc.expectedSame, traceID == reference.NewTraceID())
+				assert.Equal(t, tc.expectedSame, spanID == reference.NewSpanID())
+			}
+		})
+	}
+}
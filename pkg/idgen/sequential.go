@@ -0,0 +1,32 @@
+package idgen
+
+import (
+	"encoding/binary"
+	"github.com/k4ji/tracesimulator/pkg/model/span"
+)
+
+// Sequential generates readable trace and span IDs counting up from 1, such as 00000000000000000000000000000001.
+// The IDs are not random, so they must not be used with samplers deciding on the randomness of trace IDs.
+type Sequential struct {
+	nextTraceID uint64
+	nextSpanID  uint64
+}
+
+// NewSequential creates a new Sequential generator
+func NewSequential() *Sequential {
+	return &Sequential{nextTraceID: 1, nextSpanID: 1}
+}
+
+func (s *Sequential) NewTraceID() span.TraceID {
+	var id [16]byte
+	binary.BigEndian.PutUint64(id[8:], s.nextTraceID)
+	s.nextTraceID++
+	return span.NewTraceID(id)
+}
+
+func (s *Sequential) NewSpanID() span.ID {
+	var id [8]byte
+	binary.BigEndian.PutUint64(id[:], s.nextSpanID)
+	s.nextSpanID++
+	return span.NewSpanID(id)
+}
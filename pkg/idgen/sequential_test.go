@@ -0,0 +1,36 @@
+package idgen
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSequential(t *testing.T) {
+	type testCase struct {
+		name     string
+		generate func(gen *Sequential) string
+		expected []string
+	}
+
+	testCases := []testCase{
+		{
+			name:     "generate trace IDs in sequence",
+			generate: func(gen *Sequential) string { return gen.NewTraceID().String() },
+			expected: []string{"00000000000000000000000000000001", "00000000000000000000000000000002"},
+		},
+		{
+			name:     "generate span IDs in sequence",
+			generate: func(gen *Sequential) string { return gen.NewSpanID().String() },
+			expected: []string{"0000000000000001", "0000000000000002"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gen := NewSequential()
+			for _, expected := range tc.expected {
+				assert.Equal(t, expected, tc.generate(gen))
+			}
+		})
+	}
+}
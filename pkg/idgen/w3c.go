@@ -0,0 +1,17 @@
+package idgen
+
+// W3C generates trace IDs meeting the randomness requirements of W3C trace context level 2,
+// whose rightmost 7 bytes are uniformly random. The simulator sets the random trace flag on the spans of its traces.
+type W3C struct {
+	Random
+}
+
+// NewW3C creates a new W3C generator
+func NewW3C() *W3C {
+	return &W3C{Random: *NewRandom()}
+}
+
+// RandomTraceID reports that the trace IDs satisfy the W3C randomness requirements
+func (w *W3C) RandomTraceID() bool {
+	return true
+}
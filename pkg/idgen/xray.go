@@ -0,0 +1,35 @@
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"github.com/k4ji/tracesimulator/pkg/model/span"
+	"io"
+	"time"
+)
+
+// XRay generates trace IDs compatible with AWS X-Ray, whose first 4 bytes are the epoch time in seconds
+// followed by 12 random bytes. X-Ray rejects traces whose ID is too old, so the clock should be close to the span times.
+type XRay struct {
+	clock  func() time.Time
+	source io.Reader
+}
+
+// NewXRay creates a new XRay generator taking the time of the trace IDs from the clock, time.Now when nil
+func NewXRay(clock func() time.Time) *XRay {
+	if clock == nil {
+		clock = time.Now
+	}
+	return &XRay{clock: clock, source: rand.Reader}
+}
+
+func (x *XRay) NewTraceID() span.TraceID {
+	var id [16]byte
+	binary.BigEndian.PutUint32(id[:4], uint32(x.clock().Unix()))
+	fillNonZero(x.source, id[4:])
+	return span.NewTraceID(id)
+}
+
+func (x *XRay) NewSpanID() span.ID {
+	return newSpanID(x.source)
+}
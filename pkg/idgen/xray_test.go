@@ -0,0 +1,32 @@
+package idgen
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestXRay(t *testing.T) {
+	type testCase struct {
+		name           string
+		now            time.Time
+		expectedPrefix string
+	}
+
+	testCases := []testCase{
+		{name: "lead trace IDs by the epoch seconds", now: time.Unix(0x5759e988, 0), expectedPrefix: "5759e988"},
+		{name: "truncate the epoch seconds", now: time.Unix(0x5759e988, 999999999), expectedPrefix: "5759e988"},
+		{name: "pad the epoch seconds with zeros", now: time.Unix(0x1, 0), expectedPrefix: "00000001"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gen := NewXRay(func() time.Time { return tc.now })
+			first, second := gen.NewTraceID(), gen.NewTraceID()
+			assert.Equal(t, tc.expectedPrefix, first.String()[:8])
+			assert.Equal(t, tc.expectedPrefix, second.String()[:8])
+			assert.NotEqual(t, first, second)
+			assert.NotEqual(t, gen.NewSpanID(), gen.NewSpanID())
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package imperfection
+
+import (
+	"fmt"
+	simulator "github.com/k4ji/tracesimulator/pkg"
+	"github.com/k4ji/tracesimulator/pkg/model/span"
+	"time"
+)
+
+var _ simulator.Stage = (*Injector)(nil)
+
+// Injector is a stage making the simulated traces imperfect like real telemetry, to exercise trace assemblers.
+// Spans are dropped, duplicated, skewed, or delivered in separate or late batches according to the profile of their service.
+// Each root span returned by the injector is delivered as its own batch, and may be an orphan whose parent is missing
+// or delivered in another batch.
+type Injector struct {
+	// profiles holds the profile of each service by name, services without a profile are left untouched
+	profiles map[string]Profile
+	// randomness is a function that returns a random value between 0 and 1
+	randomness func() float64
+	report     Report
+}
+
+// batches holds the root spans of the batches to deliver
+type batches struct {
+	roots      []*span.TreeNode
+	duplicates []*span.TreeNode
+	delayed    []*span.TreeNode
+}
+
+// NewInjector creates a new Injector applying the profiles to the spans of the services
+func NewInjector(profiles map[string]Profile, randomness func() float64) (*Injector, error) {
+	for service, profile := range profiles {
+		if err := profile.validate(); err != nil {
+			return nil, fmt.Errorf("invalid profile of service %s: %w", service, err)
+		}
+	}
+	if randomness == nil {
+		return nil, fmt.Errorf("imperfection injector requires a randomness function")
+	}
+	return &Injector{profiles: profiles, randomness: randomness}, nil
+}
+
+// Report returns the alterations applied by the last call to Process
+func (i *Injector) Report() Report {
+	return i.report
+}
+
+func (i *Injector) Process(rootSpans []*span.TreeNode) ([]*span.TreeNode, error) {
+	i.report = Report{Alterations: make([]Alteration, 0)}
+	out := &batches{}
+	for _, root := range rootSpans {
+		i.visit(root, nil, out)
+	}
+	result := make([]*span.TreeNode, 0, len(out.roots)+len(out.duplicates)+len(out.delayed))
+	result = append(result, out.roots...)
+	result = append(result, out.duplicates...)
+	return append(result, out.delayed...), nil
+}
+
+// visit applies the profile of the service to the span and its descendants.
+// A span without parent is delivered as the root of a batch unless it is dropped or delayed.
+func (i *Injector) visit(node *span.TreeNode, parent *span.TreeNode, out *batches) {
+	profile, ok := i.profiles[serviceOf(node)]
+	if !ok {
+		if parent == nil {
+			out.roots = append(out.roots, node)
+		}
+		i.visitChildren(node, out)
+		return
+	}
+
+	if i.hit(profile.DropRate) {
+		detach(node, parent)
+		i.record(AlterationKindDropped, node, "")
+		for _, child := range node.Children() {
+			node.RemoveChild(child)
+			i.visit(child, nil, out)
+		}
+		return
+	}
+
+	switch {
+	case i.hit(profile.DelayRate):
+		detach(node, parent)
+		out.delayed = append(out.delayed, node)
+		i.record(AlterationKindDelayed, node, "")
+	case parent != nil && i.hit(profile.SplitRate):
+		detach(node, parent)
+		out.roots = append(out.roots, node)
+		i.record(AlterationKindSplit, node, "")
+	case parent == nil:
+		out.roots = append(out.roots, node)
+	}
+	if i.hit(profile.SkewRate) {
+		skew := time.Duration((2*i.randomness() - 1) * float64(profile.MaxSkew))
+		node.SkewTimestamps(skew)
+		i.record(AlterationKindSkewed, node, skew.String())
+	}
+	if i.hit(profile.DuplicateRate) {
+		out.duplicates = append(out.duplicates, node.CopyWithoutChildren())
+		i.record(AlterationKindDuplicated, node, "")
+	}
+	i.visitChildren(node, out)
+}
+
+func (i *Injector) visitChildren(node *span.TreeNode, out *batches) {
+	for _, child := range node.Children() {
+		i.visit(child, node, out)
+	}
+}
+
+// detach removes the span from its parent, keeping the parent ID of the span
+func detach(node *span.TreeNode, parent *span.TreeNode) {
+	if parent != nil {
+		parent.RemoveChild(node)
+	}
+}
+
+func (i *Injector) hit(rate float64) bool {
+	return rate > 0 && i.randomness() < rate
+}
+
+func (i *Injector) record(kind AlterationKind, node *span.TreeNode, detail string) {
+	i.report.Alterations = append(i.report.Alterations, Alteration{
+		Kind:    kind,
+		TraceID: node.TraceID(),
+		SpanID:  node.ID(),
+		Service: serviceOf(node),
+		Span:    node.Name(),
+		Detail:  detail,
+	})
+}
+
+func serviceOf(node *span.TreeNode) string {
+	if node.Resource() == nil {
+		return ""
+	}
+	return node.Resource().Name()
+}
@@ -0,0 +1,230 @@
+package imperfection
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/adapter/opentelemetry"
+	"github.com/k4ji/tracesimulator/pkg/model/span"
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/k4ji/tracesimulator/pkg/model/task/taskduration"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestInjector_Process(t *testing.T) {
+	type testCase struct {
+		name     string
+		profiles map[string]Profile
+		traces   int
+		// expectedSpans are the names of the root spans of the batches in order
+		expectedSpans       []string
+		expectedAlterations map[AlterationKind]int
+	}
+
+	testCases := []testCase{
+		{
+			name:                "drop spans and leave their children as orphans",
+			profiles:            map[string]Profile{"backend": {DropRate: 1}},
+			traces:              1,
+			expectedSpans:       []string{"GET /", "SELECT items"},
+			expectedAlterations: map[AlterationKind]int{AlterationKindDropped: 1},
+		},
+		{
+			name:                "split traces into separate batches",
+			profiles:            map[string]Profile{"backend": {SplitRate: 1}},
+			traces:              1,
+			expectedSpans:       []string{"GET /", "GET /items"},
+			expectedAlterations: map[AlterationKind]int{AlterationKindSplit: 1},
+		},
+		{
+			name:                "deliver delayed spans after the other batches",
+			profiles:            map[string]Profile{"backend": {DelayRate: 1}},
+			traces:              2,
+			expectedSpans:       []string{"GET /", "GET /", "GET /items", "GET /items"},
+			expectedAlterations: map[AlterationKind]int{AlterationKindDelayed: 2},
+		},
+		{
+			name:                "duplicate spans",
+			profiles:            map[string]Profile{"database": {DuplicateRate: 1}},
+			traces:              1,
+			expectedSpans:       []string{"GET /", "SELECT items"},
+			expectedAlterations: map[AlterationKind]int{AlterationKindDuplicated: 1},
+		},
+		{
+			name:                "leave services without profile untouched",
+			profiles:            map[string]Profile{"payment": {DropRate: 1}},
+			traces:              1,
+			expectedSpans:       []string{"GET /"},
+			expectedAlterations: map[AlterationKind]int{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			injector, err := NewInjector(tc.profiles, always)
+			assert.NoError(t, err)
+			traces := make([]*span.TreeNode, tc.traces)
+			for i := range traces {
+				traces[i] = newTrace(t, byte(i+1))
+			}
+			result, err := injector.Process(traces)
+			assert.NoError(t, err)
+
+			names := make([]string, len(result))
+			for i, n := range result {
+				names[i] = n.Name()
+			}
+			assert.Equal(t, tc.expectedSpans, names)
+			total := 0
+			for kind, count := range tc.expectedAlterations {
+				assert.Equal(t, count, injector.Report().Count(kind))
+				total += count
+			}
+			assert.Len(t, injector.Report().Alterations, total)
+		})
+	}
+
+	t.Run("report dropped spans whose children keep their parent ID", func(t *testing.T) {
+		injector, _ := NewInjector(map[string]Profile{"backend": {DropRate: 1}}, always)
+		root := newTrace(t, 0x01)
+		handler := root.Children()[0]
+		result, err := injector.Process([]*span.TreeNode{root})
+		assert.NoError(t, err)
+		assert.Empty(t, result[0].Children())
+		assert.Equal(t, handler.ID(), *result[1].ParentID())
+		assert.Equal(t, []Alteration{{Kind: AlterationKindDropped, TraceID: root.TraceID(), SpanID: handler.ID(), Service: "backend", Span: "GET /items"}}, injector.Report().Alterations)
+	})
+
+	t.Run("keep the parent of split spans", func(t *testing.T) {
+		injector, _ := NewInjector(map[string]Profile{"backend": {SplitRate: 1}}, always)
+		root := newTrace(t, 0x01)
+		result, err := injector.Process([]*span.TreeNode{root})
+		assert.NoError(t, err)
+		assert.Equal(t, root.ID(), *result[1].ParentID())
+		assert.Equal(t, "SELECT items", result[1].Children()[0].Name())
+	})
+
+	t.Run("keep the IDs of duplicated spans", func(t *testing.T) {
+		injector, _ := NewInjector(map[string]Profile{"database": {DuplicateRate: 1}}, always)
+		root := newTrace(t, 0x01)
+		query := root.Children()[0].Children()[0]
+		result, err := injector.Process([]*span.TreeNode{root})
+		assert.NoError(t, err)
+		assert.Equal(t, query.ID(), result[1].ID())
+		assert.Equal(t, query.ParentID(), result[1].ParentID())
+		assert.NotSame(t, query, result[1])
+	})
+
+	t.Run("skew the timestamps of spans", func(t *testing.T) {
+		values := []float64{0, 0.75}
+		randomness := func() float64 {
+			v := values[0]
+			values = values[1:]
+			return v
+		}
+		injector, _ := NewInjector(map[string]Profile{"frontend": {SkewRate: 1, MaxSkew: 100 * time.Millisecond}}, randomness)
+		result, err := injector.Process([]*span.TreeNode{newTrace(t, 0x01)})
+		assert.NoError(t, err)
+		assert.Equal(t, baseTime.Add(50*time.Millisecond), result[0].StartTime())
+		assert.Equal(t, baseTime, result[0].Children()[0].StartTime())
+		assert.Equal(t, "50ms", injector.Report().Alterations[0].Detail)
+	})
+
+	t.Run("transform orphan spans with the adapter", func(t *testing.T) {
+		injector, _ := NewInjector(map[string]Profile{"backend": {DropRate: 1}}, always)
+		root := newTrace(t, 0x01)
+		handler := root.Children()[0]
+		result, err := injector.Process([]*span.TreeNode{root})
+		assert.NoError(t, err)
+		traces, err := opentelemetry.NewAdapter().Transform(result)
+		assert.NoError(t, err)
+		assert.Len(t, traces, 2)
+		orphan := traces[1].ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+		assert.Equal(t, handler.ID().String(), orphan.ParentSpanID().String())
+	})
+}
+
+func TestNewInjectorError(t *testing.T) {
+	type testCase struct {
+		name          string
+		profiles      map[string]Profile
+		randomness    func() float64
+		expectedError string
+	}
+
+	testCases := []testCase{
+		{
+			name:          "return error for a rate above 1",
+			profiles:      map[string]Profile{"backend": {DropRate: 1.5}},
+			randomness:    always,
+			expectedError: "invalid profile of service backend",
+		},
+		{
+			name:          "return error for a skew rate without maximum skew",
+			profiles:      map[string]Profile{"backend": {SkewRate: 0.5}},
+			randomness:    always,
+			expectedError: "maximum skew must be greater than 0 when the skew rate is set",
+		},
+		{
+			name:          "return error without randomness",
+			expectedError: "imperfection injector requires a randomness function",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewInjector(tc.profiles, tc.randomness)
+			assert.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
+
+var baseTime = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// newTrace creates a trace of frontend -> backend -> database starting at baseTime, with span IDs led by the trace ID
+func newTrace(t *testing.T, traceID byte) *span.TreeNode {
+	newNode := func(name string, service string) *task.TreeNode {
+		def, _ := task.NewDefinition(
+			name,
+			true,
+			task.NewResource(service, nil),
+			make(map[string]string),
+			task.KindServer,
+			nil,
+			NewAbsoluteDurationDelay(0),
+			NewAbsoluteDurationDuration(100*time.Millisecond),
+			nil,
+			[]*task.ExternalID{},
+			[]task.Event{},
+			[]*task.ConditionalDefinition{},
+		)
+		return task.NewTreeNode(def)
+	}
+	root := newNode("GET /", "frontend")
+	handler := newNode("GET /items", "backend")
+	_ = handler.AddChild(newNode("SELECT items", "database"))
+	_ = root.AddChild(handler)
+
+	var next byte
+	rootSpan, err := span.FromTaskTree(root, span.NewTraceID([16]byte{traceID}), baseTime, func() span.ID {
+		next++
+		return span.NewSpanID([8]byte{traceID, next})
+	})
+	assert.NoError(t, err)
+	return rootSpan
+}
+
+func always() float64 {
+	return 0
+}
+
+func NewAbsoluteDurationDelay(duration time.Duration) task.Delay {
+	e, _ := taskduration.NewAbsoluteDuration(duration)
+	d, _ := task.NewDelay(e)
+	return *d
+}
+
+func NewAbsoluteDurationDuration(duration time.Duration) task.Duration {
+	e, _ := taskduration.NewAbsoluteDuration(duration)
+	d, _ := task.NewDuration(e)
+	return *d
+}
@@ -0,0 +1,41 @@
+package imperfection
+
+import (
+	"fmt"
+	"time"
+)
+
+// Profile configures the imperfections affecting the spans of a service.
+// Each rate is the probability of a span of the service to be affected, and an affected span is reported.
+type Profile struct {
+	// DropRate drops the span, its children become orphans whose parent ID points to the missing span
+	DropRate float64
+	// DuplicateRate delivers a copy of the span without its children a second time, in a separate batch
+	DuplicateRate float64
+	// SkewRate shifts the timestamps of the span by up to MaxSkew in either direction, like a host with a wrong clock
+	SkewRate float64
+	MaxSkew  time.Duration
+	// SplitRate delivers the span and its descendants in a separate batch from their parent
+	SplitRate float64
+	// DelayRate delivers the span and its descendants after all other batches
+	DelayRate float64
+}
+
+func (p Profile) validate() error {
+	rates := map[string]float64{
+		"drop":      p.DropRate,
+		"duplicate": p.DuplicateRate,
+		"skew":      p.SkewRate,
+		"split":     p.SplitRate,
+		"delay":     p.DelayRate,
+	}
+	for name, rate := range rates {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("%s rate must be between 0 and 1, got %f", name, rate)
+		}
+	}
+	if p.SkewRate > 0 && p.MaxSkew <= 0 {
+		return fmt.Errorf("maximum skew must be greater than 0 when the skew rate is set")
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package imperfection
+
+import "github.com/k4ji/tracesimulator/pkg/model/span"
+
+// AlterationKind defines how a span was altered
+type AlterationKind string
+
+const (
+	AlterationKindDropped    AlterationKind = "dropped"
+	AlterationKindDuplicated AlterationKind = "duplicated"
+	AlterationKindSkewed     AlterationKind = "skewed"
+	AlterationKindSplit      AlterationKind = "split"
+	AlterationKindDelayed    AlterationKind = "delayed"
+)
+
+// Alteration records an imperfection applied to a span
+type Alteration struct {
+	Kind    AlterationKind
+	TraceID span.TraceID
+	SpanID  span.ID
+	Service string
+	Span    string
+	// Detail describes the alteration further, such as the skew of the timestamps
+	Detail string
+}
+
+// Report lists the alterations applied to the spans, in the order they were applied
+type Report struct {
+	Alterations []Alteration
+}
+
+// Count returns the number of alterations of the kind
+func (r Report) Count(kind AlterationKind) int {
+	count := 0
+	for _, a := range r.Alterations {
+		if a.Kind == kind {
+			count++
+		}
+	}
+	return count
+}
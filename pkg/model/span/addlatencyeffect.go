@@ -0,0 +1,28 @@
+package span
+
+import (
+	"fmt"
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+)
+
+var _ Effect = (*AddLatencyEffect)(nil)
+
+// AddLatencyEffect is a conditional definition effect that extends the end of the span by additional latency.
+type AddLatencyEffect struct {
+	latency task.Delay
+}
+
+func (a AddLatencyEffect) Apply(node *TreeNode) error {
+	duration := node.endTime.Sub(node.startTime)
+	latency, err := a.latency.Resolve(&duration)
+	if err != nil {
+		return fmt.Errorf("failed to resolve latency: %w", err)
+	}
+	node.endTime = node.endTime.Add(*latency)
+	return nil
+}
+
+// FromAddLatencyEffect converts a task AddLatencyEffect to a span AddLatencyEffect.
+func FromAddLatencyEffect(spec task.AddLatencyEffect) Effect {
+	return AddLatencyEffect{latency: spec.Latency()}
+}
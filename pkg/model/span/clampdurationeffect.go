@@ -0,0 +1,32 @@
+package span
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"time"
+)
+
+var _ Effect = (*ClampDurationEffect)(nil)
+
+// ClampDurationEffect is a conditional definition effect that keeps the duration of the span between min and max.
+type ClampDurationEffect struct {
+	min time.Duration
+	max time.Duration
+}
+
+func (c ClampDurationEffect) Apply(node *TreeNode) error {
+	duration := node.endTime.Sub(node.startTime)
+	switch {
+	case duration <= 0:
+		node.endTime = node.startTime.Add(c.min)
+	case duration < c.min:
+		node.stretch(node.startTime, float64(c.min)/float64(duration))
+	case c.max > 0 && duration > c.max:
+		node.stretch(node.startTime, float64(c.max)/float64(duration))
+	}
+	return nil
+}
+
+// FromClampDurationEffect converts a task ClampDurationEffect to a span ClampDurationEffect.
+func FromClampDurationEffect(spec task.ClampDurationEffect) Effect {
+	return ClampDurationEffect{min: spec.Min(), max: spec.Max()}
+}
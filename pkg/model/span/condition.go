@@ -26,6 +26,9 @@ func FromConditionSpec(spec task.Condition) (Condition, error) {
 		if spec.HasAttribute() == nil {
 			return nil, fmt.Errorf("hasAttribute condition requires a key")
 		}
+		if value := spec.HasAttribute().Value(); value != nil {
+			return NewHasAttributeValue(spec.HasAttribute().Key(), *value), nil
+		}
 		return NewHasAttribute(spec.HasAttribute().Key()), nil
 	case task.ConditionKindChild:
 		if spec.Child() == nil {
@@ -0,0 +1,28 @@
+package span
+
+import (
+	"fmt"
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+)
+
+var _ Effect = (*DelayStartEffect)(nil)
+
+// DelayStartEffect is a conditional definition effect that delays the start of the span together with its children and events.
+type DelayStartEffect struct {
+	delay task.Delay
+}
+
+func (d DelayStartEffect) Apply(node *TreeNode) error {
+	duration := node.endTime.Sub(node.startTime)
+	delay, err := d.delay.Resolve(&duration)
+	if err != nil {
+		return fmt.Errorf("failed to resolve delay: %w", err)
+	}
+	node.ShiftTimestamps(*delay)
+	return nil
+}
+
+// FromDelayStartEffect converts a task DelayStartEffect to a span DelayStartEffect.
+func FromDelayStartEffect(spec task.DelayStartEffect) Effect {
+	return DelayStartEffect{delay: spec.Delay()}
+}
@@ -0,0 +1,36 @@
+package span
+
+import "time"
+
+// RemoveChild removes the child from the children of the span, keeping the parent ID of the child.
+// It reports whether the child was found. A removed child can be delivered on its own, like a span whose parent
+// was lost or exported in another batch.
+func (n *TreeNode) RemoveChild(child *TreeNode) bool {
+	for i, c := range n.children {
+		if c == child {
+			n.children = append(n.children[:i:i], n.children[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// CopyWithoutChildren returns a copy of the span with the same IDs but without its children, like a span exported twice
+func (n *TreeNode) CopyWithoutChildren() *TreeNode {
+	cp := *n
+	cp.children = []*TreeNode{}
+	cp.attributes = copyAttributes(n.attributes)
+	cp.events = n.Events()
+	cp.linkedTo = n.LinkedTo()
+	return &cp
+}
+
+// SkewTimestamps shifts the timestamps of the span and its events without its children, like a span recorded on a
+// host whose clock is off
+func (n *TreeNode) SkewTimestamps(delta time.Duration) {
+	n.startTime = n.startTime.Add(delta)
+	n.endTime = n.endTime.Add(delta)
+	for i := range n.events {
+		n.events[i].ShiftOccurredAt(delta)
+	}
+}
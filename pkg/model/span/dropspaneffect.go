@@ -0,0 +1,21 @@
+package span
+
+import "github.com/k4ji/tracesimulator/pkg/model/task"
+
+var _ Effect = (*DropSpanEffect)(nil)
+
+// DropSpanEffect is a conditional definition effect that removes the span from the trace.
+// The span is removed by its parent once all effects have been applied.
+type DropSpanEffect struct {
+	mode task.DropMode
+}
+
+func (d DropSpanEffect) Apply(node *TreeNode) error {
+	node.dropMode = d.mode
+	return nil
+}
+
+// FromDropSpanEffect converts a task DropSpanEffect to a span DropSpanEffect.
+func FromDropSpanEffect(spec task.DropSpanEffect) Effect {
+	return DropSpanEffect{mode: spec.Mode()}
+}
@@ -0,0 +1,138 @@
+package span
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/k4ji/tracesimulator/pkg/model/task/taskduration"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestFromTaskTree_DurationEffects(t *testing.T) {
+	type expectation struct {
+		start, end, childStart, childEnd, event time.Duration
+	}
+	type testCase struct {
+		name     string
+		effect   task.Effect
+		expected expectation
+	}
+
+	baseTime := time.Now()
+	traceID := NewTraceID([16]byte{0x01})
+	idGen := func() ID { return NewSpanID([8]byte{0x01}) }
+	scale, _ := task.NewScaleDurationEffect(4)
+	shrink, _ := task.NewClampDurationEffect(0, 500*time.Millisecond)
+	extend, _ := task.NewClampDurationEffect(2*time.Second, 0)
+	sampled, _ := taskduration.NewUniformDuration(100*time.Millisecond, 300*time.Millisecond, func() float64 { return 0.5 })
+	sampledDelay, _ := task.NewDelay(sampled)
+
+	testCases := []testCase{
+		{
+			name:     "scale the duration together with children and events",
+			effect:   task.FromScaleDurationEffect(*scale),
+			expected: expectation{start: 0, end: 4 * time.Second, childStart: 800 * time.Millisecond, childEnd: 3200 * time.Millisecond, event: 2 * time.Second},
+		},
+		{
+			name:     "add sampled latency at the end",
+			effect:   task.FromAddLatencyEffect(task.NewAddLatencyEffect(*sampledDelay)),
+			expected: expectation{start: 0, end: 1200 * time.Millisecond, childStart: 200 * time.Millisecond, childEnd: 800 * time.Millisecond, event: 500 * time.Millisecond},
+		},
+		{
+			name:     "add relative latency at the end",
+			effect:   task.FromAddLatencyEffect(task.NewAddLatencyEffect(NewRelativeDurationDelay(0.5))),
+			expected: expectation{start: 0, end: 1500 * time.Millisecond, childStart: 200 * time.Millisecond, childEnd: 800 * time.Millisecond, event: 500 * time.Millisecond},
+		},
+		{
+			name:     "clamp the duration to the maximum",
+			effect:   task.FromClampDurationEffect(*shrink),
+			expected: expectation{start: 0, end: 500 * time.Millisecond, childStart: 100 * time.Millisecond, childEnd: 400 * time.Millisecond, event: 250 * time.Millisecond},
+		},
+		{
+			name:     "clamp the duration to the minimum",
+			effect:   task.FromClampDurationEffect(*extend),
+			expected: expectation{start: 0, end: 2 * time.Second, childStart: 400 * time.Millisecond, childEnd: 1600 * time.Millisecond, event: 1 * time.Second},
+		},
+		{
+			name:     "delay the start together with children and events",
+			effect:   task.FromDelayStartEffect(task.NewDelayStartEffect(NewAbsoluteDurationDelay(300 * time.Millisecond))),
+			expected: expectation{start: 300 * time.Millisecond, end: 1300 * time.Millisecond, childStart: 500 * time.Millisecond, childEnd: 1100 * time.Millisecond, event: 800 * time.Millisecond},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root := testTask{
+				name:                   "root-task",
+				kind:                   task.KindServer,
+				duration:               NewAbsoluteDurationDuration(1 * time.Second),
+				events:                 []task.Event{task.NewEvent("event", NewAbsoluteDurationDelay(500*time.Millisecond), nil)},
+				conditionalDefinitions: always(tc.effect),
+				children: []testTask{
+					{name: "child-task", kind: task.KindClient, delay: NewAbsoluteDurationDelay(200 * time.Millisecond), duration: NewAbsoluteDurationDuration(600 * time.Millisecond)},
+				},
+			}
+			rootSpan, err := FromTaskTree(root.node(), traceID, baseTime, idGen)
+			assert.NoError(t, err)
+			assert.Equal(t, baseTime.Add(tc.expected.start), rootSpan.StartTime())
+			assert.Equal(t, baseTime.Add(tc.expected.end), rootSpan.EndTime())
+			assert.Equal(t, baseTime.Add(tc.expected.childStart), rootSpan.Children()[0].StartTime())
+			assert.Equal(t, baseTime.Add(tc.expected.childEnd), rootSpan.Children()[0].EndTime())
+			assert.Equal(t, baseTime.Add(tc.expected.event), rootSpan.Events()[0].OccurredAt())
+		})
+	}
+}
+
+func TestFromTaskTree_DurationEffectsWithTimeout(t *testing.T) {
+	type testCase struct {
+		name           string
+		effect         task.Effect
+		expectedEnd    time.Duration
+		expectedStatus StatusCode
+	}
+
+	baseTime := time.Now()
+	traceID := NewTraceID([16]byte{0x01})
+	idGen := func() ID { return NewSpanID([8]byte{0x01}) }
+	scale, _ := task.NewScaleDurationEffect(4)
+
+	testCases := []testCase{
+		{
+			name:           "cut the span extended by added latency past the timeout",
+			effect:         task.FromAddLatencyEffect(task.NewAddLatencyEffect(NewAbsoluteDurationDelay(800 * time.Millisecond))),
+			expectedEnd:    1500 * time.Millisecond,
+			expectedStatus: StatusCodeError,
+		},
+		{
+			name:           "cut the span scaled past the timeout",
+			effect:         task.FromScaleDurationEffect(*scale),
+			expectedEnd:    1500 * time.Millisecond,
+			expectedStatus: StatusCodeError,
+		},
+		{
+			name:           "keep the span extended within the timeout",
+			effect:         task.FromAddLatencyEffect(task.NewAddLatencyEffect(NewAbsoluteDurationDelay(200 * time.Millisecond))),
+			expectedEnd:    1200 * time.Millisecond,
+			expectedStatus: StatusCodeOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			timeout, err := task.NewTimeout(1500*time.Millisecond, task.TimeoutChildPolicyTruncate)
+			assert.NoError(t, err)
+			root := testTask{
+				name:                   "root-task",
+				kind:                   task.KindServer,
+				duration:               NewAbsoluteDurationDuration(1 * time.Second),
+				conditionalDefinitions: always(tc.effect),
+				options:                []task.DefinitionOption{task.WithTimeout(timeout)},
+			}
+			rootSpan, err := FromTaskTree(root.node(), traceID, baseTime, idGen)
+			assert.NoError(t, err)
+			assert.Equal(t, baseTime.Add(tc.expectedEnd), rootSpan.EndTime())
+			status := rootSpan.Status()
+			assert.Equal(t, tc.expectedStatus, status.Code())
+		})
+	}
+}
@@ -11,6 +11,19 @@ type Effect interface {
 	Apply(node *TreeNode) error
 }
 
+// spanGeneratingEffect is an Effect that creates new spans and therefore needs to generate span IDs.
+type spanGeneratingEffect interface {
+	applyWithIDGenerator(node *TreeNode, idGen func() ID) error
+}
+
+// applyEffect applies the effect to the node, providing the ID generator to effects that create new spans.
+func applyEffect(effect Effect, node *TreeNode, idGen func() ID) error {
+	if e, ok := effect.(spanGeneratingEffect); ok {
+		return e.applyWithIDGenerator(node, idGen)
+	}
+	return effect.Apply(node)
+}
+
 // FromEffectSpec converts a task effect specification to an Effect..
 func FromEffectSpec(spec task.Effect) (Effect, error) {
 	switch spec.Kind() {
@@ -30,6 +43,53 @@ func FromEffectSpec(spec task.Effect) (Effect, error) {
 			return nil, fmt.Errorf("annotate effect is nil")
 		}
 		return FromTaskAnnotateEffect(*spec.AnnotateEffect()), nil
+	case task.EffectKindFailWithException:
+		if spec.FailWithExceptionEffect() == nil {
+			return nil, fmt.Errorf("fail with exception effect is nil")
+		}
+		return FromFailWithExceptionEffect(*spec.FailWithExceptionEffect()), nil
+	case task.EffectKindScaleDuration:
+		if spec.ScaleDurationEffect() == nil {
+			return nil, fmt.Errorf("scale duration effect is nil")
+		}
+		return FromScaleDurationEffect(*spec.ScaleDurationEffect()), nil
+	case task.EffectKindAddLatency:
+		if spec.AddLatencyEffect() == nil {
+			return nil, fmt.Errorf("add latency effect is nil")
+		}
+		return FromAddLatencyEffect(*spec.AddLatencyEffect()), nil
+	case task.EffectKindClampDuration:
+		if spec.ClampDurationEffect() == nil {
+			return nil, fmt.Errorf("clamp duration effect is nil")
+		}
+		return FromClampDurationEffect(*spec.ClampDurationEffect()), nil
+	case task.EffectKindDelayStart:
+		if spec.DelayStartEffect() == nil {
+			return nil, fmt.Errorf("delay start effect is nil")
+		}
+		return FromDelayStartEffect(*spec.DelayStartEffect()), nil
+	case task.EffectKindDropSpan:
+		if spec.DropSpanEffect() == nil {
+			return nil, fmt.Errorf("drop span effect is nil")
+		}
+		return FromDropSpanEffect(*spec.DropSpanEffect()), nil
+	case task.EffectKindShortCircuit:
+		return NewShortCircuitEffect(), nil
+	case task.EffectKindInjectChild:
+		if spec.InjectChildEffect() == nil || spec.InjectChildEffect().Template() == nil {
+			return nil, fmt.Errorf("inject child effect requires a template")
+		}
+		return FromInjectChildEffect(*spec.InjectChildEffect()), nil
+	case task.EffectKindUpdateTraceState:
+		if spec.UpdateTraceStateEffect() == nil {
+			return nil, fmt.Errorf("update trace state effect is nil")
+		}
+		return FromUpdateTraceStateEffect(*spec.UpdateTraceStateEffect()), nil
+	case task.EffectKindSetTraceFlags:
+		if spec.SetTraceFlagsEffect() == nil {
+			return nil, fmt.Errorf("set trace flags effect is nil")
+		}
+		return FromSetTraceFlagsEffect(*spec.SetTraceFlagsEffect()), nil
 	default:
 		return nil, fmt.Errorf("unknown effect type: %s", spec.Kind())
 	}
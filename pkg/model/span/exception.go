@@ -0,0 +1,23 @@
+package span
+
+import "time"
+
+// Names of the exception event and its attributes, following the OpenTelemetry semantic conventions
+const (
+	ExceptionEventName              = "exception"
+	ExceptionTypeAttributeKey       = "exception.type"
+	ExceptionMessageAttributeKey    = "exception.message"
+	ExceptionStacktraceAttributeKey = "exception.stacktrace"
+)
+
+// newExceptionEvent creates an event that records an exception, with the stack trace if given
+func newExceptionEvent(occurredAt time.Time, exceptionType string, message string, stacktrace string) Event {
+	attributes := map[string]string{
+		ExceptionTypeAttributeKey:    exceptionType,
+		ExceptionMessageAttributeKey: message,
+	}
+	if stacktrace != "" {
+		attributes[ExceptionStacktraceAttributeKey] = stacktrace
+	}
+	return NewEvent(ExceptionEventName, occurredAt, attributes)
+}
@@ -0,0 +1,35 @@
+package span
+
+import (
+	"fmt"
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+)
+
+var _ Effect = (*FailWithExceptionEffect)(nil)
+
+// FailWithExceptionEffect is a conditional definition effect that marks the span as failed and records an exception event.
+type FailWithExceptionEffect struct {
+	spec task.FailWithExceptionEffect
+}
+
+func (f *FailWithExceptionEffect) Apply(node *TreeNode) error {
+	service := ""
+	if node.resource != nil {
+		service = node.resource.Name()
+	}
+	catalog := f.spec.Catalog(service)
+	if catalog == nil {
+		return fmt.Errorf("no exception catalog found for service %s", service)
+	}
+	exception := catalog.Pick()
+	message := exception.Message()
+	stacktrace := generateStacktrace(catalog.Language(), exception.Name(), message, service, node.name)
+	node.status = StatusError(&message)
+	node.events = append(node.events, newExceptionEvent(node.endTime, exception.Name(), message, stacktrace))
+	return nil
+}
+
+// FromFailWithExceptionEffect converts a task FailWithExceptionEffect to a span FailWithExceptionEffect.
+func FromFailWithExceptionEffect(spec task.FailWithExceptionEffect) Effect {
+	return &FailWithExceptionEffect{spec: spec}
+}
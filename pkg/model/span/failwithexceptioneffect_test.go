@@ -0,0 +1,96 @@
+package span
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestFailWithExceptionEffect_Apply(t *testing.T) {
+	type testCase struct {
+		name               string
+		service            string
+		expectedType       string
+		expectedMessage    string
+		expectedStacktrace func(stacktrace string) bool
+	}
+
+	newCatalog := func(language task.ExceptionLanguage, name string, message string) *task.ExceptionCatalog {
+		e, _ := task.NewExceptionType(name, message, 1)
+		c, _ := task.NewExceptionCatalog(language, []task.ExceptionType{*e}, func() float64 { return 0 })
+		return c
+	}
+	now := time.Now()
+	newNode := func(service string) *TreeNode {
+		return &TreeNode{
+			name:      "GET /checkout",
+			resource:  task.NewResource(service, nil),
+			startTime: now,
+			endTime:   now.Add(time.Second),
+			status:    StatusOK,
+		}
+	}
+	effect := FromFailWithExceptionEffect(task.NewFailWithExceptionEffect(
+		newCatalog(task.ExceptionLanguageJava, "java.net.SocketTimeoutException", "Read timed out"),
+		map[string]*task.ExceptionCatalog{
+			"payment": newCatalog(task.ExceptionLanguagePython, "requests.exceptions.ConnectionError", "connection refused"),
+		},
+	))
+
+	testCases := []testCase{
+		{
+			name:            "mark the span as failed and record an exception event",
+			service:         "checkout",
+			expectedType:    "java.net.SocketTimeoutException",
+			expectedMessage: "Read timed out",
+			expectedStacktrace: func(stacktrace string) bool {
+				return strings.HasPrefix(stacktrace, "java.net.SocketTimeoutException: Read timed out\n\tat com.example.checkout.CheckoutHandler.getCheckout(CheckoutHandler.java:")
+			},
+		},
+		{
+			name:            "use the catalog of the service",
+			service:         "payment",
+			expectedType:    "requests.exceptions.ConnectionError",
+			expectedMessage: "connection refused",
+			expectedStacktrace: func(stacktrace string) bool {
+				return strings.HasPrefix(stacktrace, "Traceback (most recent call last):") &&
+					strings.HasSuffix(stacktrace, "requests.exceptions.ConnectionError: connection refused")
+			},
+		},
+		{
+			name:            "capitalize non-ASCII service names in the frames",
+			service:         "élan",
+			expectedType:    "java.net.SocketTimeoutException",
+			expectedMessage: "Read timed out",
+			expectedStacktrace: func(stacktrace string) bool {
+				return utf8.ValidString(stacktrace) && strings.Contains(stacktrace, "\tat com.example.élan.ÉlanHandler.getCheckout(ÉlanHandler.java:")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			node := newNode(tc.service)
+			assert.NoError(t, effect.Apply(node))
+
+			assert.Equal(t, StatusCodeError, node.status.code)
+			assert.Equal(t, tc.expectedMessage, *node.status.message)
+			assert.Len(t, node.events, 1)
+
+			event := node.events[0]
+			assert.Equal(t, ExceptionEventName, event.Name())
+			assert.Equal(t, now.Add(time.Second), event.OccurredAt())
+			assert.Equal(t, tc.expectedType, event.Attributes()[ExceptionTypeAttributeKey])
+			assert.Equal(t, tc.expectedMessage, event.Attributes()[ExceptionMessageAttributeKey])
+			assert.True(t, tc.expectedStacktrace(event.Attributes()[ExceptionStacktraceAttributeKey]))
+		})
+	}
+
+	t.Run("return error when no catalog is available", func(t *testing.T) {
+		effect := FromFailWithExceptionEffect(task.NewFailWithExceptionEffect(nil, nil))
+		assert.Error(t, effect.Apply(newNode("checkout")))
+	})
+}
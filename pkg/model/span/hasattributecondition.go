@@ -1,8 +1,9 @@
 package span
 
-// HasAttribute is a condition that checks if a node has a specific attribute.
+// HasAttribute is a condition that checks if a node has a specific attribute, optionally with a specific value.
 type HasAttribute struct {
-	key string
+	key   string
+	value *string
 }
 
 func NewHasAttribute(key string) HasAttribute {
@@ -11,7 +12,18 @@ func NewHasAttribute(key string) HasAttribute {
 	}
 }
 
+// NewHasAttributeValue creates a condition that checks if a node has the attribute with the given value.
+func NewHasAttributeValue(key string, value string) HasAttribute {
+	return HasAttribute{
+		key:   key,
+		value: &value,
+	}
+}
+
 func (c HasAttribute) Evaluate(target *TreeNode) (*ConditionEvaluationResult, error) {
-	_, ok := target.Attributes()[c.key]
+	v, ok := target.Attributes()[c.key]
+	if ok && c.value != nil {
+		ok = v == *c.value
+	}
 	return NewConditionEvaluationResult([]bool{ok}, false), nil
 }
@@ -0,0 +1,30 @@
+package span
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHasAttribute_Evaluate(t *testing.T) {
+	type testCase struct {
+		name      string
+		condition HasAttribute
+		expected  bool
+	}
+
+	node := &TreeNode{attributes: map[string]string{"rpc.grpc.status_code": "14"}}
+	testCases := []testCase{
+		{name: "attribute present", condition: NewHasAttribute("rpc.grpc.status_code"), expected: true},
+		{name: "attribute missing", condition: NewHasAttribute("rpc.method"), expected: false},
+		{name: "attribute with the value", condition: NewHasAttributeValue("rpc.grpc.status_code", "14"), expected: true},
+		{name: "attribute with another value", condition: NewHasAttributeValue("rpc.grpc.status_code", "0"), expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := tc.condition.Evaluate(node)
+			assert.NoError(t, err)
+			assert.Equal(t, []bool{tc.expected}, result.evaluations)
+		})
+	}
+}
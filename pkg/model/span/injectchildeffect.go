@@ -0,0 +1,89 @@
+package span
+
+import (
+	"fmt"
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+)
+
+var _ Effect = (*InjectChildEffect)(nil)
+
+// InjectChildEffect is a conditional definition effect that adds a subtree built from a template as a child of the span.
+type InjectChildEffect struct {
+	template *task.Template
+}
+
+// Apply always fails since injecting a subtree requires generating span IDs.
+func (i InjectChildEffect) Apply(_ *TreeNode) error {
+	return fmt.Errorf("inject child effect requires a span ID generator")
+}
+
+func (i InjectChildEffect) applyWithIDGenerator(node *TreeNode, idGen func() ID) error {
+	duration := node.endTime.Sub(node.startTime)
+	child, err := fromTaskNode(i.template.Root(), node.traceID, &node.id, &duration, nil, node.context(), node.startTime, idGen)
+	if err != nil {
+		return fmt.Errorf("failed to build subtree from template %s: %w", i.template.Name(), err)
+	}
+	child.inheritResource(node.resource)
+	if err := child.scopeExternalIDs(node.id.String()); err != nil {
+		return fmt.Errorf("failed to scope ExternalIDs of template %s: %w", i.template.Name(), err)
+	}
+	node.children = append(node.children, child)
+	return nil
+}
+
+// scopeExternalIDs suffixes the ExternalIDs of the injected subtree with the ID of the span it is injected into,
+// so that a template injected more than once yields unique ExternalIDs. Links within the subtree follow the renaming.
+func (n *TreeNode) scopeExternalIDs(suffix string) error {
+	renamed := make(map[task.ExternalID]*task.ExternalID)
+	var rename func(node *TreeNode) error
+	rename = func(node *TreeNode) error {
+		if node.externalID != nil {
+			scoped, err := task.NewExternalID(node.externalID.Value() + "-" + suffix)
+			if err != nil {
+				return err
+			}
+			renamed[*node.externalID] = scoped
+			node.externalID = scoped
+		}
+		for _, child := range append(node.Children(), node.dropped...) {
+			if err := rename(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	var relink func(node *TreeNode)
+	relink = func(node *TreeNode) {
+		linkedTo := make([]*task.ExternalID, len(node.linkedToExternalID))
+		for i, id := range node.linkedToExternalID {
+			linkedTo[i] = id
+			if scoped, ok := renamed[*id]; ok {
+				linkedTo[i] = scoped
+			}
+		}
+		node.linkedToExternalID = linkedTo
+		for _, child := range append(node.Children(), node.dropped...) {
+			relink(child)
+		}
+	}
+	if err := rename(n); err != nil {
+		return err
+	}
+	relink(n)
+	return nil
+}
+
+// inheritResource sets the resource of the span and its descendants that have no resource of their own
+func (n *TreeNode) inheritResource(resource *task.Resource) {
+	if n.resource == nil {
+		n.resource = resource
+	}
+	for _, child := range n.children {
+		child.inheritResource(n.resource)
+	}
+}
+
+// FromInjectChildEffect converts a task InjectChildEffect to a span InjectChildEffect.
+func FromInjectChildEffect(spec task.InjectChildEffect) Effect {
+	return InjectChildEffect{template: spec.Template()}
+}
@@ -0,0 +1,71 @@
+package span
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"time"
+)
+
+// childLayout computes the base start time of each child according to the layout of the parent
+type childLayout struct {
+	layout task.ChildLayout
+	// start is the time from which the children are laid out
+	start time.Time
+	// stepStart is the base start time of the children in the current step
+	stepStart time.Time
+	// stepEnd is the latest end time of the children laid out so far
+	stepEnd time.Time
+	// group is the layout group of the previous child
+	group string
+	// started is true once the first child has been laid out
+	started bool
+	// sequenceEnds holds the end time of the child laid out last in each layout sequence
+	sequenceEnds map[string]time.Time
+}
+
+func newChildLayout(layout task.ChildLayout, start time.Time) *childLayout {
+	return &childLayout{
+		layout:       layout,
+		start:        start,
+		stepStart:    start,
+		stepEnd:      start,
+		sequenceEnds: make(map[string]time.Time),
+	}
+}
+
+// next returns the base start time of the next child, from which its delay is applied.
+// In a sequential layout, each child starts after the previous one ends,
+// except for consecutive children in the same group, which start together.
+// In any layout, a child starts after the previous child of the same sequence ends.
+func (l *childLayout) next(group string, sequence string) time.Time {
+	if end, ok := l.sequenceEnds[sequence]; ok && sequence != "" {
+		if l.layout == task.ChildLayoutSequential {
+			l.stepStart = end
+			l.group = group
+		}
+		return end
+	}
+	if l.layout != task.ChildLayoutSequential {
+		return l.start
+	}
+	if l.started && (group == "" || group != l.group) {
+		l.stepStart = l.stepEnd
+	}
+	l.started = true
+	l.group = group
+	return l.stepStart
+}
+
+// finished records the end time of the child laid out last
+func (l *childLayout) finished(sequence string, end time.Time) {
+	if sequence != "" {
+		l.sequenceEnds[sequence] = end
+	}
+	if end.After(l.stepEnd) {
+		l.stepEnd = end
+	}
+}
+
+// end returns the latest end time of the children
+func (l *childLayout) end() time.Time {
+	return l.stepEnd
+}
@@ -0,0 +1,180 @@
+package span
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/k4ji/tracesimulator/pkg/model/task/taskduration"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestFromTaskTree_ChildLayout(t *testing.T) {
+	type expectedSpan struct {
+		start, end time.Duration
+	}
+	type testCase struct {
+		name             string
+		root             testTask
+		expectedChildren []expectedSpan
+		expectedEnd      time.Duration
+		expectError      bool
+	}
+
+	baseTime := time.Now()
+	traceID := NewTraceID([16]byte{0x01})
+	autoDuration := func(before time.Duration, after time.Duration) task.Duration {
+		expr, _ := taskduration.NewAutoDuration(before, after)
+		d, _ := task.NewDuration(expr)
+		return *d
+	}
+	formulaDelay := func(source string) task.Delay {
+		f, _ := taskduration.Parse(source, nil)
+		d, _ := task.NewDelay(f)
+		return *d
+	}
+	formulaDuration := func(source string) task.Duration {
+		f, _ := taskduration.Parse(source, nil)
+		d, _ := task.NewDuration(f)
+		return *d
+	}
+	sequential := []task.DefinitionOption{task.WithChildLayout(task.ChildLayoutSequential)}
+
+	testCases := []testCase{
+		{
+			name: "start each child after the previous sibling ends",
+			root: testTask{
+				name:     "root",
+				duration: NewAbsoluteDurationDuration(time.Second),
+				options:  sequential,
+				children: []testTask{
+					{name: "first", duration: NewAbsoluteDurationDuration(100 * time.Millisecond)},
+					{name: "second", delay: NewAbsoluteDurationDelay(10 * time.Millisecond), duration: NewAbsoluteDurationDuration(200 * time.Millisecond)},
+					{name: "third", duration: NewAbsoluteDurationDuration(50 * time.Millisecond)},
+				},
+			},
+			expectedChildren: []expectedSpan{
+				{start: 0, end: 100 * time.Millisecond},
+				{start: 110 * time.Millisecond, end: 310 * time.Millisecond},
+				{start: 310 * time.Millisecond, end: 360 * time.Millisecond},
+			},
+			expectedEnd: time.Second,
+		},
+		{
+			name: "run consecutive children of the same group in parallel",
+			root: testTask{
+				name:     "root",
+				duration: NewAbsoluteDurationDuration(time.Second),
+				options:  sequential,
+				children: []testTask{
+					{name: "auth", duration: NewAbsoluteDurationDuration(100 * time.Millisecond)},
+					{name: "profile", duration: NewAbsoluteDurationDuration(200 * time.Millisecond), options: []task.DefinitionOption{task.WithLayoutGroup("fetch")}},
+					{name: "cart", duration: NewAbsoluteDurationDuration(300 * time.Millisecond), options: []task.DefinitionOption{task.WithLayoutGroup("fetch")}},
+					{name: "render", duration: NewAbsoluteDurationDuration(50 * time.Millisecond)},
+				},
+			},
+			expectedChildren: []expectedSpan{
+				{start: 0, end: 100 * time.Millisecond},
+				{start: 100 * time.Millisecond, end: 300 * time.Millisecond},
+				{start: 100 * time.Millisecond, end: 400 * time.Millisecond},
+				{start: 400 * time.Millisecond, end: 450 * time.Millisecond},
+			},
+			expectedEnd: time.Second,
+		},
+		{
+			name: "start children of the same sequence after each other in a parallel layout",
+			root: testTask{
+				name:     "root",
+				duration: NewAbsoluteDurationDuration(time.Second),
+				children: []testTask{
+					{name: "query", delay: NewAbsoluteDurationDelay(10 * time.Millisecond), duration: NewAbsoluteDurationDuration(100 * time.Millisecond), options: []task.DefinitionOption{task.WithLayoutSequence("loop")}},
+					{name: "query", delay: NewAbsoluteDurationDelay(5 * time.Millisecond), duration: NewAbsoluteDurationDuration(100 * time.Millisecond), options: []task.DefinitionOption{task.WithLayoutSequence("loop")}},
+					{name: "other", duration: NewAbsoluteDurationDuration(50 * time.Millisecond)},
+				},
+			},
+			expectedChildren: []expectedSpan{
+				{start: 10 * time.Millisecond, end: 110 * time.Millisecond},
+				{start: 115 * time.Millisecond, end: 215 * time.Millisecond},
+				{start: 0, end: 50 * time.Millisecond},
+			},
+			expectedEnd: time.Second,
+		},
+		{
+			name: "derive the duration of the parent from its children",
+			root: testTask{
+				name:     "root",
+				duration: autoDuration(0, 0),
+				options:  sequential,
+				children: []testTask{
+					{name: "first", duration: NewAbsoluteDurationDuration(100 * time.Millisecond)},
+					{name: "second", duration: NewAbsoluteDurationDuration(200 * time.Millisecond)},
+				},
+			},
+			expectedChildren: []expectedSpan{
+				{start: 0, end: 100 * time.Millisecond},
+				{start: 100 * time.Millisecond, end: 300 * time.Millisecond},
+			},
+			expectedEnd: 300 * time.Millisecond,
+		},
+		{
+			name: "add self time before and after the children",
+			root: testTask{
+				name:     "root",
+				duration: autoDuration(20*time.Millisecond, 30*time.Millisecond),
+				children: []testTask{
+					{name: "first", delay: NewAbsoluteDurationDelay(10 * time.Millisecond), duration: NewAbsoluteDurationDuration(100 * time.Millisecond)},
+					{name: "second", duration: NewAbsoluteDurationDuration(50 * time.Millisecond)},
+				},
+			},
+			expectedChildren: []expectedSpan{
+				{start: 30 * time.Millisecond, end: 130 * time.Millisecond},
+				{start: 20 * time.Millisecond, end: 70 * time.Millisecond},
+			},
+			expectedEnd: 160 * time.Millisecond,
+		},
+		{
+			name:             "size a span without children to its self time",
+			root:             testTask{name: "root", duration: autoDuration(20*time.Millisecond, 30*time.Millisecond)},
+			expectedChildren: []expectedSpan{},
+			expectedEnd:      50 * time.Millisecond,
+		},
+		{
+			name:        "return error when an auto duration has neither children nor self time",
+			root:        testTask{name: "root", duration: autoDuration(0, 0)},
+			expectError: true,
+		},
+		{
+			name: "resolve formulas against the parent and preceding siblings",
+			root: testTask{
+				name:     "root",
+				duration: NewAbsoluteDurationDuration(time.Second),
+				children: []testTask{
+					{name: "db", delay: NewAbsoluteDurationDelay(10 * time.Millisecond), duration: formulaDuration("parent * 0.1")},
+					{name: "render", delay: formulaDelay(`sibling("db") + 5ms`), duration: formulaDuration(`max(20ms, sibling("db") / 4)`)},
+				},
+			},
+			expectedChildren: []expectedSpan{
+				{start: 10 * time.Millisecond, end: 110 * time.Millisecond},
+				{start: 115 * time.Millisecond, end: 142500 * time.Microsecond},
+			},
+			expectedEnd: time.Second,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rootSpan, err := FromTaskTree(tc.root.node(), traceID, baseTime, sequentialSpanIDs())
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			children := rootSpan.Children()
+			assert.Len(t, children, len(tc.expectedChildren))
+			for i, expected := range tc.expectedChildren {
+				assert.Equal(t, baseTime.Add(expected.start), children[i].StartTime())
+				assert.Equal(t, baseTime.Add(expected.end), children[i].EndTime())
+			}
+			assert.Equal(t, baseTime.Add(tc.expectedEnd), rootSpan.EndTime())
+		})
+	}
+}
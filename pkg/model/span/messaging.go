@@ -0,0 +1,159 @@
+package span
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// messagingGraph holds the producers and consumers of the queues across all traces
+type messagingGraph struct {
+	// producers holds the publishing spans of each queue
+	producers map[string][]*TreeNode
+	// consumers holds the consuming spans of each queue ordered by their start time
+	consumers map[string][]*TreeNode
+	// roots maps each span to the root span of its trace
+	roots map[*TreeNode]*TreeNode
+	// batches maps each consumer to the producers of the messages it consumes
+	batches map[*TreeNode][]*TreeNode
+}
+
+// ResolveMessaging schedules the consumers of the queues after the messages they consume.
+// A consumer must be the root span of its trace, and the whole consuming trace is shifted so that the consumer
+// starts after the dwell time from the end of the latest producer of its batch. The consumer is linked to the
+// producers of its batch.
+// Messages are consumed in FIFO order of the end times of the producers once they are at their final position,
+// each consumer taking up to its batch size of messages in the order of the start times of the consumers.
+func ResolveMessaging(roots []*TreeNode) error {
+	graph := &messagingGraph{
+		producers: make(map[string][]*TreeNode),
+		consumers: make(map[string][]*TreeNode),
+		roots:     make(map[*TreeNode]*TreeNode),
+		batches:   make(map[*TreeNode][]*TreeNode),
+	}
+	for _, root := range roots {
+		if err := graph.collect(root, root); err != nil {
+			return err
+		}
+	}
+	if len(graph.consumers) == 0 {
+		return nil
+	}
+	for _, consumers := range graph.consumers {
+		sort.SliceStable(consumers, func(i, j int) bool {
+			return consumers[i].startTime.Before(consumers[j].startTime)
+		})
+	}
+
+	// consumers are scheduled once the producers of their queue are at their final position
+	const (
+		visiting = iota + 1
+		scheduled
+	)
+	states := make(map[*TreeNode]int)
+	assigned := make(map[string]bool)
+	var path []string
+	var schedule func(consumer *TreeNode) error
+	schedule = func(consumer *TreeNode) error {
+		switch states[consumer] {
+		case scheduled:
+			return nil
+		case visiting:
+			return fmt.Errorf("messaging cycle detected: %s -> %s", strings.Join(path, " -> "), consumer.name)
+		}
+		states[consumer] = visiting
+		path = append(path, consumer.name)
+
+		// consumers moving the producers of the queue go first
+		queue := consumer.consumption.Queue()
+		for _, producer := range graph.producers[queue] {
+			if root := graph.roots[producer]; root.consumption != nil {
+				if err := schedule(root); err != nil {
+					return err
+				}
+			}
+		}
+		if !assigned[queue] {
+			if err := graph.assignBatches(queue); err != nil {
+				return err
+			}
+			assigned[queue] = true
+		}
+
+		dwell, err := consumer.consumption.Dwell().Resolve(nil)
+		if err != nil {
+			return fmt.Errorf("failed to resolve dwell time of %s: %w", consumer.name, err)
+		}
+		var available time.Time
+		for i, producer := range graph.batches[consumer] {
+			if i == 0 || producer.endTime.After(available) {
+				available = producer.endTime
+			}
+		}
+		consumer.ShiftTimestamps(available.Add(*dwell).Sub(consumer.startTime))
+		consumer.linkedTo = append(consumer.linkedTo, graph.batches[consumer]...)
+
+		path = path[:len(path)-1]
+		states[consumer] = scheduled
+		return nil
+	}
+	for _, queue := range sortedQueues(graph.consumers) {
+		for _, consumer := range graph.consumers[queue] {
+			if err := schedule(consumer); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (g *messagingGraph) collect(node *TreeNode, root *TreeNode) error {
+	g.roots[node] = root
+	if node.publishTo != "" {
+		g.producers[node.publishTo] = append(g.producers[node.publishTo], node)
+	}
+	if node.consumption != nil {
+		if node != root {
+			return fmt.Errorf("consumer %s must be the root span of its trace", node.name)
+		}
+		queue := node.consumption.Queue()
+		g.consumers[queue] = append(g.consumers[queue], node)
+	}
+	for _, child := range node.children {
+		if err := g.collect(child, root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assignBatches hands out the messages of the queue to its consumers in FIFO order
+func (g *messagingGraph) assignBatches(queue string) error {
+	producers := g.producers[queue]
+	sort.SliceStable(producers, func(i, j int) bool {
+		return producers[i].endTime.Before(producers[j].endTime)
+	})
+	next := 0
+	for _, consumer := range g.consumers[queue] {
+		if next == len(producers) {
+			return fmt.Errorf("no message available in queue %s for consumer %s", queue, consumer.name)
+		}
+		end := next + consumer.consumption.BatchSize()
+		if end > len(producers) {
+			end = len(producers)
+		}
+		g.batches[consumer] = producers[next:end]
+		next = end
+	}
+	return nil
+}
+
+func sortedQueues(consumers map[string][]*TreeNode) []string {
+	queues := make([]string, 0, len(consumers))
+	for queue := range consumers {
+		queues = append(queues, queue)
+	}
+	sort.Strings(queues)
+	return queues
+}
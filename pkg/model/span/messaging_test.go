@@ -0,0 +1,173 @@
+package span
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestResolveMessaging(t *testing.T) {
+	type testCase struct {
+		name   string
+		traces []testTask
+		// expectedStarts and expectedLinks are those of the spans by name
+		expectedStarts map[string]time.Duration
+		expectedLinks  map[string][]string
+	}
+
+	baseTime := time.Now()
+	testCases := []testCase{
+		{
+			name: "start the consuming trace after the dwell time",
+			traces: []testTask{
+				messagingTask("producer", 200*time.Millisecond, 100*time.Millisecond, task.WithPublish("orders")),
+				{
+					name:     "consumer",
+					duration: NewAbsoluteDurationDuration(100 * time.Millisecond),
+					options:  []task.DefinitionOption{consume("orders", 50*time.Millisecond, 1)},
+					children: []testTask{messagingTask("process", 10*time.Millisecond, 20*time.Millisecond)},
+				},
+			},
+			expectedStarts: map[string]time.Duration{"consumer": 350 * time.Millisecond, "process": 360 * time.Millisecond},
+			expectedLinks:  map[string][]string{"consumer": {"producer"}},
+		},
+		{
+			name: "consume messages in FIFO order and in batches",
+			traces: []testTask{
+				messagingTask("late", 300*time.Millisecond, 100*time.Millisecond, task.WithPublish("orders")),
+				messagingTask("early", 0, 100*time.Millisecond, task.WithPublish("orders")),
+				messagingTask("middle", 100*time.Millisecond, 100*time.Millisecond, task.WithPublish("orders")),
+				messagingTask("batch", 0, 10*time.Millisecond, consume("orders", 0, 2)),
+				messagingTask("single", time.Millisecond, 10*time.Millisecond, consume("orders", 0, 2)),
+			},
+			expectedStarts: map[string]time.Duration{"batch": 200 * time.Millisecond, "single": 400 * time.Millisecond},
+			expectedLinks:  map[string][]string{"batch": {"early", "middle"}, "single": {"late"}},
+		},
+		{
+			name: "schedule chained consumers across traces",
+			traces: []testTask{
+				messagingTask("final", 0, 100*time.Millisecond, consume("step-2", 10*time.Millisecond, 1)),
+				messagingTask("publish", 0, 100*time.Millisecond, task.WithPublish("step-1")),
+				messagingTask("relay", 0, 100*time.Millisecond, consume("step-1", 10*time.Millisecond, 1), task.WithPublish("step-2")),
+			},
+			expectedStarts: map[string]time.Duration{"relay": 110 * time.Millisecond, "final": 220 * time.Millisecond},
+			expectedLinks:  map[string][]string{"relay": {"publish"}, "final": {"relay"}},
+		},
+		{
+			name: "consume messages in FIFO order of the shifted producers",
+			traces: []testTask{
+				messagingTask("source", 0, 300*time.Millisecond, task.WithPublish("step-1")),
+				{
+					name:     "relay",
+					duration: NewAbsoluteDurationDuration(100 * time.Millisecond),
+					options:  []task.DefinitionOption{consume("step-1", 0, 1)},
+					children: []testTask{messagingTask("forward", 0, 10*time.Millisecond, task.WithPublish("step-2"))},
+				},
+				messagingTask("direct", 0, 150*time.Millisecond, task.WithPublish("step-2")),
+				messagingTask("first", 0, 10*time.Millisecond, consume("step-2", 0, 1)),
+				messagingTask("second", time.Millisecond, 10*time.Millisecond, consume("step-2", 0, 1)),
+			},
+			expectedStarts: map[string]time.Duration{"forward": 300 * time.Millisecond, "first": 150 * time.Millisecond, "second": 310 * time.Millisecond},
+			expectedLinks:  map[string][]string{"first": {"direct"}, "second": {"forward"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spans := messagingSpans(t, tc.traces, baseTime)
+			assert.NoError(t, ResolveMessaging(spans))
+
+			byName := make(map[string]*TreeNode)
+			var walk func(nodes []*TreeNode)
+			walk = func(nodes []*TreeNode) {
+				for _, n := range nodes {
+					byName[n.Name()] = n
+					walk(n.Children())
+				}
+			}
+			walk(spans)
+			for name, start := range tc.expectedStarts {
+				assert.Equal(t, baseTime.Add(start), byName[name].StartTime(), name)
+			}
+			for name, links := range tc.expectedLinks {
+				assert.Equal(t, links, spanNames(byName[name].LinkedTo()), name)
+			}
+		})
+	}
+}
+
+func TestResolveMessagingError(t *testing.T) {
+	type testCase struct {
+		name          string
+		traces        []testTask
+		expectedError string
+	}
+
+	testCases := []testCase{
+		{
+			name: "return error if no message is available",
+			traces: []testTask{
+				messagingTask("producer", 0, 100*time.Millisecond, task.WithPublish("orders")),
+				messagingTask("first", 0, 100*time.Millisecond, consume("orders", 0, 1)),
+				messagingTask("second", 0, 100*time.Millisecond, consume("orders", 0, 1)),
+			},
+			expectedError: "no message available in queue orders for consumer second",
+		},
+		{
+			name: "return error if a consumer waits for its own descendant",
+			traces: []testTask{{
+				name:     "consumer",
+				duration: NewAbsoluteDurationDuration(time.Second),
+				options:  []task.DefinitionOption{consume("orders", 0, 1)},
+				children: []testTask{messagingTask("producer", 0, 100*time.Millisecond, task.WithPublish("orders"))},
+			}},
+			expectedError: "messaging cycle detected",
+		},
+		{
+			name: "return error if a consumer is not the root span of its trace",
+			traces: []testTask{
+				messagingTask("producer", 0, 100*time.Millisecond, task.WithPublish("orders")),
+				{
+					name:     "root",
+					duration: NewAbsoluteDurationDuration(time.Second),
+					children: []testTask{messagingTask("consumer", 0, 100*time.Millisecond, consume("orders", 0, 1))},
+				},
+			},
+			expectedError: "consumer consumer must be the root span of its trace",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.ErrorContains(t, ResolveMessaging(messagingSpans(t, tc.traces, time.Now())), tc.expectedError)
+		})
+	}
+}
+
+// messagingTask returns a leaf task publishing or consuming messages by the given options
+func messagingTask(name string, delay time.Duration, duration time.Duration, options ...task.DefinitionOption) testTask {
+	return testTask{
+		name:     name,
+		delay:    NewAbsoluteDurationDelay(delay),
+		duration: NewAbsoluteDurationDuration(duration),
+		options:  options,
+	}
+}
+
+func consume(queue string, dwell time.Duration, batchSize int) task.DefinitionOption {
+	c, _ := task.NewConsumption(queue, NewAbsoluteDurationDelay(dwell), batchSize)
+	return task.WithConsumption(c)
+}
+
+// messagingSpans converts each task to a trace of its own
+func messagingSpans(t *testing.T, traces []testTask, baseTime time.Time) []*TreeNode {
+	idGen := sequentialSpanIDs()
+	spans := make([]*TreeNode, len(traces))
+	for i, trace := range traces {
+		s, err := FromTaskTree(trace.node(), NewTraceID([16]byte{byte(i + 1)}), baseTime, idGen)
+		assert.NoError(t, err)
+		spans[i] = s
+	}
+	return spans
+}
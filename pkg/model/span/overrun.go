@@ -0,0 +1,22 @@
+package span
+
+import (
+	"fmt"
+	"time"
+)
+
+// ValidateChildrenWithinParent returns an error if any descendant starts before or ends after its parent
+func (n *TreeNode) ValidateChildrenWithinParent() error {
+	for _, child := range n.children {
+		if child.startTime.Before(n.startTime) {
+			return fmt.Errorf("span %s starts %s before its parent %s", child.name, n.startTime.Sub(child.startTime), n.name)
+		}
+		if child.endTime.After(n.endTime) {
+			return fmt.Errorf("span %s ends %s after its parent %s", child.name, child.endTime.Sub(n.endTime).Round(time.Microsecond), n.name)
+		}
+		if err := child.ValidateChildrenWithinParent(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package span
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// RetryAttemptAttributeKey is the attribute set on generated retry attempt spans, holding the attempt number starting from 1
+const RetryAttemptAttributeKey = "retry.attempt"
+
+// retryChain holds a failed span and the attempt spans generated for it
+type retryChain struct {
+	failed   *TreeNode
+	attempts []*TreeNode
+}
+
+// extension returns how much later the last attempt ends compared to the failed span
+func (c retryChain) extension() time.Duration {
+	if len(c.attempts) == 0 {
+		return 0
+	}
+	return c.attempts[len(c.attempts)-1].endTime.Sub(c.failed.endTime)
+}
+
+// generateRetryAttempts simulates retry attempts of a failed client span according to the policy.
+// Attempts are generated until one succeeds or the maximum number of attempts is reached.
+func generateRetryAttempts(failed *TreeNode, policy *task.RetryPolicy, idGen func() ID) retryChain {
+	chain := retryChain{failed: failed, attempts: make([]*TreeNode, 0)}
+	if policy == nil || failed.kind != KindClient || failed.status.code != StatusCodeError {
+		return chain
+	}
+
+	duration := failed.endTime.Sub(failed.startTime)
+	previousEnd := failed.endTime
+	for attempt := 1; attempt <= policy.MaxAttempts(); attempt++ {
+		startTime := previousEnd.Add(policy.Backoff().Resolve(attempt))
+		attributes := make(map[string]string, len(failed.attributes)+1)
+		for k, v := range failed.attributes {
+			attributes[k] = v
+		}
+		attributes[RetryAttemptAttributeKey] = strconv.Itoa(attempt)
+
+		status := failed.status
+		succeeded := policy.Randomness()() < policy.SuccessProbability(attempt)
+		if succeeded {
+			status = StatusOK
+		}
+
+		chain.attempts = append(chain.attempts, &TreeNode{
+			id:                   idGen(),
+			traceID:              failed.traceID,
+			name:                 failed.name,
+			isResourceEntryPoint: failed.isResourceEntryPoint,
+			resource:             failed.resource,
+			attributes:           attributes,
+			kind:                 failed.kind,
+			startTime:            startTime,
+			endTime:              startTime.Add(duration),
+			parentID:             failed.parentID,
+			externalID:           nil,
+			children:             []*TreeNode{},
+			linkedTo:             []*TreeNode{},
+			events:               []Event{},
+			linkedToExternalID:   failed.LinkedToExternalID(),
+			status:               status,
+			retryOf:              failed,
+			traceFlags:           failed.traceFlags,
+			traceState:           failed.traceState,
+		})
+		previousEnd = startTime.Add(duration)
+		if succeeded {
+			break
+		}
+	}
+	return chain
+}
+
+// insertRetryAttempts places the attempts of each chain right after the failed span,
+// shifts the siblings starting after the failed span, and extends the end time of the node accordingly.
+func (n *TreeNode) insertRetryAttempts(chains []retryChain) {
+	// Handle the latest failure first so that earlier chains also shift the attempts of later ones
+	sort.SliceStable(chains, func(i, j int) bool {
+		return chains[i].failed.endTime.After(chains[j].failed.endTime)
+	})
+	for _, chain := range chains {
+		if len(chain.attempts) == 0 {
+			continue
+		}
+		delta := chain.extension()
+		threshold := chain.failed.endTime
+		children := make([]*TreeNode, 0, len(n.children)+len(chain.attempts))
+		for _, child := range n.children {
+			if child != chain.failed && !child.startTime.Before(threshold) {
+				child.ShiftTimestamps(delta)
+			}
+			children = append(children, child)
+			if child == chain.failed {
+				children = append(children, chain.attempts...)
+			}
+		}
+		n.children = children
+		n.endTime = n.endTime.Add(delta)
+	}
+}
@@ -0,0 +1,124 @@
+package span
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestFromTaskTree_Retry(t *testing.T) {
+	type expectedSpan struct {
+		name    string
+		attempt string
+		status  StatusCode
+		start   time.Duration
+		end     time.Duration
+	}
+	type testCase struct {
+		name             string
+		children         []testTask
+		expectedChildren []expectedSpan
+		expectedEnd      time.Duration
+	}
+
+	baseTime := time.Now()
+	traceID := NewTraceID([16]byte{0x01})
+	alwaysFail := always(task.FromMarkAsFailedEffect(task.NewMarkAsFailedEffect(ptrString("error"))))
+	retried := func(kind task.Kind, duration time.Duration, policy *task.RetryPolicy) testTask {
+		return testTask{
+			name:                   "call",
+			kind:                   kind,
+			attributes:             map[string]string{"key": "value"},
+			duration:               NewAbsoluteDurationDuration(duration),
+			conditionalDefinitions: alwaysFail,
+			options:                []task.DefinitionOption{task.WithRetryPolicy(policy)},
+		}
+	}
+
+	testCases := []testCase{
+		{
+			name: "generate attempts until one succeeds and shift subsequent siblings",
+			children: []testTask{
+				retried(task.KindClient, 500*time.Millisecond, func() *task.RetryPolicy {
+					backoff, _ := task.NewFixedBackoff(100 * time.Millisecond)
+					// the first attempt fails and the second one succeeds
+					draws := []float64{0.9, 0.1}
+					policy, _ := task.NewRetryPolicy(3, *backoff, []float64{0.5}, func() float64 {
+						d := draws[0]
+						draws = draws[1:]
+						return d
+					})
+					return policy
+				}()),
+				{name: "next", delay: NewAbsoluteDurationDelay(500 * time.Millisecond), duration: NewAbsoluteDurationDuration(100 * time.Millisecond)},
+			},
+			expectedChildren: []expectedSpan{
+				{name: "call", status: StatusCodeError, start: 0, end: 500 * time.Millisecond},
+				{name: "call", attempt: "1", status: StatusCodeError, start: 600 * time.Millisecond, end: 1100 * time.Millisecond},
+				{name: "call", attempt: "2", status: StatusCodeOK, start: 1200 * time.Millisecond, end: 1700 * time.Millisecond},
+				// the sibling starting after the failure is shifted by the time spent on retries
+				{name: "next", status: StatusCodeOK, start: 1700 * time.Millisecond, end: 1800 * time.Millisecond},
+			},
+			expectedEnd: 3200 * time.Millisecond,
+		},
+		{
+			name: "stop after the maximum number of attempts",
+			children: []testTask{
+				retried(task.KindClient, 100*time.Millisecond, func() *task.RetryPolicy {
+					backoff, _ := task.NewExponentialBackoff(100*time.Millisecond, 2, 0)
+					policy, _ := task.NewRetryPolicy(3, *backoff, []float64{0.0}, func() float64 { return 0.5 })
+					return policy
+				}()),
+			},
+			// backoffs are 100ms, 200ms, and 400ms
+			expectedChildren: []expectedSpan{
+				{name: "call", status: StatusCodeError, start: 0, end: 100 * time.Millisecond},
+				{name: "call", attempt: "1", status: StatusCodeError, start: 200 * time.Millisecond, end: 300 * time.Millisecond},
+				{name: "call", attempt: "2", status: StatusCodeError, start: 500 * time.Millisecond, end: 600 * time.Millisecond},
+				{name: "call", attempt: "3", status: StatusCodeError, start: 1000 * time.Millisecond, end: 1100 * time.Millisecond},
+			},
+			expectedEnd: 3 * time.Second,
+		},
+		{
+			name: "do not retry spans other than client spans",
+			children: []testTask{
+				retried(task.KindInternal, 100*time.Millisecond, func() *task.RetryPolicy {
+					backoff, _ := task.NewFixedBackoff(100 * time.Millisecond)
+					policy, _ := task.NewRetryPolicy(3, *backoff, []float64{1.0}, func() float64 { return 0.0 })
+					return policy
+				}()),
+			},
+			expectedChildren: []expectedSpan{
+				{name: "call", status: StatusCodeError, start: 0, end: 100 * time.Millisecond},
+			},
+			expectedEnd: 2 * time.Second,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root := testTask{name: "root", kind: task.KindServer, duration: NewAbsoluteDurationDuration(2 * time.Second), children: tc.children}
+			rootSpan, err := FromTaskTree(root.node(), traceID, baseTime, sequentialSpanIDs())
+			assert.NoError(t, err)
+
+			children := rootSpan.Children()
+			assert.Len(t, children, len(tc.expectedChildren))
+			for i, expected := range tc.expectedChildren {
+				child := children[i]
+				assert.Equal(t, expected.name, child.Name())
+				assert.Equal(t, expected.status, child.status.code)
+				assert.Equal(t, baseTime.Add(expected.start), child.StartTime())
+				assert.Equal(t, baseTime.Add(expected.end), child.EndTime())
+				assert.Equal(t, rootSpan.ID(), *child.ParentID())
+				if expected.attempt == "" {
+					assert.NotContains(t, child.Attributes(), RetryAttemptAttributeKey)
+				} else {
+					assert.Equal(t, expected.attempt, child.Attributes()[RetryAttemptAttributeKey])
+					assert.Equal(t, "value", child.Attributes()["key"])
+				}
+			}
+			assert.Equal(t, baseTime.Add(tc.expectedEnd), rootSpan.EndTime())
+		})
+	}
+}
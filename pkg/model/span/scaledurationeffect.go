@@ -0,0 +1,20 @@
+package span
+
+import "github.com/k4ji/tracesimulator/pkg/model/task"
+
+var _ Effect = (*ScaleDurationEffect)(nil)
+
+// ScaleDurationEffect is a conditional definition effect that multiplies the duration of the span by a factor.
+type ScaleDurationEffect struct {
+	factor float64
+}
+
+func (s ScaleDurationEffect) Apply(node *TreeNode) error {
+	node.stretch(node.startTime, s.factor)
+	return nil
+}
+
+// FromScaleDurationEffect converts a task ScaleDurationEffect to a span ScaleDurationEffect.
+func FromScaleDurationEffect(spec task.ScaleDurationEffect) Effect {
+	return ScaleDurationEffect{factor: spec.Factor()}
+}
@@ -0,0 +1,25 @@
+package span
+
+import "github.com/k4ji/tracesimulator/pkg/model/task"
+
+var _ Effect = (*SetTraceFlagsEffect)(nil)
+
+// SetTraceFlagsEffect is a conditional definition effect that replaces the trace flags of the span and its descendants.
+type SetTraceFlagsEffect struct {
+	flags TraceFlags
+}
+
+func (s SetTraceFlagsEffect) Apply(node *TreeNode) error {
+	node.traceFlags = s.flags
+	for _, child := range node.children {
+		if err := s.Apply(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FromSetTraceFlagsEffect converts a task SetTraceFlagsEffect to a span SetTraceFlagsEffect.
+func FromSetTraceFlagsEffect(spec task.SetTraceFlagsEffect) SetTraceFlagsEffect {
+	return SetTraceFlagsEffect{flags: TraceFlags(spec.Flags())}
+}
@@ -0,0 +1,61 @@
+package span
+
+import (
+	"sort"
+	"time"
+)
+
+var _ Effect = (*ShortCircuitEffect)(nil)
+
+// ShortCircuitEffect is a conditional definition effect that drops all children starting after the first failed child.
+// A child whose retry attempts eventually succeed is not considered as failed.
+type ShortCircuitEffect struct{}
+
+// NewShortCircuitEffect creates a new ShortCircuitEffect.
+func NewShortCircuitEffect() ShortCircuitEffect {
+	return ShortCircuitEffect{}
+}
+
+func (s ShortCircuitEffect) Apply(node *TreeNode) error {
+	// Group retry attempts with the span they retry
+	attempts := make(map[*TreeNode][]*TreeNode)
+	originals := make([]*TreeNode, 0, len(node.children))
+	for _, child := range node.children {
+		if child.retryOf != nil {
+			attempts[child.retryOf] = append(attempts[child.retryOf], child)
+		} else {
+			originals = append(originals, child)
+		}
+	}
+	sort.SliceStable(originals, func(i, j int) bool {
+		return originals[i].startTime.Before(originals[j].startTime)
+	})
+
+	var failed *TreeNode
+	var cutoff time.Time
+	for _, original := range originals {
+		last := original
+		if a := attempts[original]; len(a) > 0 {
+			last = a[len(a)-1]
+		}
+		if last.status.code == StatusCodeError {
+			failed = original
+			cutoff = last.endTime
+			break
+		}
+	}
+	if failed == nil {
+		return nil
+	}
+
+	children := make([]*TreeNode, 0, len(node.children))
+	for _, child := range node.children {
+		if child == failed || child.retryOf == failed || child.startTime.Before(cutoff) {
+			children = append(children, child)
+		} else {
+			node.dropped = append(node.dropped, child)
+		}
+	}
+	node.children = children
+	return nil
+}
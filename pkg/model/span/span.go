@@ -3,6 +3,7 @@ package span
 import (
 	"fmt"
 	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/k4ji/tracesimulator/pkg/model/task/taskduration"
 	"time"
 )
 
@@ -24,6 +25,13 @@ type TreeNode struct {
 	events               []Event
 	linkedToExternalID   []*task.ExternalID
 	status               Status
+	dropMode             task.DropMode     // Set when the span is removed from the trace by an effect
+	dropped              []*TreeNode       // Dropped descendants, kept so that links to them can still be resolved
+	retryOf              *TreeNode         // Failed span this span is a retry attempt of (if any)
+	publishTo            string            // Queue the span publishes a message to when it ends (if any)
+	consumption          *task.Consumption // Queue the span consumes messages from (if any)
+	traceFlags           TraceFlags        // W3C trace flags, inherited from the parent unless the task defines them
+	traceState           string            // W3C tracestate header value, inherited like the trace flags
 }
 
 // FromTaskTree converts a task tree to a span tree
@@ -33,7 +41,15 @@ func FromTaskTree(
 	baseStartTime time.Time,
 	idGen func() ID,
 ) (*TreeNode, error) {
-	rootSpan, err := fromTaskNode(taskTree, traceID, nil, nil, baseStartTime, idGen)
+	// the root is checked up front so that the conversion does not fail only when the condition of the effect holds
+	for _, cd := range taskTree.Definition().ConditionalDefinitions() {
+		for _, effect := range cd.Effects() {
+			if effect.Kind() == task.EffectKindDropSpan {
+				return nil, fmt.Errorf("root task %s of a trace cannot have a drop span effect", taskTree.Definition().Name())
+			}
+		}
+	}
+	rootSpan, err := fromTaskNode(taskTree, traceID, nil, nil, nil, traceContext{}, baseStartTime, idGen)
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert task tree to span tree: %w", err)
 	}
@@ -48,36 +64,36 @@ func fromTaskNode(
 	traceID TraceID,
 	parentID *ID,
 	parentDuration *time.Duration,
+	siblingEndTimes map[string]time.Time,
+	inherited traceContext,
 	baseStartTime time.Time,
 	idGen func() ID,
 ) (*TreeNode, error) {
 	spanID := idGen()
-	delay, err := taskNode.Definition().Delay().Resolve(parentDuration)
-	if err != nil {
-		return nil, fmt.Errorf("failed to resolve delay: %w", err)
+	resolution := &taskduration.Context{
+		Parent:   parentDuration,
+		Siblings: make(map[string]time.Duration, len(siblingEndTimes)),
 	}
-	duration, err := taskNode.Definition().Duration().Resolve(parentDuration)
+	for name, endTime := range siblingEndTimes {
+		resolution.Siblings[name] = endTime.Sub(baseStartTime)
+	}
+	delay, err := taskNode.Definition().Delay().Resolve(resolution)
 	if err != nil {
-		return nil, fmt.Errorf("failed to resolve duration: %w", err)
+		return nil, fmt.Errorf("failed to resolve delay: %w", err)
 	}
-
 	startTime := baseStartTime.Add(*delay)
-	endTime := startTime.Add(*duration)
 
-	events := make([]Event, len(taskNode.Definition().Events()))
-	for i, event := range taskNode.Definition().Events() {
-		d, err := event.Delay().Resolve(duration)
+	// An auto-sized span is resolved once its children are laid out
+	var duration *time.Duration
+	auto, isAuto := taskNode.Definition().Duration().Auto()
+	childrenStartTime := startTime
+	if isAuto {
+		childrenStartTime = startTime.Add(auto.Before())
+	} else {
+		duration, err = taskNode.Definition().Duration().Resolve(resolution)
 		if err != nil {
-			return nil, fmt.Errorf("failed to resolve event delay: %w", err)
-		}
-		if *d > *duration {
-			return nil, fmt.Errorf("event delay cannot be greater than task duration")
+			return nil, fmt.Errorf("failed to resolve duration: %w", err)
 		}
-		events[i] = NewEvent(
-			event.Name(),
-			startTime.Add(*d),
-			event.Attributes(),
-		)
 	}
 
 	node := TreeNode{
@@ -86,26 +102,67 @@ func fromTaskNode(
 		name:                 taskNode.Definition().Name(),
 		isResourceEntryPoint: taskNode.Definition().IsResourceEntryPoint(),
 		resource:             taskNode.Definition().Resource(),
-		attributes:           taskNode.Definition().Attributes(),
+		attributes:           copyAttributes(taskNode.Definition().Attributes()),
 		kind:                 FromTaskKind(taskNode.Definition().Kind()),
 		startTime:            startTime,
-		endTime:              endTime,
 		parentID:             parentID,
 		externalID:           taskNode.Definition().ExternalID(),
 		children:             []*TreeNode{},
 		linkedTo:             []*TreeNode{},
-		events:               events,
 		linkedToExternalID:   taskNode.Definition().LinkedTo(),
 		status:               StatusOK,
+		publishTo:            taskNode.Definition().PublishTo(),
+		consumption:          taskNode.Definition().Consumption(),
 	}
+	node.setTraceContext(resolveTraceContext(taskNode.Definition(), inherited))
 
+	retryChains := make([]retryChain, 0)
+	childEndTimes := make(map[string]time.Time)
+	layout := newChildLayout(taskNode.Definition().ChildLayout(), childrenStartTime)
 	for _, childTask := range taskNode.Children() {
-		childSpan, err := fromTaskNode(childTask, traceID, &spanID, duration, startTime, idGen)
+		childBaseStartTime := layout.next(childTask.Definition().LayoutGroup(), childTask.Definition().LayoutSequence())
+		childSpan, err := fromTaskNode(childTask, traceID, &spanID, duration, childEndTimes, node.context(), childBaseStartTime, idGen)
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert child task to span: %w", err)
 		}
+		layout.finished(childTask.Definition().LayoutSequence(), childSpan.endTime)
+		childEndTimes[childSpan.name] = childSpan.endTime
+		if childSpan.dropMode != "" {
+			node.dropChild(childSpan)
+			continue
+		}
 		node.children = append(node.children, childSpan)
+		if policy := childTask.Definition().RetryPolicy(); policy != nil {
+			retryChains = append(retryChains, generateRetryAttempts(childSpan, policy, idGen))
+		}
+	}
+
+	if isAuto {
+		d := layout.end().Sub(startTime) + auto.After()
+		if d <= 0 {
+			return nil, fmt.Errorf("failed to resolve duration: auto duration requires a child or self time")
+		}
+		duration = &d
 	}
+	node.endTime = startTime.Add(*duration)
+
+	node.events = make([]Event, len(taskNode.Definition().Events()))
+	for i, event := range taskNode.Definition().Events() {
+		d, err := event.Delay().Resolve(duration)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve event delay: %w", err)
+		}
+		if *d > *duration {
+			return nil, fmt.Errorf("event delay cannot be greater than task duration")
+		}
+		node.events[i] = NewEvent(
+			event.Name(),
+			startTime.Add(*d),
+			event.Attributes(),
+		)
+	}
+
+	node.insertRetryAttempts(retryChains)
 
 	for _, spec := range taskNode.Definition().ConditionalDefinitions() {
 		condition, err := FromConditionSpec(spec.Condition())
@@ -126,16 +183,47 @@ func fromTaskNode(
 				if err != nil {
 					return nil, fmt.Errorf("failed to convert effect spec to effect: %w", err)
 				}
-				if err := effect.Apply(&node); err != nil {
+				if err := applyEffect(effect, &node, idGen); err != nil {
 					return nil, fmt.Errorf("failed to apply effect: %w", err)
 				}
 			}
 		}
 	}
 
+	// The timeout is applied last so that it also cuts the spans extended or delayed by the effects
+	if timeout := taskNode.Definition().Timeout(); timeout != nil {
+		node.applyTimeout(timeout)
+	}
+
 	return &node, nil
 }
 
+// copyAttributes copies the attributes of a task so that effects modifying them do not leak into later spans
+func copyAttributes(attributes map[string]string) map[string]string {
+	if attributes == nil {
+		return nil
+	}
+	cp := make(map[string]string, len(attributes))
+	for k, v := range attributes {
+		cp[k] = v
+	}
+	return cp
+}
+
+// dropChild removes a child dropped by an effect, attaching its children to this span if requested
+func (n *TreeNode) dropChild(child *TreeNode) {
+	n.dropped = append(n.dropped, child)
+	if child.dropMode != task.DropModeReparent {
+		return
+	}
+	for _, grandchild := range child.children {
+		parentID := n.id
+		grandchild.parentID = &parentID
+		n.children = append(n.children, grandchild)
+	}
+	child.children = []*TreeNode{}
+}
+
 func (n *TreeNode) validate() error {
 	// it returns an error if the externalID is not unique
 	externalIDToSpan := make(map[task.ExternalID]*TreeNode)
@@ -171,6 +259,21 @@ func (n *TreeNode) ShiftTimestamps(delta time.Duration) {
 	}
 }
 
+// stretch scales the offsets of the timestamps of the span and its descendants from the origin by a factor
+func (n *TreeNode) stretch(origin time.Time, factor float64) {
+	scale := func(t time.Time) time.Time {
+		return origin.Add(time.Duration(float64(t.Sub(origin)) * factor))
+	}
+	n.startTime = scale(n.startTime)
+	n.endTime = scale(n.endTime)
+	for i := range n.events {
+		n.events[i].occurredAt = scale(n.events[i].occurredAt)
+	}
+	for _, child := range n.children {
+		child.stretch(origin, factor)
+	}
+}
+
 // ExternalIDToSpan returns a map of external IDs to the span and its children
 func (n *TreeNode) ExternalIDToSpan() map[task.ExternalID]*TreeNode {
 	// it returns an error if the externalID is not unique
@@ -178,7 +281,7 @@ func (n *TreeNode) ExternalIDToSpan() map[task.ExternalID]*TreeNode {
 	if n.externalID != nil {
 		externalIDToSpan[*n.externalID] = n
 	}
-	for _, child := range n.children {
+	for _, child := range append(n.Children(), n.dropped...) {
 		childExternalIDToSpan := child.ExternalIDToSpan()
 		for id, span := range childExternalIDToSpan {
 			externalIDToSpan[id] = span
@@ -276,3 +379,29 @@ func (n *TreeNode) LinkedToExternalID() []*task.ExternalID {
 func (n *TreeNode) Status() Status {
 	return n.status
 }
+
+func (n *TreeNode) TraceFlags() TraceFlags {
+	return n.traceFlags
+}
+
+func (n *TreeNode) TraceState() string {
+	return n.traceState
+}
+
+// SetTraceFlags sets the W3C trace flags of the span
+func (n *TreeNode) SetTraceFlags(flags TraceFlags) {
+	n.traceFlags = flags
+}
+
+// SetTraceState sets the W3C tracestate header value of the span
+func (n *TreeNode) SetTraceState(state string) {
+	n.traceState = state
+}
+
+// SetAttribute sets an attribute of the span, such as the decision metadata of a sampling stage
+func (n *TreeNode) SetAttribute(key string, value string) {
+	if n.attributes == nil {
+		n.attributes = make(map[string]string)
+	}
+	n.attributes[key] = value
+}
@@ -1236,6 +1236,67 @@ func TestFromTaskTreeError(t *testing.T) {
 	}
 }
 
+func TestFromTaskTreeKeepsTaskAttributes(t *testing.T) {
+	type testCase struct {
+		name       string
+		attributes map[string]string
+		effects    []task.Effect
+		expected   map[string]string
+	}
+
+	testCases := []testCase{
+		{
+			name:       "annotate effect does not modify the attributes of the task",
+			attributes: map[string]string{"team": "team-a"},
+			effects: []task.Effect{
+				task.FromAnnotateEffect(task.NewAnnotateEffect(map[string]string{"http.response.status_code": "500"})),
+			},
+			expected: map[string]string{"team": "team-a", "http.response.status_code": "500"},
+		},
+		{
+			name:       "annotate effect overriding an attribute does not modify the attributes of the task",
+			attributes: map[string]string{"team": "team-a"},
+			effects: []task.Effect{
+				task.FromAnnotateEffect(task.NewAnnotateEffect(map[string]string{"team": "team-b"})),
+			},
+			expected: map[string]string{"team": "team-b"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			def, err := task.NewDefinition(
+				"root-task",
+				true,
+				task.NewResource("service-a", make(map[string]string)),
+				tc.attributes,
+				task.KindInternal,
+				nil,
+				NewAbsoluteDurationDelay(0),
+				NewAbsoluteDurationDuration(2*time.Second),
+				nil,
+				[]*task.ExternalID{},
+				[]task.Event{},
+				[]*task.ConditionalDefinition{
+					task.NewConditionalDefinition(
+						task.NewProbabilisticCondition(1.0, func() float64 { return 0 }),
+						tc.effects,
+					),
+				},
+			)
+			assert.NoError(t, err)
+			original := make(map[string]string, len(tc.attributes))
+			for k, v := range tc.attributes {
+				original[k] = v
+			}
+			node, err := FromTaskTree(task.NewTreeNode(def), NewTraceID([16]byte{0x01}), time.Now(), func() ID { return NewSpanID([8]byte{0x01}) })
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, node.Attributes())
+			assert.Equal(t, original, def.Attributes())
+		})
+	}
+}
+
 func TestShiftTimestamps(t *testing.T) {
 	now := time.Now()
 	rootNodeStartTime := now.Add(0 * time.Second)
@@ -1289,3 +1350,87 @@ func NewRelativeDurationDuration(v float64) task.Duration {
 func ptrString(s string) *string {
 	return &s
 }
+
+// testTask describes a task tree of the tests, unset fields default to an internal task of service-a without delay
+type testTask struct {
+	name                   string
+	kind                   task.Kind
+	resource               *task.Resource
+	attributes             map[string]string
+	externalID             string
+	delay                  task.Delay
+	duration               task.Duration
+	events                 []task.Event
+	conditionalDefinitions []*task.ConditionalDefinition
+	options                []task.DefinitionOption
+	children               []testTask
+}
+
+// node converts the testTask and its children to a task.TreeNode
+func (tt testTask) node() *task.TreeNode {
+	kind := tt.kind
+	if kind == task.KindUnknown {
+		kind = task.KindInternal
+	}
+	resource := tt.resource
+	if resource == nil {
+		resource = task.NewResource("service-a", make(map[string]string))
+	}
+	attributes := tt.attributes
+	if attributes == nil {
+		attributes = make(map[string]string)
+	}
+	var externalID *task.ExternalID
+	if tt.externalID != "" {
+		externalID, _ = task.NewExternalID(tt.externalID)
+	}
+	delay := tt.delay
+	if delay.Expression() == nil {
+		delay = NewAbsoluteDurationDelay(0)
+	}
+	def, _ := task.NewDefinition(
+		tt.name,
+		false,
+		resource,
+		attributes,
+		kind,
+		externalID,
+		delay,
+		tt.duration,
+		nil,
+		[]*task.ExternalID{},
+		tt.events,
+		tt.conditionalDefinitions,
+		tt.options...,
+	)
+	node := task.NewTreeNode(def)
+	for _, child := range tt.children {
+		_ = node.AddChild(child.node())
+	}
+	return node
+}
+
+// always returns a conditional definition applying the effects every time
+func always(effects ...task.Effect) []*task.ConditionalDefinition {
+	return []*task.ConditionalDefinition{
+		task.NewConditionalDefinition(task.NewProbabilisticCondition(1.0, func() float64 { return 0.0 }), effects),
+	}
+}
+
+// sequentialSpanIDs returns a generator of the span IDs 1, 2, 3 and so on
+func sequentialSpanIDs() func() ID {
+	var i byte
+	return func() ID {
+		i++
+		return NewSpanID([8]byte{i})
+	}
+}
+
+// spanNames returns the names of the spans in order
+func spanNames(nodes []*TreeNode) []string {
+	names := make([]string, len(nodes))
+	for i, n := range nodes {
+		names[i] = n.Name()
+	}
+	return names
+}
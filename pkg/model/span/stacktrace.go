@@ -0,0 +1,92 @@
+package span
+
+import (
+	"fmt"
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"hash/fnv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// generateStacktrace generates a stack trace in the format of the given language.
+// Frames are derived from the service and span names so that the same operation always yields the same stack trace.
+func generateStacktrace(language task.ExceptionLanguage, exceptionType string, message string, service string, operation string) string {
+	module := identifier(service, false)
+	className := identifier(service, true)
+	function := identifier(operation, false)
+	method := identifier(operation, true)
+	line := lineNumber(service, operation)
+
+	var b strings.Builder
+	switch language {
+	case task.ExceptionLanguageJava:
+		_, _ = fmt.Fprintf(&b, "%s: %s\n", exceptionType, message)
+		_, _ = fmt.Fprintf(&b, "\tat com.example.%s.%sHandler.%s(%sHandler.java:%d)\n", module, className, function, className, line)
+		_, _ = fmt.Fprintf(&b, "\tat com.example.%s.%sController.handle(%sController.java:%d)\n", module, className, className, line+27)
+		b.WriteString("\tat java.base/java.lang.Thread.run(Thread.java:833)")
+	case task.ExceptionLanguagePython:
+		b.WriteString("Traceback (most recent call last):\n")
+		_, _ = fmt.Fprintf(&b, "  File \"/app/%s/views.py\", line %d, in handle\n    return %s(request)\n", module, line+27, toSnakeCase(operation))
+		_, _ = fmt.Fprintf(&b, "  File \"/app/%s/service.py\", line %d, in %s\n    raise %s(%q)\n", module, line, toSnakeCase(operation), lastSegment(exceptionType, "."), message)
+		_, _ = fmt.Fprintf(&b, "%s: %s", exceptionType, message)
+	case task.ExceptionLanguageGo:
+		_, _ = fmt.Fprintf(&b, "%s: %s\n\ngoroutine 1 [running]:\n", exceptionType, message)
+		_, _ = fmt.Fprintf(&b, "main.%s(...)\n\t/app/%s/handler.go:%d +0x1d\n", function, module, line)
+		_, _ = fmt.Fprintf(&b, "main.main()\n\t/app/%s/main.go:%d +0x45", module, line+27)
+	case task.ExceptionLanguageNodeJS:
+		_, _ = fmt.Fprintf(&b, "%s: %s\n", exceptionType, message)
+		_, _ = fmt.Fprintf(&b, "    at %s (/app/%s/src/handler.js:%d:13)\n", function, module, line)
+		b.WriteString("    at process.processTicksAndRejections (node:internal/process/task_queues:95:5)")
+	case task.ExceptionLanguageDotNet:
+		_, _ = fmt.Fprintf(&b, "%s: %s\n", exceptionType, message)
+		_, _ = fmt.Fprintf(&b, "   at %s.%sHandler.%s() in /app/%s/%sHandler.cs:line %d\n", className, className, method, module, className, line)
+		_, _ = fmt.Fprintf(&b, "   at %s.Program.Main(String[] args) in /app/%s/Program.cs:line %d", className, module, line+27)
+	default:
+		_, _ = fmt.Fprintf(&b, "%s: %s", exceptionType, message)
+	}
+	return b.String()
+}
+
+// identifier converts a free-form name such as "GET /checkout" into an identifier such as "getCheckout"
+func identifier(name string, exported bool) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return "unknown"
+	}
+	var b strings.Builder
+	for i, w := range words {
+		w = strings.ToLower(w)
+		if i > 0 || exported {
+			r, size := utf8.DecodeRuneInString(w)
+			w = string(unicode.ToUpper(r)) + w[size:]
+		}
+		b.WriteString(w)
+	}
+	return b.String()
+}
+
+func toSnakeCase(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return "unknown"
+	}
+	return strings.Join(words, "_")
+}
+
+func lastSegment(s string, sep string) string {
+	return s[strings.LastIndex(s, sep)+1:]
+}
+
+// lineNumber returns a stable pseudo line number for the given names
+func lineNumber(names ...string) int {
+	h := fnv.New32a()
+	for _, n := range names {
+		_, _ = h.Write([]byte(n))
+	}
+	return int(h.Sum32()%400) + 10
+}
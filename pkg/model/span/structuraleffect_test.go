@@ -0,0 +1,208 @@
+package span
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestFromTaskTree_StructuralEffects(t *testing.T) {
+	type testCase struct {
+		name             string
+		root             testTask
+		expectedChildren []string
+		expectError      bool
+	}
+
+	baseTime := time.Now()
+	traceID := NewTraceID([16]byte{0x01})
+	dropReparent, _ := task.NewDropSpanEffect(task.DropModeReparent)
+	dropSubtree, _ := task.NewDropSpanEffect(task.DropModeSubtree)
+	shortCircuit := always(task.FromShortCircuitEffect(task.NewShortCircuitEffect()))
+	fail := always(task.FromMarkAsFailedEffect(task.NewMarkAsFailedEffect(ptrString("error"))))
+	backoff, _ := task.NewFixedBackoff(0)
+	policy, _ := task.NewRetryPolicy(1, *backoff, []float64{1.0}, func() float64 { return 0.0 })
+
+	testCases := []testCase{
+		{
+			name: "drop a span and attach its children to its parent",
+			root: testTask{
+				name:     "root",
+				duration: NewAbsoluteDurationDuration(time.Second),
+				children: []testTask{
+					{
+						name:                   "middle",
+						duration:               NewAbsoluteDurationDuration(time.Second),
+						conditionalDefinitions: always(task.FromDropSpanEffect(*dropReparent)),
+						children:               []testTask{{name: "leaf", duration: NewAbsoluteDurationDuration(time.Second)}},
+					},
+				},
+			},
+			expectedChildren: []string{"leaf"},
+		},
+		{
+			name: "drop a span with its subtree",
+			root: testTask{
+				name:     "root",
+				duration: NewAbsoluteDurationDuration(time.Second),
+				children: []testTask{
+					{
+						name:                   "middle",
+						duration:               NewAbsoluteDurationDuration(time.Second),
+						conditionalDefinitions: always(task.FromDropSpanEffect(*dropSubtree)),
+						children:               []testTask{{name: "leaf", duration: NewAbsoluteDurationDuration(time.Second)}},
+					},
+				},
+			},
+			expectedChildren: []string{},
+		},
+		{
+			name: "return error when dropping the root span",
+			root: testTask{
+				name:                   "root",
+				duration:               NewAbsoluteDurationDuration(time.Second),
+				conditionalDefinitions: always(task.FromDropSpanEffect(*dropSubtree)),
+			},
+			expectError: true,
+		},
+		{
+			name: "return error for a drop span effect on the root even when its condition does not hold",
+			root: testTask{
+				name:     "root",
+				duration: NewAbsoluteDurationDuration(time.Second),
+				conditionalDefinitions: []*task.ConditionalDefinition{
+					task.NewConditionalDefinition(task.NewProbabilisticCondition(0.05, func() float64 { return 0.99 }), []task.Effect{task.FromDropSpanEffect(*dropSubtree)}),
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "drop children after the first failure",
+			root: testTask{
+				name:                   "root",
+				duration:               NewAbsoluteDurationDuration(2 * time.Second),
+				conditionalDefinitions: shortCircuit,
+				children: []testTask{
+					{name: "first", duration: NewAbsoluteDurationDuration(100 * time.Millisecond)},
+					{name: "parallel", delay: NewAbsoluteDurationDelay(150 * time.Millisecond), duration: NewAbsoluteDurationDuration(500 * time.Millisecond)},
+					{name: "failing", delay: NewAbsoluteDurationDelay(100 * time.Millisecond), duration: NewAbsoluteDurationDuration(100 * time.Millisecond), conditionalDefinitions: fail},
+					{name: "after", delay: NewAbsoluteDurationDelay(300 * time.Millisecond), duration: NewAbsoluteDurationDuration(100 * time.Millisecond)},
+				},
+			},
+			expectedChildren: []string{"first", "parallel", "failing"},
+		},
+		{
+			name: "do not short-circuit when a retry succeeds",
+			root: testTask{
+				name:                   "root",
+				duration:               NewAbsoluteDurationDuration(2 * time.Second),
+				conditionalDefinitions: shortCircuit,
+				children: []testTask{
+					{
+						name:                   "failing",
+						kind:                   task.KindClient,
+						duration:               NewAbsoluteDurationDuration(100 * time.Millisecond),
+						conditionalDefinitions: fail,
+						options:                []task.DefinitionOption{task.WithRetryPolicy(policy)},
+					},
+					{name: "after", delay: NewAbsoluteDurationDelay(300 * time.Millisecond), duration: NewAbsoluteDurationDuration(100 * time.Millisecond)},
+				},
+			},
+			expectedChildren: []string{"failing", "failing", "after"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rootSpan, err := FromTaskTree(tc.root.node(), traceID, baseTime, sequentialSpanIDs())
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedChildren, spanNames(rootSpan.Children()))
+			for _, child := range rootSpan.Children() {
+				assert.Equal(t, rootSpan.ID(), *child.ParentID())
+			}
+		})
+	}
+
+	t.Run("keep dropped spans reachable by their ExternalIDs so that links to them are kept", func(t *testing.T) {
+		root := testTask{
+			name:       "root",
+			externalID: "root",
+			duration:   NewAbsoluteDurationDuration(time.Second),
+			children: []testTask{
+				{
+					name:                   "middle",
+					externalID:             "middle",
+					duration:               NewAbsoluteDurationDuration(time.Second),
+					conditionalDefinitions: always(task.FromDropSpanEffect(*dropReparent)),
+				},
+			},
+		}
+		rootSpan, err := FromTaskTree(root.node(), traceID, baseTime, sequentialSpanIDs())
+		assert.NoError(t, err)
+		assert.Len(t, rootSpan.Children(), 0)
+		assert.Len(t, rootSpan.ExternalIDToSpan(), 2)
+	})
+
+	t.Run("inject a subtree from a template", func(t *testing.T) {
+		// the template task has no resource so that it inherits the resource of the task it is injected into
+		templateDef, _ := task.NewDefinition("cache-lookup", false, nil, make(map[string]string), task.KindClient, nil,
+			NewAbsoluteDurationDelay(100*time.Millisecond), NewAbsoluteDurationDuration(50*time.Millisecond), nil, []*task.ExternalID{}, []task.Event{}, nil)
+		template, _ := task.NewTemplate("cache", task.NewTreeNode(templateDef))
+		root := testTask{
+			name:                   "root",
+			duration:               NewAbsoluteDurationDuration(time.Second),
+			conditionalDefinitions: always(task.FromInjectChildEffect(task.NewInjectChildEffect(template))),
+		}
+
+		rootSpan, err := FromTaskTree(root.node(), traceID, baseTime, sequentialSpanIDs())
+		assert.NoError(t, err)
+		assert.Len(t, rootSpan.Children(), 1)
+		injected := rootSpan.Children()[0]
+		assert.Equal(t, "cache-lookup", injected.Name())
+		assert.Equal(t, rootSpan.Resource(), injected.Resource())
+		assert.Equal(t, rootSpan.ID(), *injected.ParentID())
+		assert.NotEqual(t, rootSpan.ID(), injected.ID())
+		assert.Equal(t, baseTime.Add(100*time.Millisecond), injected.StartTime())
+	})
+
+	t.Run("scope the ExternalIDs of a subtree injected more than once", func(t *testing.T) {
+		lookupID, _ := task.NewExternalID("lookup")
+		hitID, _ := task.NewExternalID("hit")
+		lookupDef, _ := task.NewDefinition("cache-lookup", false, nil, make(map[string]string), task.KindClient, lookupID,
+			NewAbsoluteDurationDelay(0), NewAbsoluteDurationDuration(50*time.Millisecond), nil, []*task.ExternalID{hitID}, []task.Event{}, nil)
+		hitDef, _ := task.NewDefinition("cache-hit", false, nil, make(map[string]string), task.KindInternal, hitID,
+			NewAbsoluteDurationDelay(0), NewAbsoluteDurationDuration(10*time.Millisecond), nil, []*task.ExternalID{}, []task.Event{}, nil)
+		lookup := task.NewTreeNode(lookupDef)
+		_ = lookup.AddChild(task.NewTreeNode(hitDef))
+		template, _ := task.NewTemplate("cache", lookup)
+		inject := always(task.FromInjectChildEffect(task.NewInjectChildEffect(template)))
+		root := testTask{
+			name:     "root",
+			duration: NewAbsoluteDurationDuration(time.Second),
+			children: []testTask{
+				{name: "first", duration: NewAbsoluteDurationDuration(100 * time.Millisecond), conditionalDefinitions: inject},
+				{name: "second", duration: NewAbsoluteDurationDuration(100 * time.Millisecond), conditionalDefinitions: inject},
+			},
+		}
+
+		rootSpan, err := FromTaskTree(root.node(), traceID, baseTime, sequentialSpanIDs())
+		assert.NoError(t, err)
+		externalIDToSpan := rootSpan.ExternalIDToSpan()
+		assert.Len(t, externalIDToSpan, 4)
+		assert.NoError(t, rootSpan.LinkSpan(externalIDToSpan))
+		for _, parent := range rootSpan.Children() {
+			injected := parent.Children()[0]
+			assert.Equal(t, "lookup-"+parent.ID().String(), injected.ExternalID().Value())
+			// the link follows the ExternalID of the same injection
+			assert.Equal(t, []*TreeNode{injected.Children()[0]}, injected.LinkedTo())
+		}
+		// the template itself is left unchanged
+		assert.Equal(t, "lookup", lookupDef.ExternalID().Value())
+		assert.Equal(t, "hit", lookupDef.LinkedTo()[0].Value())
+	})
+}
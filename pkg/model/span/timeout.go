@@ -0,0 +1,57 @@
+package span
+
+import (
+	"fmt"
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"time"
+)
+
+// TimeoutExceptionType is the exception type recorded on spans cut by a timeout
+const TimeoutExceptionType = "TimeoutError"
+
+// applyTimeout cuts the span at the timeout if it runs longer, marks it as failed, and records an exception event.
+// In-flight children are truncated or left running past the end of the span depending on the child policy.
+func (n *TreeNode) applyTimeout(timeout *task.Timeout) {
+	cutoff := n.startTime.Add(timeout.Duration())
+	if !n.endTime.After(cutoff) {
+		return
+	}
+
+	message := fmt.Sprintf("timed out after %s", timeout.Duration())
+	n.endTime = cutoff
+	n.events = eventsBefore(n.events, cutoff)
+	n.events = append(n.events, newExceptionEvent(cutoff, TimeoutExceptionType, message, ""))
+	n.status = StatusError(&message)
+
+	if timeout.ChildPolicy() == task.TimeoutChildPolicyTruncate {
+		n.truncateChildren(cutoff)
+	}
+}
+
+// truncateChildren drops the descendants that start at or after the cutoff and cuts the rest at the cutoff
+func (n *TreeNode) truncateChildren(cutoff time.Time) {
+	children := make([]*TreeNode, 0, len(n.children))
+	for _, child := range n.children {
+		if !child.startTime.Before(cutoff) {
+			continue
+		}
+		if child.endTime.After(cutoff) {
+			child.endTime = cutoff
+			child.events = eventsBefore(child.events, cutoff)
+		}
+		child.truncateChildren(cutoff)
+		children = append(children, child)
+	}
+	n.children = children
+}
+
+// eventsBefore returns the events that occurred at or before the cutoff
+func eventsBefore(events []Event, cutoff time.Time) []Event {
+	kept := make([]Event, 0, len(events))
+	for _, e := range events {
+		if !e.occurredAt.After(cutoff) {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
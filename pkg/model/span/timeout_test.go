@@ -0,0 +1,112 @@
+package span
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestFromTaskTree_Timeout(t *testing.T) {
+	type expectedChild struct {
+		name          string
+		end           time.Duration
+		grandchildren int
+	}
+	type testCase struct {
+		name             string
+		timeout          time.Duration
+		policy           task.TimeoutChildPolicy
+		expectedEnd      time.Duration
+		expectedStatus   Status
+		expectedEvents   []string
+		expectedChildren []expectedChild
+	}
+
+	baseTime := time.Now()
+	traceID := NewTraceID([16]byte{0x01})
+
+	testCases := []testCase{
+		{
+			name:           "cut the span at the timeout, record an exception and truncate in-flight children",
+			timeout:        1 * time.Second,
+			policy:         task.TimeoutChildPolicyTruncate,
+			expectedEnd:    1 * time.Second,
+			expectedStatus: NewStatus(StatusCodeError, ptrString("timed out after 1s")),
+			expectedEvents: []string{"early", ExceptionEventName},
+			// the grandchild starts at 1.3s, after the timeout
+			expectedChildren: []expectedChild{{name: "in-flight", end: 1 * time.Second}},
+		},
+		{
+			name:           "leave in-flight children running as orphans",
+			timeout:        1 * time.Second,
+			policy:         task.TimeoutChildPolicyOrphan,
+			expectedEnd:    1 * time.Second,
+			expectedStatus: NewStatus(StatusCodeError, ptrString("timed out after 1s")),
+			expectedEvents: []string{"early", ExceptionEventName},
+			expectedChildren: []expectedChild{
+				{name: "in-flight", end: 1500 * time.Millisecond, grandchildren: 1},
+				{name: "not-started", end: 2500 * time.Millisecond},
+			},
+		},
+		{
+			name:           "keep the span as is when it finishes within the timeout",
+			timeout:        5 * time.Second,
+			policy:         task.TimeoutChildPolicyTruncate,
+			expectedEnd:    3 * time.Second,
+			expectedStatus: StatusOK,
+			expectedEvents: []string{"early", "late"},
+			expectedChildren: []expectedChild{
+				{name: "in-flight", end: 1500 * time.Millisecond, grandchildren: 1},
+				{name: "not-started", end: 2500 * time.Millisecond},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			timeout, _ := task.NewTimeout(tc.timeout, tc.policy)
+			root := testTask{
+				name:     "root",
+				duration: NewAbsoluteDurationDuration(3 * time.Second),
+				events: []task.Event{
+					task.NewEvent("early", NewAbsoluteDurationDelay(500*time.Millisecond), nil),
+					task.NewEvent("late", NewAbsoluteDurationDelay(2*time.Second), nil),
+				},
+				options: []task.DefinitionOption{task.WithTimeout(timeout)},
+				children: []testTask{
+					{
+						name:     "in-flight",
+						delay:    NewAbsoluteDurationDelay(500 * time.Millisecond),
+						duration: NewAbsoluteDurationDuration(1 * time.Second),
+						children: []testTask{
+							{name: "grandchild", delay: NewAbsoluteDurationDelay(800 * time.Millisecond), duration: NewAbsoluteDurationDuration(100 * time.Millisecond)},
+						},
+					},
+					{name: "not-started", delay: NewAbsoluteDurationDelay(1500 * time.Millisecond), duration: NewAbsoluteDurationDuration(1 * time.Second)},
+				},
+			}
+			rootSpan, err := FromTaskTree(root.node(), traceID, baseTime, sequentialSpanIDs())
+			assert.NoError(t, err)
+
+			assert.Equal(t, baseTime.Add(tc.expectedEnd), rootSpan.EndTime())
+			assert.Equal(t, tc.expectedStatus, rootSpan.Status())
+			events := rootSpan.Events()
+			assert.Len(t, events, len(tc.expectedEvents))
+			for i, name := range tc.expectedEvents {
+				assert.Equal(t, name, events[i].Name())
+				if name == ExceptionEventName {
+					assert.Equal(t, rootSpan.EndTime(), events[i].OccurredAt())
+					assert.Equal(t, TimeoutExceptionType, events[i].Attributes()[ExceptionTypeAttributeKey])
+				}
+			}
+			children := rootSpan.Children()
+			assert.Len(t, children, len(tc.expectedChildren))
+			for i, expected := range tc.expectedChildren {
+				assert.Equal(t, expected.name, children[i].Name())
+				assert.Equal(t, baseTime.Add(expected.end), children[i].EndTime())
+				assert.Len(t, children[i].Children(), expected.grandchildren)
+			}
+		})
+	}
+}
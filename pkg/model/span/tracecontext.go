@@ -0,0 +1,48 @@
+package span
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"strings"
+)
+
+// traceContext is the W3C trace flags and tracestate a span propagates to its children
+type traceContext struct {
+	flags TraceFlags
+	state string
+}
+
+// resolveTraceContext returns the trace context defined by the task, or the inherited one when it defines none
+func resolveTraceContext(definition *task.Definition, inherited traceContext) traceContext {
+	if defined := definition.TraceContext(); defined != nil {
+		return traceContext{flags: TraceFlags(defined.Flags()), state: defined.State()}
+	}
+	return inherited
+}
+
+// setTraceContext sets the trace flags and tracestate of the span
+func (n *TreeNode) setTraceContext(context traceContext) {
+	n.traceFlags = context.flags
+	n.traceState = context.state
+}
+
+// context returns the trace context the span propagates to its children
+func (n *TreeNode) context() traceContext {
+	return traceContext{flags: n.traceFlags, state: n.traceState}
+}
+
+// withTraceStateMember returns the tracestate with the member of the key set to the value, or removed when the value
+// is empty. An updated member moves to the front of the list, as required by W3C trace context.
+func withTraceStateMember(state string, key string, value string) string {
+	members := make([]string, 0)
+	if value != "" {
+		members = append(members, key+"="+value)
+	}
+	for _, member := range strings.Split(state, ",") {
+		member = strings.TrimSpace(member)
+		if member == "" || strings.HasPrefix(member, key+"=") {
+			continue
+		}
+		members = append(members, member)
+	}
+	return strings.Join(members, ",")
+}
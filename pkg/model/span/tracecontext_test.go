@@ -0,0 +1,125 @@
+package span
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestFromTaskTree_TraceContext(t *testing.T) {
+	type expectedContext struct {
+		sampled    bool
+		traceState string
+	}
+	type testCase struct {
+		name string
+		root testTask
+		// expected are the trace contexts of the spans by name
+		expected map[string]expectedContext
+	}
+
+	rootContext, err := task.NewTraceContext(0x01, "vendor=abc,ot=th:8")
+	assert.NoError(t, err)
+	childContext, err := task.NewTraceContext(0x00, "vendor=xyz")
+	assert.NoError(t, err)
+	update, _ := task.NewUpdateTraceStateEffect("ot", "th:c")
+	remove, _ := task.NewUpdateTraceStateEffect("vendor", "")
+	rootTask := func(children ...testTask) testTask {
+		return testTask{
+			name:     "root",
+			duration: NewAbsoluteDurationDuration(100 * time.Millisecond),
+			options:  []task.DefinitionOption{task.WithTraceContext(rootContext)},
+			children: children,
+		}
+	}
+	testCases := []testCase{
+		{
+			name: "propagate the trace context of the root to its descendants",
+			root: rootTask(testTask{
+				name:     "child",
+				duration: NewAbsoluteDurationDuration(100 * time.Millisecond),
+				children: []testTask{{name: "grandchild", duration: NewAbsoluteDurationDuration(100 * time.Millisecond)}},
+			}),
+			expected: map[string]expectedContext{
+				"root":       {sampled: true, traceState: "vendor=abc,ot=th:8"},
+				"child":      {sampled: true, traceState: "vendor=abc,ot=th:8"},
+				"grandchild": {sampled: true, traceState: "vendor=abc,ot=th:8"},
+			},
+		},
+		{
+			name: "replace the inherited trace context by the one of the task",
+			root: rootTask(testTask{
+				name:     "child",
+				duration: NewAbsoluteDurationDuration(100 * time.Millisecond),
+				options:  []task.DefinitionOption{task.WithTraceContext(childContext)},
+			}),
+			expected: map[string]expectedContext{
+				"root":  {sampled: true, traceState: "vendor=abc,ot=th:8"},
+				"child": {sampled: false, traceState: "vendor=xyz"},
+			},
+		},
+		{
+			name: "update the trace context of the span and its descendants by effects",
+			root: rootTask(testTask{
+				name:     "child",
+				duration: NewAbsoluteDurationDuration(100 * time.Millisecond),
+				conditionalDefinitions: always(
+					task.FromUpdateTraceStateEffect(*update),
+					task.FromUpdateTraceStateEffect(*remove),
+					task.FromSetTraceFlagsEffect(task.NewSetTraceFlagsEffect(0x00)),
+				),
+				children: []testTask{{name: "grandchild", duration: NewAbsoluteDurationDuration(100 * time.Millisecond)}},
+			}),
+			expected: map[string]expectedContext{
+				"root":       {sampled: true, traceState: "vendor=abc,ot=th:8"},
+				"child":      {sampled: false, traceState: "ot=th:c"},
+				"grandchild": {sampled: false, traceState: "ot=th:c"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rootSpan, err := FromTaskTree(tc.root.node(), NewTraceID([16]byte{0x01}), time.Now(), sequentialSpanIDs())
+			assert.NoError(t, err)
+
+			actual := make(map[string]expectedContext)
+			var walk func(n *TreeNode)
+			walk = func(n *TreeNode) {
+				actual[n.Name()] = expectedContext{sampled: n.TraceFlags().IsSampled(), traceState: n.TraceState()}
+				for _, child := range n.Children() {
+					walk(child)
+				}
+			}
+			walk(rootSpan)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestFromTaskTree_TraceContextError(t *testing.T) {
+	type testCase struct {
+		name       string
+		traceState string
+	}
+
+	testCases := []testCase{
+		{name: "member without value", traceState: "novalue"},
+		{name: "key with upper case", traceState: "Upper=1"},
+		{name: "duplicate key", traceState: "a=1,a=2"},
+		{name: "value with equal sign", traceState: "a=x=y"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := task.NewTraceContext(0x01, tc.traceState)
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("return error when updating the tracestate without key", func(t *testing.T) {
+		_, err := task.NewUpdateTraceStateEffect("", "value")
+		assert.Error(t, err)
+	})
+}
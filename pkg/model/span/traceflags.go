@@ -0,0 +1,24 @@
+package span
+
+// TraceFlags holds the W3C trace flags of a span
+type TraceFlags byte
+
+const (
+	// TraceFlagsSampled indicates that the trace was sampled by the caller
+	TraceFlagsSampled TraceFlags = 0x01
+	// TraceFlagsRandom indicates that the rightmost 7 bytes of the trace ID are random, as defined by W3C trace context level 2
+	TraceFlagsRandom TraceFlags = 0x02
+)
+
+// IsSampled reports whether the sampled flag is set
+func (f TraceFlags) IsSampled() bool {
+	return f&TraceFlagsSampled != 0
+}
+
+// WithSampled returns the flags with the sampled flag set or cleared
+func (f TraceFlags) WithSampled(sampled bool) TraceFlags {
+	if sampled {
+		return f | TraceFlagsSampled
+	}
+	return f &^ TraceFlagsSampled
+}
@@ -0,0 +1,26 @@
+package span
+
+import "github.com/k4ji/tracesimulator/pkg/model/task"
+
+var _ Effect = (*UpdateTraceStateEffect)(nil)
+
+// UpdateTraceStateEffect is a conditional definition effect that sets a member of the tracestate of the span and its descendants.
+type UpdateTraceStateEffect struct {
+	key   string
+	value string
+}
+
+func (u UpdateTraceStateEffect) Apply(node *TreeNode) error {
+	node.traceState = withTraceStateMember(node.traceState, u.key, u.value)
+	for _, child := range node.children {
+		if err := u.Apply(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FromUpdateTraceStateEffect converts a task UpdateTraceStateEffect to a span UpdateTraceStateEffect.
+func FromUpdateTraceStateEffect(spec task.UpdateTraceStateEffect) UpdateTraceStateEffect {
+	return UpdateTraceStateEffect{key: spec.Key(), value: spec.Value()}
+}
@@ -0,0 +1,17 @@
+package task
+
+// AddLatencyEffect is a conditional definition effect that extends the task by additional latency after its children have finished.
+type AddLatencyEffect struct {
+	// latency is resolved against the duration of the task, so it can be fixed, sampled, or relative.
+	latency Delay
+}
+
+// NewAddLatencyEffect creates a new AddLatencyEffect with the given latency.
+func NewAddLatencyEffect(latency Delay) AddLatencyEffect {
+	return AddLatencyEffect{latency: latency}
+}
+
+// Latency returns the latency to add.
+func (a AddLatencyEffect) Latency() Delay {
+	return a.latency
+}
@@ -0,0 +1,96 @@
+package task
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// BackoffKind defines the strategy used to compute the wait time between retry attempts.
+type BackoffKind string
+
+const (
+	BackoffKindFixed       BackoffKind = "fixed"
+	BackoffKindExponential BackoffKind = "exponential"
+	BackoffKindJittered    BackoffKind = "jittered"
+)
+
+// Backoff represents the wait time between retry attempts.
+type Backoff struct {
+	kind BackoffKind
+	// base is the wait time before the first retry attempt.
+	base time.Duration
+	// multiplier is the factor applied to the wait time after each attempt.
+	multiplier float64
+	// max caps the wait time. Zero means no cap.
+	max time.Duration
+	// randomness is a function that returns a random value between 0 and 1, used for jitter.
+	randomness func() float64
+}
+
+// NewFixedBackoff creates a new Backoff that always waits for the same duration.
+func NewFixedBackoff(base time.Duration) (*Backoff, error) {
+	if base < 0 {
+		return nil, fmt.Errorf("backoff cannot be negative, got %s", base)
+	}
+	return &Backoff{
+		kind:       BackoffKindFixed,
+		base:       base,
+		multiplier: 1,
+	}, nil
+}
+
+// NewExponentialBackoff creates a new Backoff that multiplies the wait time by the multiplier after each attempt.
+func NewExponentialBackoff(base time.Duration, multiplier float64, max time.Duration) (*Backoff, error) {
+	if base < 0 {
+		return nil, fmt.Errorf("backoff cannot be negative, got %s", base)
+	}
+	if multiplier < 1 {
+		return nil, fmt.Errorf("backoff multiplier must be greater than or equal to 1, got %f", multiplier)
+	}
+	if max < 0 {
+		return nil, fmt.Errorf("backoff cap cannot be negative, got %s", max)
+	}
+	return &Backoff{
+		kind:       BackoffKindExponential,
+		base:       base,
+		multiplier: multiplier,
+		max:        max,
+	}, nil
+}
+
+// NewJitteredBackoff creates a new exponential Backoff whose wait time is drawn uniformly between 0 and the computed value ("full jitter").
+func NewJitteredBackoff(base time.Duration, multiplier float64, max time.Duration, randomness func() float64) (*Backoff, error) {
+	if randomness == nil {
+		return nil, fmt.Errorf("jittered backoff requires a randomness function")
+	}
+	b, err := NewExponentialBackoff(base, multiplier, max)
+	if err != nil {
+		return nil, err
+	}
+	b.kind = BackoffKindJittered
+	b.randomness = randomness
+	return b, nil
+}
+
+func (b Backoff) Kind() BackoffKind {
+	return b.kind
+}
+
+// Resolve returns the wait time before the given retry attempt, starting from 1.
+func (b Backoff) Resolve(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	wait := float64(b.base)
+	if b.kind != BackoffKindFixed {
+		wait *= math.Pow(b.multiplier, float64(attempt-1))
+	}
+	if b.max > 0 && wait > float64(b.max) {
+		wait = float64(b.max)
+	}
+	if b.kind == BackoffKindJittered {
+		wait *= b.randomness()
+	}
+	return time.Duration(wait)
+}
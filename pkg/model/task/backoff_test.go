@@ -0,0 +1,113 @@
+package task_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff_Resolve(t *testing.T) {
+	type testCase struct {
+		name    string
+		backoff func() (*task.Backoff, error)
+		// expected are the backoffs before the attempts 1, 2, 3 and so on
+		expected []time.Duration
+	}
+
+	testCases := []testCase{
+		{
+			name:     "fixed backoff",
+			backoff:  func() (*task.Backoff, error) { return task.NewFixedBackoff(100 * time.Millisecond) },
+			expected: []time.Duration{100 * time.Millisecond, 100 * time.Millisecond, 100 * time.Millisecond, 100 * time.Millisecond, 100 * time.Millisecond},
+		},
+		{
+			name: "exponential backoff with cap",
+			backoff: func() (*task.Backoff, error) {
+				return task.NewExponentialBackoff(100*time.Millisecond, 2, 300*time.Millisecond)
+			},
+			expected: []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond},
+		},
+		{
+			name: "jittered backoff",
+			backoff: func() (*task.Backoff, error) {
+				return task.NewJitteredBackoff(100*time.Millisecond, 2, 0, func() float64 { return 0.5 })
+			},
+			expected: []time.Duration{50 * time.Millisecond, 100 * time.Millisecond},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := tc.backoff()
+			assert.NoError(t, err)
+			for i, expected := range tc.expected {
+				assert.Equal(t, expected, b.Resolve(i+1))
+			}
+		})
+	}
+}
+
+func TestBackoffError(t *testing.T) {
+	type testCase struct {
+		name    string
+		backoff func() (*task.Backoff, error)
+	}
+
+	testCases := []testCase{
+		{
+			name:    "negative fixed backoff",
+			backoff: func() (*task.Backoff, error) { return task.NewFixedBackoff(-1) },
+		},
+		{
+			name:    "exponential backoff with a multiplier below 1",
+			backoff: func() (*task.Backoff, error) { return task.NewExponentialBackoff(time.Second, 0.5, 0) },
+		},
+		{
+			name:    "jittered backoff without randomness",
+			backoff: func() (*task.Backoff, error) { return task.NewJitteredBackoff(time.Second, 2, 0, nil) },
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.backoff()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNewRetryPolicy(t *testing.T) {
+	b, _ := task.NewFixedBackoff(time.Second)
+	p, err := task.NewRetryPolicy(3, *b, []float64{0.1, 0.5}, func() float64 { return 0 })
+	assert.NoError(t, err)
+	// the last success probability is used for the remaining attempts
+	assert.Equal(t, 0.1, p.SuccessProbability(1))
+	assert.Equal(t, 0.5, p.SuccessProbability(2))
+	assert.Equal(t, 0.5, p.SuccessProbability(3))
+}
+
+func TestNewRetryPolicyError(t *testing.T) {
+	type testCase struct {
+		name                 string
+		maxAttempts          int
+		successProbabilities []float64
+		randomness           func() float64
+	}
+
+	b, _ := task.NewFixedBackoff(time.Second)
+	testCases := []testCase{
+		{name: "no attempt", maxAttempts: 0, successProbabilities: []float64{0.5}, randomness: func() float64 { return 0 }},
+		{name: "no success probability", maxAttempts: 1, successProbabilities: []float64{}, randomness: func() float64 { return 0 }},
+		{name: "success probability above 1", maxAttempts: 1, successProbabilities: []float64{1.5}, randomness: func() float64 { return 0 }},
+		{name: "no randomness", maxAttempts: 1, successProbabilities: []float64{0.5}, randomness: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := task.NewRetryPolicy(tc.maxAttempts, *b, tc.successProbabilities, tc.randomness)
+			assert.Error(t, err)
+		})
+	}
+}
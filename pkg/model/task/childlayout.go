@@ -0,0 +1,12 @@
+package task
+
+// ChildLayout defines how the children of a task are placed in time.
+type ChildLayout string
+
+const (
+	// ChildLayoutParallel applies the delay of each child from the start of the parent
+	ChildLayoutParallel ChildLayout = "parallel"
+	// ChildLayoutSequential applies the delay of each child from the end of the previous sibling.
+	// Consecutive children sharing the same layout group run in parallel as a single step of the sequence.
+	ChildLayoutSequential ChildLayout = "sequential"
+)
@@ -0,0 +1,35 @@
+package task
+
+import (
+	"fmt"
+	"time"
+)
+
+// ClampDurationEffect is a conditional definition effect that keeps the duration of the task between min and max.
+// Children and events of the task are stretched or compressed accordingly.
+type ClampDurationEffect struct {
+	min time.Duration
+	// max is the maximum duration. Zero means no maximum.
+	max time.Duration
+}
+
+// NewClampDurationEffect creates a new ClampDurationEffect with the given bounds.
+func NewClampDurationEffect(min time.Duration, max time.Duration) (*ClampDurationEffect, error) {
+	if min < 0 || max < 0 {
+		return nil, fmt.Errorf("clamp bounds cannot be negative, got min %s and max %s", min, max)
+	}
+	if max > 0 && max < min {
+		return nil, fmt.Errorf("clamp max must be greater than or equal to min, got min %s and max %s", min, max)
+	}
+	return &ClampDurationEffect{min: min, max: max}, nil
+}
+
+// Min returns the minimum duration.
+func (c ClampDurationEffect) Min() time.Duration {
+	return c.min
+}
+
+// Max returns the maximum duration, or zero if there is no maximum.
+func (c ClampDurationEffect) Max() time.Duration {
+	return c.max
+}
@@ -68,6 +68,17 @@ func NewHasAttributeCondition(key string) Condition {
 	}
 }
 
+// NewHasAttributeValueCondition creates a new Condition that checks if the task has the attribute with the given value.
+func NewHasAttributeValueCondition(key string, value string) Condition {
+	return Condition{
+		kind: ConditionKindHasAttribute,
+		hasAttribute: &HasAttributeCondition{
+			key:   key,
+			value: &value,
+		},
+	}
+}
+
 // NewMarkedAsFailedCondition creates a new Condition that checks if the task is marked as failed.
 func NewMarkedAsFailedCondition() Condition {
 	return Condition{
@@ -0,0 +1,35 @@
+package task
+
+import "fmt"
+
+// Consumption describes how a task consumes the messages published to a queue.
+// The consuming span must be the root of its trace, which starts after the dwell time from the end of the latest producer of its batch.
+type Consumption struct {
+	queue string
+	// dwell is the time the messages wait in the queue
+	dwell Delay
+	// batchSize is the maximum number of messages consumed at once
+	batchSize int
+}
+
+func NewConsumption(queue string, dwell Delay, batchSize int) (*Consumption, error) {
+	if queue == "" {
+		return nil, fmt.Errorf("queue name cannot be empty")
+	}
+	if batchSize < 1 {
+		return nil, fmt.Errorf("batch size must be at least 1, got %d", batchSize)
+	}
+	return &Consumption{queue: queue, dwell: dwell, batchSize: batchSize}, nil
+}
+
+func (c *Consumption) Queue() string {
+	return c.queue
+}
+
+func (c *Consumption) Dwell() Delay {
+	return c.dwell
+}
+
+func (c *Consumption) BatchSize() int {
+	return c.batchSize
+}
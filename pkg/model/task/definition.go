@@ -14,11 +14,79 @@ type Definition struct {
 	linkedTo               []*ExternalID            // IDs of linked spans (for producer/consumer relationships)
 	events                 []Event                  // Events associated with the task
 	conditionalDefinitions []*ConditionalDefinition // Conditional definitions for the task
+	retryPolicy            *RetryPolicy             // Retry policy applied when the task fails (if any)
+	timeout                *Timeout                 // Maximum duration of the task (if any)
+	childLayout            ChildLayout              // How the children of the task are placed in time
+	layoutGroup            string                   // Group of siblings running in parallel within a sequential layout
+	layoutSequence         string                   // Sequence of siblings running one after another in any layout
+	publishTo              string                   // Queue the task publishes a message to (if any)
+	consumption            *Consumption             // Queue the task consumes messages from (if any)
+	traceContext           *TraceContext            // Trace flags and tracestate the task starts with (if any)
+}
+
+// DefinitionOption configures optional behavior of a task definition
+type DefinitionOption func(*Definition)
+
+// WithRetryPolicy sets the retry policy of the task
+func WithRetryPolicy(policy *RetryPolicy) DefinitionOption {
+	return func(d *Definition) {
+		d.retryPolicy = policy
+	}
+}
+
+// WithChildLayout sets how the children of the task are placed in time
+func WithChildLayout(layout ChildLayout) DefinitionOption {
+	return func(d *Definition) {
+		d.childLayout = layout
+	}
+}
+
+// WithLayoutGroup sets the group of siblings the task runs in parallel with within a sequential layout
+func WithLayoutGroup(group string) DefinitionOption {
+	return func(d *Definition) {
+		d.layoutGroup = group
+	}
+}
+
+// WithLayoutSequence makes the task start after the end of the previous sibling in the same sequence, whatever the layout
+// of the parent, such as the iterations of a sequential loop. The delay of the task is applied from that end.
+func WithLayoutSequence(sequence string) DefinitionOption {
+	return func(d *Definition) {
+		d.layoutSequence = sequence
+	}
+}
+
+// WithPublish makes the task publish a message to the queue when it ends
+func WithPublish(queue string) DefinitionOption {
+	return func(d *Definition) {
+		d.publishTo = queue
+	}
+}
+
+// WithConsumption makes the task consume messages from a queue
+func WithConsumption(consumption *Consumption) DefinitionOption {
+	return func(d *Definition) {
+		d.consumption = consumption
+	}
+}
+
+// WithTraceContext sets the trace flags and tracestate of the task and its descendants
+func WithTraceContext(traceContext *TraceContext) DefinitionOption {
+	return func(d *Definition) {
+		d.traceContext = traceContext
+	}
+}
+
+// WithTimeout sets the timeout of the task
+func WithTimeout(timeout *Timeout) DefinitionOption {
+	return func(d *Definition) {
+		d.timeout = timeout
+	}
 }
 
 // NewDefinition creates a new task definition
-func NewDefinition(name string, isResourceEntryPoint bool, resource *Resource, attributes map[string]string, kind Kind, externalID *ExternalID, delay Delay, duration Duration, childOf *ExternalID, linkedTo []*ExternalID, events []Event, conditionaldefinitions []*ConditionalDefinition) (*Definition, error) {
-	return &Definition{
+func NewDefinition(name string, isResourceEntryPoint bool, resource *Resource, attributes map[string]string, kind Kind, externalID *ExternalID, delay Delay, duration Duration, childOf *ExternalID, linkedTo []*ExternalID, events []Event, conditionaldefinitions []*ConditionalDefinition, opts ...DefinitionOption) (*Definition, error) {
+	def := &Definition{
 		name:                   name,
 		isResourceEntryPoint:   isResourceEntryPoint,
 		resource:               resource,
@@ -31,7 +99,12 @@ func NewDefinition(name string, isResourceEntryPoint bool, resource *Resource, a
 		linkedTo:               linkedTo,
 		events:                 events,
 		conditionalDefinitions: conditionaldefinitions,
-	}, nil
+		childLayout:            ChildLayoutParallel,
+	}
+	for _, opt := range opts {
+		opt(def)
+	}
+	return def, nil
 }
 
 func (d *Definition) Name() string {
@@ -81,3 +154,45 @@ func (d *Definition) Events() []Event {
 func (d *Definition) ConditionalDefinitions() []*ConditionalDefinition {
 	return d.conditionalDefinitions
 }
+
+func (d *Definition) RetryPolicy() *RetryPolicy {
+	return d.retryPolicy
+}
+
+func (d *Definition) Timeout() *Timeout {
+	return d.timeout
+}
+
+func (d *Definition) ChildLayout() ChildLayout {
+	return d.childLayout
+}
+
+func (d *Definition) LayoutGroup() string {
+	return d.layoutGroup
+}
+
+func (d *Definition) LayoutSequence() string {
+	return d.layoutSequence
+}
+
+func (d *Definition) PublishTo() string {
+	return d.publishTo
+}
+
+func (d *Definition) Consumption() *Consumption {
+	return d.consumption
+}
+
+func (d *Definition) TraceContext() *TraceContext {
+	return d.traceContext
+}
+
+// WithAppendedConditionalDefinitions returns a copy of the definition with conditional definitions evaluated after
+// the existing ones, so that a wrapping blueprint can change the behavior of tasks it did not define
+func (d *Definition) WithAppendedConditionalDefinitions(definitions ...*ConditionalDefinition) *Definition {
+	copied := *d
+	merged := make([]*ConditionalDefinition, 0, len(d.conditionalDefinitions)+len(definitions))
+	merged = append(merged, d.conditionalDefinitions...)
+	copied.conditionalDefinitions = append(merged, definitions...)
+	return &copied
+}
@@ -17,10 +17,15 @@ func NewDelay(expr taskduration.Expression) (*Delay, error) {
 	return &Delay{expr: expr}, nil
 }
 
+// Expression returns the expression the delay is resolved from
+func (d Delay) Expression() taskduration.Expression {
+	return d.expr
+}
+
 func (d Delay) Resolve(context interface{}) (*time.Duration, error) {
 	switch d.expr.(type) {
 	case *taskduration.RelativeDuration:
-		parentDuration, ok := context.(*time.Duration)
+		parentDuration, ok := parentDurationOf(context)
 		if !ok {
 			return nil, fmt.Errorf("failed to resolve relative delay: invalid context type %T, expected time.Duration", context)
 		}
@@ -35,7 +40,7 @@ func (d Delay) Resolve(context interface{}) (*time.Duration, error) {
 			return nil, fmt.Errorf("duration cannot be negative, got %s", delay)
 		}
 		return delay, nil
-	case *taskduration.AbsoluteDuration:
+	case *taskduration.AbsoluteDuration, *taskduration.UniformDuration:
 		delay, err := d.expr.Resolve(nil)
 		if err != nil {
 			return nil, fmt.Errorf("failed to resolve delay: %w", err)
@@ -44,6 +49,15 @@ func (d Delay) Resolve(context interface{}) (*time.Duration, error) {
 			return nil, fmt.Errorf("duration cannot be negative, got %s", delay)
 		}
 		return delay, nil
+	case *taskduration.Formula:
+		delay, err := d.expr.Resolve(context)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve delay: %w", err)
+		}
+		if *delay < 0 {
+			return nil, fmt.Errorf("duration cannot be negative, got %s", delay)
+		}
+		return delay, nil
 	default:
 		return nil, fmt.Errorf("unsupported delay type: %T", d.expr)
 	}
@@ -0,0 +1,17 @@
+package task
+
+// DelayStartEffect is a conditional definition effect that delays the start of the task together with its children and events.
+type DelayStartEffect struct {
+	// delay is resolved against the duration of the task, so it can be fixed, sampled, or relative.
+	delay Delay
+}
+
+// NewDelayStartEffect creates a new DelayStartEffect with the given delay.
+func NewDelayStartEffect(delay Delay) DelayStartEffect {
+	return DelayStartEffect{delay: delay}
+}
+
+// Delay returns the delay of the start.
+func (d DelayStartEffect) Delay() Delay {
+	return d.delay
+}
@@ -0,0 +1,33 @@
+package task
+
+import "fmt"
+
+// DropMode defines what happens to the children of a dropped task.
+type DropMode string
+
+const (
+	// DropModeReparent drops only the task and attaches its children to its parent
+	DropModeReparent DropMode = "reparent"
+	// DropModeSubtree drops the task together with all its descendants
+	DropModeSubtree DropMode = "subtree"
+)
+
+// DropSpanEffect is a conditional definition effect that removes the task from the trace, simulating an instrumentation gap.
+type DropSpanEffect struct {
+	mode DropMode
+}
+
+// NewDropSpanEffect creates a new DropSpanEffect with the given mode.
+func NewDropSpanEffect(mode DropMode) (*DropSpanEffect, error) {
+	switch mode {
+	case DropModeReparent, DropModeSubtree:
+	default:
+		return nil, fmt.Errorf("unsupported drop mode: %s", mode)
+	}
+	return &DropSpanEffect{mode: mode}, nil
+}
+
+// Mode returns what happens to the children of the dropped task.
+func (d DropSpanEffect) Mode() DropMode {
+	return d.mode
+}
@@ -17,10 +17,15 @@ func NewDuration(expr taskduration.Expression) (*Duration, error) {
 	return &Duration{expr: expr}, nil
 }
 
+// Expression returns the expression the duration is resolved from
+func (d Duration) Expression() taskduration.Expression {
+	return d.expr
+}
+
 func (d Duration) Resolve(context interface{}) (*time.Duration, error) {
 	switch d.expr.(type) {
 	case *taskduration.RelativeDuration:
-		parentDuration, ok := context.(*time.Duration)
+		parentDuration, ok := parentDurationOf(context)
 		if !ok {
 			return nil, fmt.Errorf("failed to resolve relative duration: invalid context type %T, expected time.Duration", context)
 		}
@@ -35,7 +40,7 @@ func (d Duration) Resolve(context interface{}) (*time.Duration, error) {
 			return nil, fmt.Errorf("duration must be greater than 0, got %s", duration)
 		}
 		return duration, nil
-	case *taskduration.AbsoluteDuration:
+	case *taskduration.AbsoluteDuration, *taskduration.UniformDuration:
 		duration, err := d.expr.Resolve(nil)
 		if err != nil {
 			return nil, fmt.Errorf("failed to resolve duration: %w", err)
@@ -44,7 +49,22 @@ func (d Duration) Resolve(context interface{}) (*time.Duration, error) {
 			return nil, fmt.Errorf("duration must be greater than 0, got %s", duration)
 		}
 		return duration, nil
+	case *taskduration.Formula:
+		duration, err := d.expr.Resolve(context)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve duration: %w", err)
+		}
+		if *duration <= 0 {
+			return nil, fmt.Errorf("duration must be greater than 0, got %s", duration)
+		}
+		return duration, nil
 	default:
 		return nil, fmt.Errorf("unsupported duration type: %T", d.expr)
 	}
 }
+
+// Auto returns the auto duration and true if the duration is derived from the children of the task
+func (d Duration) Auto() (*taskduration.AutoDuration, bool) {
+	auto, ok := d.expr.(*taskduration.AutoDuration)
+	return auto, ok
+}
@@ -4,16 +4,36 @@ package task
 type EffectKind string
 
 const (
-	EffectKindMarkAsFailed EffectKind = "markAsFailed"
-	EffectKindRecordEvent  EffectKind = "recordEvent"
-	EffectKindAnnotate     EffectKind = "annotate"
+	EffectKindMarkAsFailed      EffectKind = "markAsFailed"
+	EffectKindRecordEvent       EffectKind = "recordEvent"
+	EffectKindAnnotate          EffectKind = "annotate"
+	EffectKindFailWithException EffectKind = "failWithException"
+	EffectKindScaleDuration     EffectKind = "scaleDuration"
+	EffectKindAddLatency        EffectKind = "addLatency"
+	EffectKindClampDuration     EffectKind = "clampDuration"
+	EffectKindDelayStart        EffectKind = "delayStart"
+	EffectKindDropSpan          EffectKind = "dropSpan"
+	EffectKindShortCircuit      EffectKind = "shortCircuit"
+	EffectKindInjectChild       EffectKind = "injectChild"
+	EffectKindUpdateTraceState  EffectKind = "updateTraceState"
+	EffectKindSetTraceFlags     EffectKind = "setTraceFlags"
 )
 
 type Effect struct {
-	kind         EffectKind
-	markAsFailed *MarkAsFailedEffect
-	recordEvent  *RecordEventEffect
-	annotate     *AnnotateEffect
+	kind              EffectKind
+	markAsFailed      *MarkAsFailedEffect
+	recordEvent       *RecordEventEffect
+	annotate          *AnnotateEffect
+	failWithException *FailWithExceptionEffect
+	scaleDuration     *ScaleDurationEffect
+	addLatency        *AddLatencyEffect
+	clampDuration     *ClampDurationEffect
+	delayStart        *DelayStartEffect
+	dropSpan          *DropSpanEffect
+	shortCircuit      *ShortCircuitEffect
+	injectChild       *InjectChildEffect
+	updateTraceState  *UpdateTraceStateEffect
+	setTraceFlags     *SetTraceFlagsEffect
 }
 
 func FromMarkAsFailedEffect(markAsFailed MarkAsFailedEffect) Effect {
@@ -37,6 +57,76 @@ func FromAnnotateEffect(annotate AnnotateEffect) Effect {
 	}
 }
 
+func FromFailWithExceptionEffect(failWithException FailWithExceptionEffect) Effect {
+	return Effect{
+		kind:              EffectKindFailWithException,
+		failWithException: &failWithException,
+	}
+}
+
+func FromScaleDurationEffect(scaleDuration ScaleDurationEffect) Effect {
+	return Effect{
+		kind:          EffectKindScaleDuration,
+		scaleDuration: &scaleDuration,
+	}
+}
+
+func FromAddLatencyEffect(addLatency AddLatencyEffect) Effect {
+	return Effect{
+		kind:       EffectKindAddLatency,
+		addLatency: &addLatency,
+	}
+}
+
+func FromClampDurationEffect(clampDuration ClampDurationEffect) Effect {
+	return Effect{
+		kind:          EffectKindClampDuration,
+		clampDuration: &clampDuration,
+	}
+}
+
+func FromDelayStartEffect(delayStart DelayStartEffect) Effect {
+	return Effect{
+		kind:       EffectKindDelayStart,
+		delayStart: &delayStart,
+	}
+}
+
+func FromDropSpanEffect(dropSpan DropSpanEffect) Effect {
+	return Effect{
+		kind:     EffectKindDropSpan,
+		dropSpan: &dropSpan,
+	}
+}
+
+func FromShortCircuitEffect(shortCircuit ShortCircuitEffect) Effect {
+	return Effect{
+		kind:         EffectKindShortCircuit,
+		shortCircuit: &shortCircuit,
+	}
+}
+
+func FromInjectChildEffect(injectChild InjectChildEffect) Effect {
+	return Effect{
+		kind:        EffectKindInjectChild,
+		injectChild: &injectChild,
+	}
+}
+
+func FromUpdateTraceStateEffect(updateTraceState UpdateTraceStateEffect) Effect {
+	return Effect{
+		kind:             EffectKindUpdateTraceState,
+		updateTraceState: &updateTraceState,
+	}
+}
+
+func FromSetTraceFlagsEffect(setTraceFlags SetTraceFlagsEffect) Effect {
+	return Effect{
+		kind:          EffectKindSetTraceFlags,
+		setTraceFlags: &setTraceFlags,
+	}
+}
+
 func (e *Effect) Kind() EffectKind {
 	return e.kind
 }
@@ -52,3 +142,43 @@ func (e *Effect) RecordEventEffect() *RecordEventEffect {
 func (e *Effect) AnnotateEffect() *AnnotateEffect {
 	return e.annotate
 }
+
+func (e *Effect) FailWithExceptionEffect() *FailWithExceptionEffect {
+	return e.failWithException
+}
+
+func (e *Effect) ScaleDurationEffect() *ScaleDurationEffect {
+	return e.scaleDuration
+}
+
+func (e *Effect) AddLatencyEffect() *AddLatencyEffect {
+	return e.addLatency
+}
+
+func (e *Effect) ClampDurationEffect() *ClampDurationEffect {
+	return e.clampDuration
+}
+
+func (e *Effect) DelayStartEffect() *DelayStartEffect {
+	return e.delayStart
+}
+
+func (e *Effect) DropSpanEffect() *DropSpanEffect {
+	return e.dropSpan
+}
+
+func (e *Effect) ShortCircuitEffect() *ShortCircuitEffect {
+	return e.shortCircuit
+}
+
+func (e *Effect) InjectChildEffect() *InjectChildEffect {
+	return e.injectChild
+}
+
+func (e *Effect) UpdateTraceStateEffect() *UpdateTraceStateEffect {
+	return e.updateTraceState
+}
+
+func (e *Effect) SetTraceFlagsEffect() *SetTraceFlagsEffect {
+	return e.setTraceFlags
+}
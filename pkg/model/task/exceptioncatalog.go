@@ -0,0 +1,108 @@
+package task
+
+import "fmt"
+
+// ExceptionLanguage is the language whose stack trace format is used for generated exceptions.
+type ExceptionLanguage string
+
+const (
+	ExceptionLanguageJava   ExceptionLanguage = "java"
+	ExceptionLanguagePython ExceptionLanguage = "python"
+	ExceptionLanguageGo     ExceptionLanguage = "go"
+	ExceptionLanguageNodeJS ExceptionLanguage = "nodejs"
+	ExceptionLanguageDotNet ExceptionLanguage = "dotnet"
+)
+
+// ExceptionType describes an exception that can be raised by a task.
+type ExceptionType struct {
+	// name is the fully qualified type of the exception, such as java.net.SocketTimeoutException.
+	name string
+	// message is the exception message.
+	message string
+	// weight is the relative likelihood of the exception being picked from a catalog.
+	weight float64
+}
+
+// NewExceptionType creates a new ExceptionType.
+func NewExceptionType(name string, message string, weight float64) (*ExceptionType, error) {
+	if name == "" {
+		return nil, fmt.Errorf("exception type name cannot be empty")
+	}
+	if weight <= 0 {
+		return nil, fmt.Errorf("exception weight must be greater than 0, got %f", weight)
+	}
+	return &ExceptionType{
+		name:    name,
+		message: message,
+		weight:  weight,
+	}, nil
+}
+
+// Name returns the fully qualified type of the exception.
+func (e ExceptionType) Name() string {
+	return e.name
+}
+
+// Message returns the exception message.
+func (e ExceptionType) Message() string {
+	return e.message
+}
+
+// Weight returns the relative likelihood of the exception being picked.
+func (e ExceptionType) Weight() float64 {
+	return e.weight
+}
+
+// ExceptionCatalog is a set of exceptions raised by a service written in a given language.
+type ExceptionCatalog struct {
+	language ExceptionLanguage
+	types    []ExceptionType
+	// randomness is a function that returns a random value between 0 and 1.
+	randomness func() float64
+}
+
+// NewExceptionCatalog creates a new ExceptionCatalog.
+func NewExceptionCatalog(language ExceptionLanguage, types []ExceptionType, randomness func() float64) (*ExceptionCatalog, error) {
+	switch language {
+	case ExceptionLanguageJava, ExceptionLanguagePython, ExceptionLanguageGo, ExceptionLanguageNodeJS, ExceptionLanguageDotNet:
+	default:
+		return nil, fmt.Errorf("unsupported exception language: %s", language)
+	}
+	if len(types) == 0 {
+		return nil, fmt.Errorf("exception catalog requires at least one exception type")
+	}
+	if randomness == nil {
+		return nil, fmt.Errorf("exception catalog requires a randomness function")
+	}
+	return &ExceptionCatalog{
+		language:   language,
+		types:      types,
+		randomness: randomness,
+	}, nil
+}
+
+// Language returns the language of the service raising the exceptions.
+func (c *ExceptionCatalog) Language() ExceptionLanguage {
+	return c.language
+}
+
+// Types returns the exceptions in the catalog.
+func (c *ExceptionCatalog) Types() []ExceptionType {
+	return c.types
+}
+
+// Pick returns an exception from the catalog according to the weights.
+func (c *ExceptionCatalog) Pick() ExceptionType {
+	total := 0.0
+	for _, t := range c.types {
+		total += t.weight
+	}
+	r := c.randomness() * total
+	for _, t := range c.types {
+		if r < t.weight {
+			return t
+		}
+		r -= t.weight
+	}
+	return c.types[len(c.types)-1]
+}
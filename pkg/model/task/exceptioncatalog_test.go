@@ -0,0 +1,77 @@
+package task_test
+
+import (
+	"testing"
+
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExceptionCatalog_Pick(t *testing.T) {
+	type testCase struct {
+		name     string
+		random   float64
+		expected string
+	}
+
+	a, _ := task.NewExceptionType("a", "message a", 1)
+	b, _ := task.NewExceptionType("b", "message b", 3)
+	testCases := []testCase{
+		{name: "pick the first type", random: 0.0, expected: "a"},
+		{name: "pick the second type", random: 0.25, expected: "b"},
+		{name: "pick the last type with the highest random value", random: 0.999, expected: "b"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := task.NewExceptionCatalog(task.ExceptionLanguageGo, []task.ExceptionType{*a, *b}, func() float64 { return tc.random })
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, c.Pick().Name())
+		})
+	}
+}
+
+func TestNewExceptionCatalogError(t *testing.T) {
+	type testCase struct {
+		name    string
+		catalog func() error
+	}
+
+	a, _ := task.NewExceptionType("a", "message a", 1)
+	testCases := []testCase{
+		{
+			name: "unsupported language",
+			catalog: func() error {
+				_, err := task.NewExceptionCatalog("cobol", []task.ExceptionType{*a}, func() float64 { return 0 })
+				return err
+			},
+		},
+		{
+			name: "no exception type",
+			catalog: func() error {
+				_, err := task.NewExceptionCatalog(task.ExceptionLanguageJava, []task.ExceptionType{}, func() float64 { return 0 })
+				return err
+			},
+		},
+		{
+			name: "exception type without a name",
+			catalog: func() error {
+				_, err := task.NewExceptionType("", "message", 1)
+				return err
+			},
+		},
+		{
+			name: "exception type without weight",
+			catalog: func() error {
+				_, err := task.NewExceptionType("a", "message", 0)
+				return err
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Error(t, tc.catalog())
+		})
+	}
+}
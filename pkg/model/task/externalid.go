@@ -5,7 +5,9 @@ import (
 	"regexp"
 )
 
-const externalIDPattern = `^[a-zA-Z0-9_-]+$`
+// externalIDPattern also accepts ${name} placeholders so that the IDs of templated tasks can be validated
+// before their parameters are bound
+const externalIDPattern = `^([a-zA-Z0-9_-]|\$\{[A-Za-z_][A-Za-z0-9_]*\})+$`
 
 type ExternalID struct {
 	string
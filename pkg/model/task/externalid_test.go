@@ -11,6 +11,8 @@ func TestNewExternalID(t *testing.T) {
 		{"alphanumeric are valid", "validID123", false},
 		{"underscores are valid", "valid_id_123", false},
 		{"hyphens are valid", "valid-id-123", false},
+		{"placeholders are valid", "lookup-${region}", false},
+		{"unterminated placeholders are invalid", "lookup-${region", true},
 		{"spaces are invalid", "invalid id", true},
 		{"special characters are invalid", "invalid@id", true},
 		{"empty string is invalid", "", true},
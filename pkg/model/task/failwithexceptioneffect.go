@@ -0,0 +1,25 @@
+package task
+
+// FailWithExceptionEffect is a conditional definition effect that marks the task as failed and records an exception event.
+type FailWithExceptionEffect struct {
+	// catalog is the catalog used when no catalog is registered for the service.
+	catalog *ExceptionCatalog
+	// serviceCatalogs are the catalogs keyed by the name of the service executing the task.
+	serviceCatalogs map[string]*ExceptionCatalog
+}
+
+// NewFailWithExceptionEffect creates a new FailWithExceptionEffect with the default catalog and per-service catalogs.
+func NewFailWithExceptionEffect(catalog *ExceptionCatalog, serviceCatalogs map[string]*ExceptionCatalog) FailWithExceptionEffect {
+	return FailWithExceptionEffect{
+		catalog:         catalog,
+		serviceCatalogs: serviceCatalogs,
+	}
+}
+
+// Catalog returns the catalog for the given service, falling back to the default catalog.
+func (f *FailWithExceptionEffect) Catalog(service string) *ExceptionCatalog {
+	if c, ok := f.serviceCatalogs[service]; ok {
+		return c
+	}
+	return f.catalog
+}
@@ -1,10 +1,16 @@
 package task
 
-// HasAttributeCondition is a condition that checks if a task has a specific attribute.
+// HasAttributeCondition is a condition that checks if a task has a specific attribute, optionally with a specific value.
 type HasAttributeCondition struct {
-	key string
+	key   string
+	value *string
 }
 
 func (c HasAttributeCondition) Key() string {
 	return c.key
 }
+
+// Value returns the value the attribute must have, nil if any value is accepted
+func (c HasAttributeCondition) Value() *string {
+	return c.value
+}
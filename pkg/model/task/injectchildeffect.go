@@ -0,0 +1,16 @@
+package task
+
+// InjectChildEffect is a conditional definition effect that adds a subtree built from a template as a child of the task.
+type InjectChildEffect struct {
+	template *Template
+}
+
+// NewInjectChildEffect creates a new InjectChildEffect with the given template.
+func NewInjectChildEffect(template *Template) InjectChildEffect {
+	return InjectChildEffect{template: template}
+}
+
+// Template returns the template of the injected subtree.
+func (i InjectChildEffect) Template() *Template {
+	return i.template
+}
@@ -0,0 +1,19 @@
+package task
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/model/task/taskduration"
+	"time"
+)
+
+// parentDurationOf extracts the duration of the parent from the context a Delay or Duration is resolved with,
+// which is either the duration itself or a *taskduration.Context
+func parentDurationOf(context interface{}) (*time.Duration, bool) {
+	switch c := context.(type) {
+	case *time.Duration:
+		return c, true
+	case *taskduration.Context:
+		return c.Parent, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,68 @@
+package task
+
+import "fmt"
+
+// RetryPolicy describes how a failed client task is retried.
+// Each retry attempt is simulated as an additional span next to the failed one.
+type RetryPolicy struct {
+	// maxAttempts is the maximum number of retry attempts after the initial failure.
+	maxAttempts int
+	// backoff is the wait time between attempts.
+	backoff Backoff
+	// successProbabilities is the probability of each retry attempt succeeding.
+	// The last value is used for all remaining attempts.
+	successProbabilities []float64
+	// randomness is a function that returns a random value between 0 and 1.
+	randomness func() float64
+}
+
+// NewRetryPolicy creates a new RetryPolicy.
+func NewRetryPolicy(maxAttempts int, backoff Backoff, successProbabilities []float64, randomness func() float64) (*RetryPolicy, error) {
+	if maxAttempts < 1 {
+		return nil, fmt.Errorf("max attempts must be greater than 0, got %d", maxAttempts)
+	}
+	if len(successProbabilities) == 0 {
+		return nil, fmt.Errorf("at least one success probability is required")
+	}
+	for _, p := range successProbabilities {
+		if p < 0 || p > 1 {
+			return nil, fmt.Errorf("success probability must be between 0 and 1, got %f", p)
+		}
+	}
+	if randomness == nil {
+		return nil, fmt.Errorf("retry policy requires a randomness function")
+	}
+	return &RetryPolicy{
+		maxAttempts:          maxAttempts,
+		backoff:              backoff,
+		successProbabilities: successProbabilities,
+		randomness:           randomness,
+	}, nil
+}
+
+// MaxAttempts returns the maximum number of retry attempts.
+func (r *RetryPolicy) MaxAttempts() int {
+	return r.maxAttempts
+}
+
+// Backoff returns the wait time between attempts.
+func (r *RetryPolicy) Backoff() Backoff {
+	return r.backoff
+}
+
+// SuccessProbability returns the probability of the given retry attempt, starting from 1, succeeding.
+func (r *RetryPolicy) SuccessProbability(attempt int) float64 {
+	i := attempt - 1
+	if i >= len(r.successProbabilities) {
+		i = len(r.successProbabilities) - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	return r.successProbabilities[i]
+}
+
+// Randomness returns the randomness function used to decide whether an attempt succeeds.
+func (r *RetryPolicy) Randomness() func() float64 {
+	return r.randomness
+}
@@ -0,0 +1,22 @@
+package task
+
+import "fmt"
+
+// ScaleDurationEffect is a conditional definition effect that multiplies the duration of the task by a factor.
+// Children and events of the task are stretched accordingly.
+type ScaleDurationEffect struct {
+	factor float64
+}
+
+// NewScaleDurationEffect creates a new ScaleDurationEffect with the given factor.
+func NewScaleDurationEffect(factor float64) (*ScaleDurationEffect, error) {
+	if factor <= 0 {
+		return nil, fmt.Errorf("scale factor must be greater than 0, got %f", factor)
+	}
+	return &ScaleDurationEffect{factor: factor}, nil
+}
+
+// Factor returns the factor applied to the duration.
+func (s ScaleDurationEffect) Factor() float64 {
+	return s.factor
+}
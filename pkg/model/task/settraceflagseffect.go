@@ -0,0 +1,17 @@
+package task
+
+// SetTraceFlagsEffect is a conditional definition effect that replaces the trace flags of the task and its descendants,
+// such as a service deciding to sample a trace it received unsampled.
+type SetTraceFlagsEffect struct {
+	flags byte
+}
+
+// NewSetTraceFlagsEffect creates a new SetTraceFlagsEffect with the given trace flags.
+func NewSetTraceFlagsEffect(flags byte) SetTraceFlagsEffect {
+	return SetTraceFlagsEffect{flags: flags}
+}
+
+// Flags returns the trace flags to set.
+func (s SetTraceFlagsEffect) Flags() byte {
+	return s.flags
+}
@@ -0,0 +1,10 @@
+package task
+
+// ShortCircuitEffect is a conditional definition effect that drops all children starting after the first failed child,
+// simulating an early exit on error.
+type ShortCircuitEffect struct{}
+
+// NewShortCircuitEffect creates a new ShortCircuitEffect.
+func NewShortCircuitEffect() ShortCircuitEffect {
+	return ShortCircuitEffect{}
+}
@@ -0,0 +1,37 @@
+package taskduration
+
+import (
+	"fmt"
+	"time"
+)
+
+var _ Expression = AutoDuration{}
+
+// AutoDuration represents a duration derived from the children of a task so that the task covers all of them,
+// plus the self time the task spends before its first child starts and after its last child ends.
+// It cannot be resolved on its own.
+type AutoDuration struct {
+	before time.Duration
+	after  time.Duration
+}
+
+func NewAutoDuration(before time.Duration, after time.Duration) (*AutoDuration, error) {
+	if before < 0 || after < 0 {
+		return nil, fmt.Errorf("self time of auto duration cannot be negative, got before %s and after %s", before, after)
+	}
+	return &AutoDuration{before: before, after: after}, nil
+}
+
+func (a AutoDuration) Resolve(_ interface{}) (*time.Duration, error) {
+	return nil, fmt.Errorf("auto duration is derived from children and cannot be resolved on its own")
+}
+
+// Before returns the self time before the children start
+func (a AutoDuration) Before() time.Duration {
+	return a.before
+}
+
+// After returns the self time after the children end
+func (a AutoDuration) After() time.Duration {
+	return a.after
+}
@@ -0,0 +1,15 @@
+package taskduration
+
+import "time"
+
+// Context holds the values a Formula is resolved against.
+type Context struct {
+	// Parent is the duration of the parent span, nil for a root span
+	Parent *time.Duration
+	// Siblings maps the names of the siblings laid out so far to their end times,
+	// measured from the point the resolved value is applied from
+	Siblings map[string]time.Duration
+	// Randomness returns a random value between 0 and 1, used by the sampling functions.
+	// Formula.Resolve falls back to the randomness the formula was parsed with when it is nil.
+	Randomness func() float64
+}
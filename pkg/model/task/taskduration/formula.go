@@ -0,0 +1,308 @@
+package taskduration
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+var _ Expression = Formula{}
+
+// Formula represents a duration computed from an expression every time it is resolved.
+// See Parse for the syntax.
+type Formula struct {
+	source     string
+	root       node
+	randomness func() float64
+}
+
+// Source returns the formula as it was written
+func (f Formula) Source() string {
+	return f.source
+}
+
+// Randomness returns the randomness function the formula was parsed with
+func (f Formula) Randomness() func() float64 {
+	return f.randomness
+}
+
+func (f Formula) String() string {
+	return f.source
+}
+
+// Resolve evaluates the formula against a *Context.
+// A *time.Duration is also accepted as the duration of the parent.
+// The sampling functions use the randomness of the context if set, and the one the formula was parsed with otherwise.
+func (f Formula) Resolve(context interface{}) (*time.Duration, error) {
+	var ctx *Context
+	switch c := context.(type) {
+	case *Context:
+		copied := *c
+		ctx = &copied
+	case *time.Duration:
+		ctx = &Context{Parent: c}
+	case nil:
+		ctx = &Context{}
+	default:
+		return nil, fmt.Errorf("failed to resolve formula %q: invalid context type %T, expected *taskduration.Context", f.source, context)
+	}
+	if ctx.Randomness == nil {
+		ctx.Randomness = f.randomness
+	}
+	v, err := f.root.eval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve formula %q: %w", f.source, err)
+	}
+	if !v.isDuration {
+		return nil, fmt.Errorf("failed to resolve formula %q: result is a number, not a duration", f.source)
+	}
+	d := time.Duration(v.amount)
+	return &d, nil
+}
+
+// value is either a duration in nanoseconds or a plain number
+type value struct {
+	amount     float64
+	isDuration bool
+}
+
+func (v value) describe() string {
+	if v.isDuration {
+		return "duration"
+	}
+	return "number"
+}
+
+type node interface {
+	eval(ctx *Context) (value, error)
+}
+
+type literalNode struct {
+	value value
+}
+
+func (n *literalNode) eval(_ *Context) (value, error) {
+	return n.value, nil
+}
+
+type parentNode struct {
+	pos int
+}
+
+func (n *parentNode) eval(ctx *Context) (value, error) {
+	if ctx.Parent == nil {
+		return value{}, fmt.Errorf("parent at position %d requires a parent span", n.pos)
+	}
+	return value{amount: float64(*ctx.Parent), isDuration: true}, nil
+}
+
+// placeholderNode is a parameter reference left to be substituted before the formula is resolved
+type placeholderNode struct {
+	text string
+	pos  int
+}
+
+func (n *placeholderNode) eval(_ *Context) (value, error) {
+	return value{}, fmt.Errorf("unbound parameter %s at position %d", n.text, n.pos)
+}
+
+type negateNode struct {
+	operand node
+}
+
+func (n *negateNode) eval(ctx *Context) (value, error) {
+	v, err := n.operand.eval(ctx)
+	if err != nil {
+		return value{}, err
+	}
+	v.amount = -v.amount
+	return v, nil
+}
+
+type binaryNode struct {
+	op    string
+	left  node
+	right node
+	pos   int
+}
+
+func (n *binaryNode) eval(ctx *Context) (value, error) {
+	l, err := n.left.eval(ctx)
+	if err != nil {
+		return value{}, err
+	}
+	r, err := n.right.eval(ctx)
+	if err != nil {
+		return value{}, err
+	}
+	mismatch := func() (value, error) {
+		return value{}, fmt.Errorf("cannot apply %s to %s and %s at position %d", n.op, l.describe(), r.describe(), n.pos)
+	}
+	switch n.op {
+	case "+", "-":
+		if l.isDuration != r.isDuration {
+			return mismatch()
+		}
+		if n.op == "-" {
+			return value{amount: l.amount - r.amount, isDuration: l.isDuration}, nil
+		}
+		return value{amount: l.amount + r.amount, isDuration: l.isDuration}, nil
+	case "*":
+		if l.isDuration && r.isDuration {
+			return mismatch()
+		}
+		return value{amount: l.amount * r.amount, isDuration: l.isDuration || r.isDuration}, nil
+	default:
+		if !l.isDuration && r.isDuration {
+			return mismatch()
+		}
+		if r.amount == 0 {
+			return value{}, fmt.Errorf("division by zero at position %d", n.pos)
+		}
+		// a duration divided by a duration is a ratio
+		return value{amount: l.amount / r.amount, isDuration: l.isDuration && !r.isDuration}, nil
+	}
+}
+
+type callNode struct {
+	name  string
+	args  []node
+	names []string
+	pos   int
+}
+
+func (n *callNode) eval(ctx *Context) (value, error) {
+	args := make([]value, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(ctx)
+		if err != nil {
+			return value{}, err
+		}
+		args[i] = v
+	}
+	v, err := functions[n.name].eval(ctx, args, n.names)
+	if err != nil {
+		return value{}, fmt.Errorf("%s at position %d: %w", n.name, n.pos, err)
+	}
+	return v, nil
+}
+
+// function describes a function available in formulas
+type function struct {
+	minArgs int
+	// maxArgs is negative for functions taking any number of arguments
+	maxArgs int
+	// takesName is true for functions whose arguments are quoted names rather than expressions
+	takesName bool
+	// samples is true for functions drawing random values, which require a randomness function
+	samples bool
+	eval    func(ctx *Context, args []value, names []string) (value, error)
+}
+
+func (f function) arity() string {
+	switch {
+	case f.maxArgs < 0:
+		return fmt.Sprintf("takes at least %d arguments", f.minArgs)
+	case f.minArgs == 1 && f.maxArgs == 1:
+		return "takes 1 argument"
+	default:
+		return fmt.Sprintf("takes %d arguments", f.maxArgs)
+	}
+}
+
+var functions = map[string]function{
+	"min": {minArgs: 1, maxArgs: -1, eval: func(_ *Context, args []value, _ []string) (value, error) {
+		return extreme(args, math.Min)
+	}},
+	"max": {minArgs: 1, maxArgs: -1, eval: func(_ *Context, args []value, _ []string) (value, error) {
+		return extreme(args, math.Max)
+	}},
+	"sibling": {minArgs: 1, maxArgs: 1, takesName: true, eval: func(ctx *Context, _ []value, names []string) (value, error) {
+		end, ok := ctx.Siblings[names[0]]
+		if !ok {
+			return value{}, fmt.Errorf("no preceding sibling named %q", names[0])
+		}
+		return value{amount: float64(end), isDuration: true}, nil
+	}},
+	"uniform": {minArgs: 2, maxArgs: 2, samples: true, eval: func(ctx *Context, args []value, _ []string) (value, error) {
+		if err := expectKinds(args, true, true); err != nil {
+			return value{}, err
+		}
+		u, err := random(ctx)
+		if err != nil {
+			return value{}, err
+		}
+		return value{amount: args[0].amount + (args[1].amount-args[0].amount)*u, isDuration: true}, nil
+	}},
+	"normal": {minArgs: 2, maxArgs: 2, samples: true, eval: func(ctx *Context, args []value, _ []string) (value, error) {
+		if err := expectKinds(args, true, true); err != nil {
+			return value{}, err
+		}
+		z, err := standardNormal(ctx)
+		if err != nil {
+			return value{}, err
+		}
+		// the tail of the distribution below 0 is clamped so that a sample is never a negative duration
+		return value{amount: math.Max(args[0].amount+args[1].amount*z, 0), isDuration: true}, nil
+	}},
+	"lognormal": {minArgs: 2, maxArgs: 2, samples: true, eval: func(ctx *Context, args []value, _ []string) (value, error) {
+		if err := expectKinds(args, true, false); err != nil {
+			return value{}, err
+		}
+		if args[0].amount <= 0 {
+			return value{}, fmt.Errorf("median must be greater than 0")
+		}
+		z, err := standardNormal(ctx)
+		if err != nil {
+			return value{}, err
+		}
+		return value{amount: args[0].amount * math.Exp(args[1].amount*z), isDuration: true}, nil
+	}},
+}
+
+func extreme(args []value, pick func(a, b float64) float64) (value, error) {
+	result := args[0]
+	for _, arg := range args[1:] {
+		if arg.isDuration != result.isDuration {
+			return value{}, fmt.Errorf("cannot compare %s and %s", result.describe(), arg.describe())
+		}
+		result.amount = pick(result.amount, arg.amount)
+	}
+	return result, nil
+}
+
+func expectKinds(args []value, kinds ...bool) error {
+	want := make([]string, len(kinds))
+	ok := true
+	for i, isDuration := range kinds {
+		want[i] = value{isDuration: isDuration}.describe()
+		if args[i].isDuration != isDuration {
+			ok = false
+		}
+	}
+	if !ok {
+		return fmt.Errorf("expected arguments (%s)", strings.Join(want, ", "))
+	}
+	return nil
+}
+
+// random returns a random value between 0 and 1 from the context
+func random(ctx *Context) (float64, error) {
+	if ctx.Randomness == nil {
+		return 0, fmt.Errorf("randomness function is required")
+	}
+	return ctx.Randomness(), nil
+}
+
+// standardNormal samples from the standard normal distribution by inverting its CDF,
+// so that a random value of 0.5 yields the mean
+func standardNormal(ctx *Context) (float64, error) {
+	u, err := random(ctx)
+	if err != nil {
+		return 0, err
+	}
+	// keep the value away from 0 and 1 where the inverse is infinite
+	u = math.Min(math.Max(u, 1e-9), 1-1e-9)
+	return math.Sqrt2 * math.Erfinv(2*u-1), nil
+}
@@ -0,0 +1,139 @@
+package taskduration
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormula_Resolve(t *testing.T) {
+	type testCase struct {
+		name       string
+		source     string
+		randomness func() float64
+		context    interface{}
+		expected   time.Duration
+	}
+
+	parent := 200 * time.Millisecond
+	ctx := &Context{
+		Parent:   &parent,
+		Siblings: map[string]time.Duration{"db": 30 * time.Millisecond},
+	}
+	testCases := []testCase{
+		{name: "duration literal", source: "5ms", context: ctx, expected: 5 * time.Millisecond},
+		{name: "duration literal with several units", source: "1m30s", context: ctx, expected: 90 * time.Second},
+		{name: "fraction of parent plus offset", source: "parent * 0.3 + 5ms", context: ctx, expected: 65 * time.Millisecond},
+		{name: "operator precedence and parentheses", source: "(parent - 100ms) * 2 / 4", context: ctx, expected: 50 * time.Millisecond},
+		{name: "unary minus", source: "parent + -50ms", context: ctx, expected: 150 * time.Millisecond},
+		{name: "ratio of durations", source: "10ms * (parent / 100ms)", context: ctx, expected: 20 * time.Millisecond},
+		{name: "max of sibling and literal", source: `max(20ms, sibling("db") )`, context: ctx, expected: 30 * time.Millisecond},
+		{name: "min", source: "min(parent, 1s, 150ms)", context: ctx, expected: 150 * time.Millisecond},
+		{name: "uniform", source: "uniform(10ms, 30ms)", randomness: constant(0.5), context: ctx, expected: 20 * time.Millisecond},
+		{name: "lognormal", source: "lognormal(50ms, 0.4)", randomness: constant(0.5), context: ctx, expected: 50 * time.Millisecond},
+		{name: "normal", source: "normal(50ms, 10ms)", randomness: constant(0.5), context: ctx, expected: 50 * time.Millisecond},
+		{name: "normal clamped at 0", source: "normal(10ms, 100ms)", randomness: constant(0.01), context: ctx, expected: 0},
+		{name: "randomness of the context over that of the formula", source: "uniform(10ms, 30ms)", randomness: constant(0.5), context: &Context{Randomness: constant(1)}, expected: 30 * time.Millisecond},
+		{name: "parent duration as context", source: "parent / 2", context: &parent, expected: 100 * time.Millisecond},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := Parse(tc.source, tc.randomness)
+			if err != nil {
+				t.Fatalf("failed to parse formula: %v", err)
+			}
+			if f.Source() != tc.source {
+				t.Errorf("expected source %q, got %q", tc.source, f.Source())
+			}
+			result, err := f.Resolve(tc.context)
+			if err != nil {
+				t.Fatalf("did not expect an error but got: %v", err)
+			}
+			if diff := *result - tc.expected; diff < -time.Microsecond || diff > time.Microsecond {
+				t.Errorf("expected %v, got %v", tc.expected, *result)
+			}
+		})
+	}
+}
+
+func TestFormula_ResolveError(t *testing.T) {
+	type testCase struct {
+		name    string
+		source  string
+		context interface{}
+	}
+
+	parent := 200 * time.Millisecond
+	ctx := &Context{
+		Parent:   &parent,
+		Siblings: map[string]time.Duration{"db": 30 * time.Millisecond},
+	}
+	testCases := []testCase{
+		{name: "parent without parent span", source: "parent * 0.5", context: &Context{}},
+		{name: "unknown sibling", source: `sibling("cache")`, context: ctx},
+		{name: "number result", source: "2 * 3", context: ctx},
+		{name: "adding number to duration", source: "parent + 5", context: ctx},
+		{name: "multiplying durations", source: "parent * 5ms", context: ctx},
+		{name: "division by zero", source: "parent / 0", context: ctx},
+		{name: "invalid context", source: "5ms", context: "invalid"},
+		{name: "unbound placeholder", source: "${latency} * 2", context: ctx},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := Parse(tc.source, nil)
+			if err != nil {
+				t.Fatalf("failed to parse formula: %v", err)
+			}
+			if _, err := f.Resolve(tc.context); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	type testCase struct {
+		source   string
+		position int
+		message  string
+	}
+
+	testCases := []testCase{
+		{source: "parent * ", position: 9, message: "got end of formula"},
+		{source: "parent * 0.3 +", position: 14, message: "got end of formula"},
+		{source: "5 parsecs", position: 2, message: `unexpected "parsecs"`},
+		{source: "parnt * 0.5", position: 0, message: `unknown identifier "parnt"`},
+		{source: "10xs", position: 0, message: `invalid duration "10xs"`},
+		{source: "max(20ms, 10ms", position: 14, message: `expected "," or ")"`},
+		{source: `sibling(db)`, position: 8, message: "expected a quoted name"},
+		{source: `sibling("db`, position: 8, message: "unterminated string"},
+		{source: "lognormal(50ms)", position: 0, message: "lognormal takes 2 arguments, got 1"},
+		{source: "median(1ms)", position: 0, message: `unknown function "median"`},
+		{source: "parent % 2", position: 7, message: `unexpected character '%'`},
+		{source: "parent 2", position: 7, message: `unexpected "2"`},
+		{source: "10ms + uniform(10ms, 30ms)", position: 7, message: "uniform requires a randomness function"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.source, func(t *testing.T) {
+			_, err := Parse(tc.source, nil)
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("expected a ParseError, got %v", err)
+			}
+			if parseErr.Position != tc.position {
+				t.Errorf("expected position %d, got %d (%v)", tc.position, parseErr.Position, err)
+			}
+			if !strings.Contains(parseErr.Message, tc.message) {
+				t.Errorf("expected message to contain %q, got %q", tc.message, parseErr.Message)
+			}
+		})
+	}
+}
+
+// constant returns a randomness function always returning v
+func constant(v float64) func() float64 {
+	return func() float64 { return v }
+}
@@ -0,0 +1,311 @@
+package taskduration
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ParseError describes a syntax error in a formula and where it occurred
+type ParseError struct {
+	Source   string
+	Position int
+	Message  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("invalid duration formula %q at position %d: %s", e.Source, e.Position, e.Message)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenDuration
+	tokenIdent
+	tokenString
+	tokenOperator
+	tokenLeftParen
+	tokenRightParen
+	tokenComma
+	tokenPlaceholder
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	pos   int
+	value float64
+}
+
+func (t token) describe() string {
+	if t.kind == tokenEOF {
+		return "end of formula"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// Parse parses a duration formula such as `parent * 0.3 + 5ms`, `max(20ms, sibling("db"))` or `lognormal(50ms, 0.4)`.
+//
+// A formula combines duration literals (e.g. 1.5s, 200ms), plain numbers, the duration of the parent (`parent`)
+// and the following functions with +, -, * and / and parentheses:
+//   - min(a, b, ...) and max(a, b, ...) return the smallest and largest argument
+//   - sibling("name") returns the end time of the preceding sibling with the given name
+//   - uniform(min, max) samples a duration uniformly between min and max
+//   - normal(mean, stddev) samples a duration from a normal distribution, clamped at 0
+//   - lognormal(median, sigma) samples a duration from a log-normal distribution
+//
+// The sampling functions draw their random values from randomness, which may be nil for a formula that does not sample.
+//
+// A placeholder such as ${latency} is accepted so that templates can be validated before their parameters are bound,
+// but resolving a formula that still contains one fails.
+func Parse(source string, randomness func() float64) (*Formula, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{source: source, tokens: tokens, randomness: randomness}
+	root, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf(t.pos, "unexpected %s", t.describe())
+	}
+	return &Formula{source: source, root: root, randomness: randomness}, nil
+}
+
+func tokenize(source string) ([]token, error) {
+	tokens := make([]token, 0)
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			unitStart := i
+			// a unit turns the number into a duration, which may have several components such as 1m30s
+			for i < len(runes) && unicode.IsLetter(runes[i]) {
+				for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '.') {
+					i++
+				}
+			}
+			text := string(runes[start:i])
+			if unitStart == i {
+				v, err := strconv.ParseFloat(text, 64)
+				if err != nil {
+					return nil, &ParseError{Source: source, Position: start, Message: fmt.Sprintf("invalid number %q", text)}
+				}
+				tokens = append(tokens, token{kind: tokenNumber, text: text, pos: start, value: v})
+				continue
+			}
+			d, err := time.ParseDuration(text)
+			if err != nil {
+				return nil, &ParseError{Source: source, Position: start, Message: fmt.Sprintf("invalid duration %q, expected a unit such as ns, us, ms, s, m or h", text)}
+			}
+			tokens = append(tokens, token{kind: tokenDuration, text: text, pos: start, value: float64(d)})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:i]), pos: start})
+		case r == '"':
+			start := i
+			i++
+			var sb strings.Builder
+			for i < len(runes) && runes[i] != '"' {
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if i == len(runes) {
+				return nil, &ParseError{Source: source, Position: start, Message: "unterminated string"}
+			}
+			i++
+			tokens = append(tokens, token{kind: tokenString, text: sb.String(), pos: start})
+		case r == '$' && i+1 < len(runes) && runes[i+1] == '{':
+			start := i
+			end := start + 2
+			for end < len(runes) && runes[end] != '}' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, &ParseError{Source: source, Position: start, Message: "unterminated placeholder"}
+			}
+			tokens = append(tokens, token{kind: tokenPlaceholder, text: string(runes[start : end+1]), pos: start})
+			i = end + 1
+		case strings.ContainsRune("+-*/", r):
+			tokens = append(tokens, token{kind: tokenOperator, text: string(r), pos: i})
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLeftParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRightParen, text: ")", pos: i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", pos: i})
+			i++
+		default:
+			return nil, &ParseError{Source: source, Position: i, Message: fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+type parser struct {
+	source     string
+	tokens     []token
+	pos        int
+	randomness func() float64
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(pos int, format string, args ...interface{}) error {
+	return &ParseError{Source: p.source, Position: pos, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) expect(kind tokenKind, want string) (token, error) {
+	t := p.next()
+	if t.kind != kind {
+		return t, p.errorf(t.pos, "expected %s, got %s", want, t.describe())
+	}
+	return t, nil
+}
+
+// parseExpression parses additions and subtractions
+func (p *parser) parseExpression() (node, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for t := p.peek(); t.kind == tokenOperator && (t.text == "+" || t.text == "-"); t = p.peek() {
+		p.next()
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: t.text, left: left, right: right, pos: t.pos}
+	}
+	return left, nil
+}
+
+// parseTerm parses multiplications and divisions
+func (p *parser) parseTerm() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for t := p.peek(); t.kind == tokenOperator && (t.text == "*" || t.text == "/"); t = p.peek() {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: t.text, left: left, right: right, pos: t.pos}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if t := p.peek(); t.kind == tokenOperator && t.text == "-" {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &negateNode{operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		return &literalNode{value: value{amount: t.value}}, nil
+	case tokenDuration:
+		return &literalNode{value: value{amount: t.value, isDuration: true}}, nil
+	case tokenPlaceholder:
+		return &placeholderNode{text: t.text, pos: t.pos}, nil
+	case tokenLeftParen:
+		inner, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenRightParen, `")"`); err != nil {
+			return nil, err
+		}
+		return inner, nil
+	case tokenIdent:
+		if p.peek().kind != tokenLeftParen {
+			if t.text == "parent" {
+				return &parentNode{pos: t.pos}, nil
+			}
+			return nil, p.errorf(t.pos, "unknown identifier %q, expected parent or a function call", t.text)
+		}
+		return p.parseCall(t)
+	default:
+		return nil, p.errorf(t.pos, "expected a duration, number, parent or function call, got %s", t.describe())
+	}
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	fn, ok := functions[name.text]
+	if !ok {
+		return nil, p.errorf(name.pos, "unknown function %q", name.text)
+	}
+	p.next()
+	call := &callNode{name: name.text, pos: name.pos}
+	if p.peek().kind != tokenRightParen {
+		for {
+			if fn.takesName {
+				arg, err := p.expect(tokenString, "a quoted name")
+				if err != nil {
+					return nil, err
+				}
+				call.names = append(call.names, arg.text)
+			} else {
+				arg, err := p.parseExpression()
+				if err != nil {
+					return nil, err
+				}
+				call.args = append(call.args, arg)
+			}
+			if p.peek().kind != tokenComma {
+				break
+			}
+			p.next()
+		}
+	}
+	if _, err := p.expect(tokenRightParen, `"," or ")"`); err != nil {
+		return nil, err
+	}
+	n := len(call.args) + len(call.names)
+	if n < fn.minArgs || (fn.maxArgs >= 0 && n > fn.maxArgs) {
+		return nil, p.errorf(name.pos, "%s %s, got %d", name.text, fn.arity(), n)
+	}
+	if fn.samples && p.randomness == nil {
+		return nil, p.errorf(name.pos, "%s requires a randomness function", name.text)
+	}
+	return call, nil
+}
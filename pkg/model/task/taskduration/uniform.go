@@ -0,0 +1,34 @@
+package taskduration
+
+import (
+	"fmt"
+	"time"
+)
+
+var _ Expression = UniformDuration{}
+
+// UniformDuration represents a duration sampled uniformly between min and max every time it is resolved.
+type UniformDuration struct {
+	min time.Duration
+	max time.Duration
+	// randomness is a function that returns a random value between 0 and 1.
+	randomness func() float64
+}
+
+func NewUniformDuration(min time.Duration, max time.Duration, randomness func() float64) (*UniformDuration, error) {
+	if min < 0 {
+		return nil, fmt.Errorf("uniform duration cannot be negative, got %s", min)
+	}
+	if max < min {
+		return nil, fmt.Errorf("uniform duration max must be greater than or equal to min, got min %s and max %s", min, max)
+	}
+	if randomness == nil {
+		return nil, fmt.Errorf("uniform duration requires a randomness function")
+	}
+	return &UniformDuration{min: min, max: max, randomness: randomness}, nil
+}
+
+func (u UniformDuration) Resolve(_ interface{}) (*time.Duration, error) {
+	d := u.min + time.Duration(float64(u.max-u.min)*u.randomness())
+	return &d, nil
+}
@@ -0,0 +1,40 @@
+package taskduration
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUniformDuration_Resolve(t *testing.T) {
+	type testCase struct {
+		name     string
+		random   float64
+		expected time.Duration
+	}
+
+	testCases := []testCase{
+		{name: "lower bound", random: 0.0, expected: 100 * time.Millisecond},
+		{name: "midpoint", random: 0.5, expected: 200 * time.Millisecond},
+		{name: "upper bound", random: 1.0, expected: 300 * time.Millisecond},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ud, err := NewUniformDuration(100*time.Millisecond, 300*time.Millisecond, func() float64 { return tc.random })
+			if err != nil {
+				t.Fatalf("failed to create UniformDuration: %v", err)
+			}
+			result, err := ud.Resolve(nil)
+			if err != nil {
+				t.Errorf("did not expect an error but got: %v", err)
+			}
+			if result == nil || *result != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+
+	if _, err := NewUniformDuration(300*time.Millisecond, 100*time.Millisecond, func() float64 { return 0 }); err == nil {
+		t.Errorf("expected an error when max is less than min")
+	}
+}
@@ -0,0 +1,31 @@
+package task
+
+import "fmt"
+
+// Template is a named task subtree that can be injected into a trace at simulation time.
+type Template struct {
+	name string
+	root *TreeNode
+}
+
+// NewTemplate creates a new Template with the given name and root task.
+// Tasks without a resource inherit the resource of the task they are injected into.
+func NewTemplate(name string, root *TreeNode) (*Template, error) {
+	if name == "" {
+		return nil, fmt.Errorf("template name cannot be empty")
+	}
+	if root == nil {
+		return nil, fmt.Errorf("template %s requires a root task", name)
+	}
+	return &Template{name: name, root: root}, nil
+}
+
+// Name returns the name of the template.
+func (t *Template) Name() string {
+	return t.name
+}
+
+// Root returns the root task of the template.
+func (t *Template) Root() *TreeNode {
+	return t.root
+}
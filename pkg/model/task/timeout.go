@@ -0,0 +1,48 @@
+package task
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeoutChildPolicy defines what happens to in-flight children when a task times out.
+type TimeoutChildPolicy string
+
+const (
+	// TimeoutChildPolicyTruncate cuts in-flight children at the timeout and drops children that have not started yet
+	TimeoutChildPolicyTruncate TimeoutChildPolicy = "truncate"
+	// TimeoutChildPolicyOrphan leaves children running past the end of the timed out task
+	TimeoutChildPolicyOrphan TimeoutChildPolicy = "orphan"
+)
+
+// Timeout represents the maximum duration of a task.
+type Timeout struct {
+	duration    time.Duration
+	childPolicy TimeoutChildPolicy
+}
+
+// NewTimeout creates a new Timeout with the given duration and child policy.
+func NewTimeout(duration time.Duration, childPolicy TimeoutChildPolicy) (*Timeout, error) {
+	if duration <= 0 {
+		return nil, fmt.Errorf("timeout must be greater than 0, got %s", duration)
+	}
+	switch childPolicy {
+	case TimeoutChildPolicyTruncate, TimeoutChildPolicyOrphan:
+	default:
+		return nil, fmt.Errorf("unsupported timeout child policy: %s", childPolicy)
+	}
+	return &Timeout{
+		duration:    duration,
+		childPolicy: childPolicy,
+	}, nil
+}
+
+// Duration returns the maximum duration of the task.
+func (t *Timeout) Duration() time.Duration {
+	return t.duration
+}
+
+// ChildPolicy returns what happens to in-flight children when the task times out.
+func (t *Timeout) ChildPolicy() TimeoutChildPolicy {
+	return t.childPolicy
+}
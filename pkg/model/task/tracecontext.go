@@ -0,0 +1,85 @@
+package task
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxTraceStateMembers is the maximum number of list members of a tracestate allowed by W3C trace context
+const maxTraceStateMembers = 32
+
+// TraceContext holds the W3C trace flags and tracestate a task starts with, such as the context received by a root span.
+// The descendants of the task inherit it unless they define their own.
+type TraceContext struct {
+	flags byte
+	state string
+}
+
+// NewTraceContext creates a new TraceContext with the given trace flags and tracestate header value
+func NewTraceContext(flags byte, state string) (*TraceContext, error) {
+	if err := validateTraceState(state); err != nil {
+		return nil, err
+	}
+	return &TraceContext{flags: flags, state: state}, nil
+}
+
+func (c *TraceContext) Flags() byte {
+	return c.flags
+}
+
+func (c *TraceContext) State() string {
+	return c.state
+}
+
+// validateTraceState checks that the tracestate is a list of key=value members with unique keys
+func validateTraceState(state string) error {
+	if strings.TrimSpace(state) == "" {
+		return nil
+	}
+	members := strings.Split(state, ",")
+	if len(members) > maxTraceStateMembers {
+		return fmt.Errorf("tracestate cannot have more than %d members, got %d", maxTraceStateMembers, len(members))
+	}
+	keys := make(map[string]struct{}, len(members))
+	for _, member := range members {
+		key, value, found := strings.Cut(strings.TrimSpace(member), "=")
+		if !found {
+			return fmt.Errorf("invalid tracestate member %q", member)
+		}
+		if err := validateTraceStateKey(key); err != nil {
+			return err
+		}
+		if err := validateTraceStateValue(value); err != nil {
+			return err
+		}
+		if _, exists := keys[key]; exists {
+			return fmt.Errorf("duplicate tracestate key %s", key)
+		}
+		keys[key] = struct{}{}
+	}
+	return nil
+}
+
+func validateTraceStateKey(key string) error {
+	if key == "" || len(key) > 256 {
+		return fmt.Errorf("invalid tracestate key %q", key)
+	}
+	for _, r := range key {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && !strings.ContainsRune("_-*/@", r) {
+			return fmt.Errorf("invalid tracestate key %q", key)
+		}
+	}
+	return nil
+}
+
+func validateTraceStateValue(value string) error {
+	if value == "" || len(value) > 256 || strings.HasSuffix(value, " ") {
+		return fmt.Errorf("invalid tracestate value %q", value)
+	}
+	for _, r := range value {
+		if r < 0x20 || r > 0x7e || r == ',' || r == '=' {
+			return fmt.Errorf("invalid tracestate value %q", value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package task
+
+// UpdateTraceStateEffect is a conditional definition effect that sets a member of the tracestate of the task and its
+// descendants, such as a vendor recording its decision. An empty value removes the member.
+type UpdateTraceStateEffect struct {
+	key   string
+	value string
+}
+
+// NewUpdateTraceStateEffect creates a new UpdateTraceStateEffect setting the member with the given key to the value.
+func NewUpdateTraceStateEffect(key string, value string) (*UpdateTraceStateEffect, error) {
+	if err := validateTraceStateKey(key); err != nil {
+		return nil, err
+	}
+	if value != "" {
+		if err := validateTraceStateValue(value); err != nil {
+			return nil, err
+		}
+	}
+	return &UpdateTraceStateEffect{key: key, value: value}, nil
+}
+
+// Key returns the key of the member to set.
+func (u UpdateTraceStateEffect) Key() string {
+	return u.key
+}
+
+// Value returns the value of the member, empty to remove it.
+func (u UpdateTraceStateEffect) Value() string {
+	return u.value
+}
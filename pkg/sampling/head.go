@@ -0,0 +1,47 @@
+package sampling
+
+import (
+	"fmt"
+	simulator "github.com/k4ji/tracesimulator/pkg"
+	"github.com/k4ji/tracesimulator/pkg/model/span"
+)
+
+var _ simulator.Stage = (*HeadSampler)(nil)
+
+// HeadSampler keeps traces with a fixed probability following the OpenTelemetry consistent probability sampling.
+// The decision is made from the randomness of the trace ID, so that it is consistent with the other samplers,
+// and the kept traces are marked as sampled with the threshold recorded in the th value of their tracestate.
+type HeadSampler struct {
+	threshold uint64
+}
+
+// NewHeadSampler creates a new HeadSampler keeping traces with the given probability
+func NewHeadSampler(probability float64) (*HeadSampler, error) {
+	threshold, err := ThresholdFromProbability(probability)
+	if err != nil {
+		return nil, err
+	}
+	return &HeadSampler{threshold: threshold}, nil
+}
+
+func (h *HeadSampler) Process(rootSpans []*span.TreeNode) ([]*span.TreeNode, error) {
+	kept := make([]*span.TreeNode, 0, len(rootSpans))
+	for _, root := range rootSpans {
+		threshold, err := thresholdOf(root)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read threshold of trace %s: %w", root.TraceID(), err)
+		}
+		// sampling a sampled trace again keeps the stricter threshold
+		threshold = max(threshold, h.threshold)
+		randomness, err := randomnessOf(root)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read randomness of trace %s: %w", root.TraceID(), err)
+		}
+		if randomness < threshold {
+			continue
+		}
+		keep(root, &threshold)
+		kept = append(kept, root)
+	}
+	return kept, nil
+}
@@ -0,0 +1,142 @@
+package sampling
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/model/span"
+	"github.com/k4ji/tracesimulator/pkg/model/task"
+	"github.com/k4ji/tracesimulator/pkg/model/task/taskduration"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestHeadSampler_Process(t *testing.T) {
+	type testCase struct {
+		name string
+		// probabilities are those of the head samplers applied in turn
+		probabilities []float64
+		// randomness leads the randomness of each trace
+		randomness         []byte
+		expectedKept       []byte
+		expectedTraceState string
+	}
+
+	testCases := []testCase{
+		{
+			name:               "keep traces whose randomness reaches the threshold",
+			probabilities:      []float64{0.5},
+			randomness:         []byte{0x7f, 0x80},
+			expectedKept:       []byte{0x80},
+			expectedTraceState: "ot=th:8",
+		},
+		{
+			name:               "mark all spans of the kept traces as sampled with the threshold",
+			probabilities:      []float64{0.25},
+			randomness:         []byte{0xff},
+			expectedKept:       []byte{0xff},
+			expectedTraceState: "ot=th:c",
+		},
+		{
+			name:               "keep the stricter threshold when sampling again",
+			probabilities:      []float64{0.25, 0.5},
+			randomness:         []byte{0xff},
+			expectedKept:       []byte{0xff},
+			expectedTraceState: "ot=th:c",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			traces := make([]*span.TreeNode, len(tc.randomness))
+			for i, r := range tc.randomness {
+				traces[i] = newTrace(t, r, 0, 100*time.Millisecond, false, nil)
+			}
+			for _, p := range tc.probabilities {
+				sampler, err := NewHeadSampler(p)
+				assert.NoError(t, err)
+				traces, err = sampler.Process(traces)
+				assert.NoError(t, err)
+			}
+
+			assert.Len(t, traces, len(tc.expectedKept))
+			for i, kept := range traces {
+				assert.Equal(t, tc.expectedKept[i], kept.TraceID().Bytes()[9])
+				for _, n := range []*span.TreeNode{kept, kept.Children()[0]} {
+					assert.True(t, n.TraceFlags().IsSampled())
+					assert.Equal(t, tc.expectedTraceState, n.TraceState())
+				}
+			}
+		})
+	}
+}
+
+func TestNewHeadSamplerError(t *testing.T) {
+	type testCase struct {
+		name        string
+		probability float64
+	}
+
+	testCases := []testCase{
+		{name: "zero probability", probability: 0},
+		{name: "probability above 1", probability: 1.5},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewHeadSampler(tc.probability)
+			assert.ErrorContains(t, err, "sampling probability must be in (0, 1]")
+		})
+	}
+}
+
+// newTrace creates a trace of a root span with one child, whose randomness is led by the given byte
+func newTrace(t *testing.T, randomness byte, delay time.Duration, duration time.Duration, failed bool, attributes map[string]string) *span.TreeNode {
+	newNode := func(name string, d time.Duration, definitions []*task.ConditionalDefinition) *task.TreeNode {
+		def, err := task.NewDefinition(
+			name,
+			true,
+			task.NewResource("service-a", nil),
+			attributes,
+			task.KindServer,
+			nil,
+			NewAbsoluteDurationDelay(delay),
+			NewAbsoluteDurationDuration(d),
+			nil,
+			[]*task.ExternalID{},
+			[]task.Event{},
+			definitions,
+		)
+		assert.NoError(t, err)
+		return task.NewTreeNode(def)
+	}
+	definitions := make([]*task.ConditionalDefinition, 0)
+	if failed {
+		definitions = append(definitions, task.NewConditionalDefinition(
+			task.NewProbabilisticCondition(1.0, func() float64 { return 0 }),
+			[]task.Effect{task.FromMarkAsFailedEffect(task.NewMarkAsFailedEffect(nil))},
+		))
+	}
+	root := newNode("root", duration, nil)
+	_ = root.AddChild(newNode("child", duration, definitions))
+
+	var traceID [16]byte
+	traceID[9] = randomness
+	var next byte
+	rootSpan, err := span.FromTaskTree(root, span.NewTraceID(traceID), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), func() span.ID {
+		next++
+		return span.NewSpanID([8]byte{next})
+	})
+	assert.NoError(t, err)
+	return rootSpan
+}
+
+func NewAbsoluteDurationDelay(duration time.Duration) task.Delay {
+	e, _ := taskduration.NewAbsoluteDuration(duration)
+	d, _ := task.NewDelay(e)
+	return *d
+}
+
+func NewAbsoluteDurationDuration(duration time.Duration) task.Duration {
+	e, _ := taskduration.NewAbsoluteDuration(duration)
+	d, _ := task.NewDuration(e)
+	return *d
+}
@@ -0,0 +1,62 @@
+package sampling
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/model/span"
+	"time"
+)
+
+// Policy decides whether the tail sampler keeps a complete trace
+type Policy struct {
+	// Name is recorded on the root span of the traces kept by the policy
+	Name string
+	// Keep reports whether the trace of the root span is kept
+	Keep func(root *span.TreeNode) bool
+}
+
+// KeepErrors keeps the traces containing a span marked as failed
+func KeepErrors() Policy {
+	return Policy{
+		Name: "errors",
+		Keep: func(root *span.TreeNode) bool {
+			return anySpan(root, func(n *span.TreeNode) bool {
+				status := n.Status()
+				return status.Code() == span.StatusCodeError
+			})
+		},
+	}
+}
+
+// KeepSlow keeps the traces whose root span lasts at least the given duration
+func KeepSlow(threshold time.Duration) Policy {
+	return Policy{
+		Name: "slow",
+		Keep: func(root *span.TreeNode) bool {
+			return root.EndTime().Sub(root.StartTime()) >= threshold
+		},
+	}
+}
+
+// KeepAttribute keeps the traces containing a span with the attribute set to the value
+func KeepAttribute(key string, value string) Policy {
+	return Policy{
+		Name: "attribute:" + key,
+		Keep: func(root *span.TreeNode) bool {
+			return anySpan(root, func(n *span.TreeNode) bool {
+				v, ok := n.Attributes()[key]
+				return ok && v == value
+			})
+		},
+	}
+}
+
+func anySpan(node *span.TreeNode, predicate func(n *span.TreeNode) bool) bool {
+	if predicate(node) {
+		return true
+	}
+	for _, child := range node.Children() {
+		if anySpan(child, predicate) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package sampling
+
+import (
+	"fmt"
+	simulator "github.com/k4ji/tracesimulator/pkg"
+	"github.com/k4ji/tracesimulator/pkg/model/span"
+	"sort"
+	"time"
+)
+
+var _ simulator.Stage = (*RateLimiter)(nil)
+
+// RateLimiter keeps at most a fixed number of traces per interval, based on the start time of their root span.
+// Within an interval, the traces with the highest randomness are kept, so that the decision is equivalent to
+// consistent probability sampling with the threshold of the first dropped trace, which is recorded in the tracestate.
+// The traces with the same randomness as the first dropped trace are dropped too, which may keep fewer traces.
+type RateLimiter struct {
+	maxTraces int
+	interval  time.Duration
+}
+
+// NewRateLimiter creates a new RateLimiter keeping at most maxTraces traces per interval
+func NewRateLimiter(maxTraces int, interval time.Duration) (*RateLimiter, error) {
+	if maxTraces < 1 {
+		return nil, fmt.Errorf("maximum number of traces must be at least 1, got %d", maxTraces)
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("interval must be greater than 0, got %s", interval)
+	}
+	return &RateLimiter{maxTraces: maxTraces, interval: interval}, nil
+}
+
+// candidate is a trace competing for the capacity of an interval
+type candidate struct {
+	root       *span.TreeNode
+	threshold  uint64
+	randomness uint64
+}
+
+func (r *RateLimiter) Process(rootSpans []*span.TreeNode) ([]*span.TreeNode, error) {
+	windows := make(map[int64][]*candidate)
+	candidates := make([]*candidate, len(rootSpans))
+	for i, root := range rootSpans {
+		threshold, err := thresholdOf(root)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read threshold of trace %s: %w", root.TraceID(), err)
+		}
+		randomness, err := randomnessOf(root)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read randomness of trace %s: %w", root.TraceID(), err)
+		}
+		candidates[i] = &candidate{root: root, threshold: threshold, randomness: randomness}
+		window := root.StartTime().Truncate(r.interval).UnixNano()
+		windows[window] = append(windows[window], candidates[i])
+	}
+
+	kept := make(map[*candidate]struct{}, len(rootSpans))
+	for _, window := range windows {
+		sort.SliceStable(window, func(i, j int) bool {
+			return window[i].randomness > window[j].randomness
+		})
+		// the first dropped trace defines the threshold the kept traces were sampled with,
+		// which also rejects the traces tied with it, so that they are dropped as well
+		var threshold uint64
+		if len(window) > r.maxTraces {
+			cutoff := window[r.maxTraces].randomness
+			end := r.maxTraces
+			for end > 0 && window[end-1].randomness == cutoff {
+				end--
+			}
+			window = window[:end]
+			threshold = min(cutoff+1, maxThreshold-1)
+		}
+		for _, c := range window {
+			c.threshold = max(c.threshold, threshold)
+			kept[c] = struct{}{}
+		}
+	}
+
+	result := make([]*span.TreeNode, 0, len(kept))
+	for _, c := range candidates {
+		if _, ok := kept[c]; ok {
+			keep(c.root, &c.threshold)
+			result = append(result, c.root)
+		}
+	}
+	return result, nil
+}
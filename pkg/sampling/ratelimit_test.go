@@ -0,0 +1,76 @@
+package sampling
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/model/span"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Process(t *testing.T) {
+	limiter, err := NewRateLimiter(2, time.Second)
+	assert.NoError(t, err)
+	kept, err := limiter.Process([]*span.TreeNode{
+		newTrace(t, 0x10, 0, 100*time.Millisecond, false, nil),
+		newTrace(t, 0x90, 0, 100*time.Millisecond, false, nil),
+		newTrace(t, 0x40, 0, 100*time.Millisecond, false, nil),
+		newTrace(t, 0x20, 0, 100*time.Millisecond, false, nil),
+		newTrace(t, 0x05, 1500*time.Millisecond, 100*time.Millisecond, false, nil),
+	})
+	assert.NoError(t, err)
+
+	// the traces with the highest randomness are kept in each interval
+	assert.Len(t, kept, 3)
+	assert.Equal(t, byte(0x90), kept[0].TraceID().Bytes()[9])
+	assert.Equal(t, byte(0x40), kept[1].TraceID().Bytes()[9])
+	assert.Equal(t, byte(0x05), kept[2].TraceID().Bytes()[9])
+	// the first dropped trace has the randomness 0x20000000000000
+	assert.Equal(t, "ot=th:20000000000001", kept[0].TraceState())
+	assert.True(t, kept[0].TraceFlags().IsSampled())
+	assert.Equal(t, "ot=th:0", kept[2].TraceState())
+
+	t.Run("drop the traces tied with the first dropped trace", func(t *testing.T) {
+		kept, err := limiter.Process([]*span.TreeNode{
+			newTrace(t, 0x40, 0, 100*time.Millisecond, false, nil),
+			newTrace(t, 0x90, 0, 100*time.Millisecond, false, nil),
+			newTrace(t, 0x40, 0, 100*time.Millisecond, false, nil),
+			newTrace(t, 0x10, 0, 100*time.Millisecond, false, nil),
+		})
+		assert.NoError(t, err)
+
+		assert.Len(t, kept, 1)
+		assert.Equal(t, byte(0x90), kept[0].TraceID().Bytes()[9])
+		assert.Equal(t, "ot=th:40000000000001", kept[0].TraceState())
+	})
+}
+
+func TestNewRateLimiterError(t *testing.T) {
+	type testCase struct {
+		name          string
+		maxTraces     int
+		interval      time.Duration
+		expectedError string
+	}
+
+	testCases := []testCase{
+		{
+			name:          "return error without traces to keep",
+			maxTraces:     0,
+			interval:      time.Second,
+			expectedError: "maximum number of traces must be at least 1, got 0",
+		},
+		{
+			name:          "return error without interval",
+			maxTraces:     1,
+			interval:      0,
+			expectedError: "interval must be greater than 0, got 0s",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewRateLimiter(tc.maxTraces, tc.interval)
+			assert.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package sampling
+
+import (
+	"fmt"
+	simulator "github.com/k4ji/tracesimulator/pkg"
+	"github.com/k4ji/tracesimulator/pkg/model/span"
+)
+
+var _ simulator.Stage = (*TailSampler)(nil)
+
+// PolicyAttributeKey is the attribute set on the root span of the traces kept by the tail sampler, naming the deciding policy
+const PolicyAttributeKey = "sampling.policy"
+
+// fallbackPolicyName is recorded on the traces kept by the probabilistic fallback of the tail sampler
+const fallbackPolicyName = "probabilistic"
+
+// TailSampler decides on complete traces, keeping those matching any of its policies.
+// Other traces are kept with the fallback probability following the consistent probability sampling,
+// so that the backend can extrapolate them from the th value of their tracestate.
+// The traces kept by a policy keep the threshold they had, as all traces matching the policy are kept.
+type TailSampler struct {
+	policies []Policy
+	// fallback is the rejection threshold of the traces matching no policy, nil to drop them
+	fallback *uint64
+}
+
+// NewTailSampler creates a new TailSampler with the given policies and the probability of keeping the other traces,
+// which are all dropped when the probability is 0
+func NewTailSampler(policies []Policy, fallbackProbability float64) (*TailSampler, error) {
+	for _, p := range policies {
+		if p.Name == "" || p.Keep == nil {
+			return nil, fmt.Errorf("policy requires a name and a decision function")
+		}
+	}
+	sampler := &TailSampler{policies: policies}
+	if fallbackProbability != 0 {
+		threshold, err := ThresholdFromProbability(fallbackProbability)
+		if err != nil {
+			return nil, err
+		}
+		sampler.fallback = &threshold
+	}
+	return sampler, nil
+}
+
+func (t *TailSampler) Process(rootSpans []*span.TreeNode) ([]*span.TreeNode, error) {
+	kept := make([]*span.TreeNode, 0, len(rootSpans))
+	for _, root := range rootSpans {
+		policy := t.match(root)
+		if policy != "" {
+			root.SetAttribute(PolicyAttributeKey, policy)
+			keep(root, nil)
+			kept = append(kept, root)
+			continue
+		}
+		if t.fallback == nil {
+			continue
+		}
+		threshold, err := thresholdOf(root)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read threshold of trace %s: %w", root.TraceID(), err)
+		}
+		threshold = max(threshold, *t.fallback)
+		randomness, err := randomnessOf(root)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read randomness of trace %s: %w", root.TraceID(), err)
+		}
+		if randomness < threshold {
+			continue
+		}
+		root.SetAttribute(PolicyAttributeKey, fallbackPolicyName)
+		keep(root, &threshold)
+		kept = append(kept, root)
+	}
+	return kept, nil
+}
+
+// match returns the name of the first policy keeping the trace, empty when none does
+func (t *TailSampler) match(root *span.TreeNode) string {
+	for _, p := range t.policies {
+		if p.Keep(root) {
+			return p.Name
+		}
+	}
+	return ""
+}
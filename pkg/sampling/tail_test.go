@@ -0,0 +1,107 @@
+package sampling
+
+import (
+	"github.com/k4ji/tracesimulator/pkg/model/span"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTailSampler_Process(t *testing.T) {
+	type testCase struct {
+		name                string
+		fallbackProbability float64
+		// expectedPolicies and expectedTraceStates are those of the kept traces
+		expectedPolicies    []string
+		expectedTraceStates []string
+	}
+
+	testCases := []testCase{
+		{
+			name:                "keep the traces matching a policy",
+			fallbackProbability: 0,
+			expectedPolicies:    []string{"errors", "slow", "attribute:user.tier"},
+			expectedTraceStates: []string{"", "", ""},
+		},
+		{
+			name:                "keep other traces with the fallback probability",
+			fallbackProbability: 0.5,
+			expectedPolicies:    []string{"errors", "slow", "attribute:user.tier", "probabilistic"},
+			expectedTraceStates: []string{"", "", "", "ot=th:8"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sampler, err := NewTailSampler(newPolicies(), tc.fallbackProbability)
+			assert.NoError(t, err)
+			kept, err := sampler.Process(newTailTraces(t))
+			assert.NoError(t, err)
+
+			policies := make([]string, len(kept))
+			traceStates := make([]string, len(kept))
+			for i, k := range kept {
+				assert.True(t, k.TraceFlags().IsSampled())
+				policies[i] = k.Attributes()[PolicyAttributeKey]
+				traceStates[i] = k.TraceState()
+			}
+			assert.Equal(t, tc.expectedPolicies, policies)
+			assert.Equal(t, tc.expectedTraceStates, traceStates)
+		})
+	}
+
+	t.Run("keep the threshold of head sampled traces kept by a policy", func(t *testing.T) {
+		head, _ := NewHeadSampler(1)
+		sampled, err := head.Process(newTailTraces(t)[:1])
+		assert.NoError(t, err)
+		sampler, _ := NewTailSampler(newPolicies(), 0)
+		kept, err := sampler.Process(sampled)
+		assert.NoError(t, err)
+		assert.Equal(t, "ot=th:0", kept[0].TraceState())
+	})
+}
+
+func TestNewTailSamplerError(t *testing.T) {
+	type testCase struct {
+		name                string
+		policies            []Policy
+		fallbackProbability float64
+		expectedError       string
+	}
+
+	testCases := []testCase{
+		{
+			name:          "return error for an incomplete policy",
+			policies:      []Policy{{Name: "incomplete"}},
+			expectedError: "policy requires a name and a decision function",
+		},
+		{
+			name:                "return error for a fallback probability above 1",
+			policies:            newPolicies(),
+			fallbackProbability: 2,
+			expectedError:       "sampling probability must be in (0, 1]",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewTailSampler(tc.policies, tc.fallbackProbability)
+			assert.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
+
+// newTailTraces returns a failing, a slow, a premium and two ordinary traces, the last of which has a high randomness
+func newTailTraces(t *testing.T) []*span.TreeNode {
+	return []*span.TreeNode{
+		newTrace(t, 0x00, 0, 100*time.Millisecond, true, nil),
+		newTrace(t, 0x00, 0, 2*time.Second, false, nil),
+		newTrace(t, 0x00, 0, 100*time.Millisecond, false, map[string]string{"user.tier": "premium"}),
+		newTrace(t, 0x00, 0, 100*time.Millisecond, false, nil),
+		newTrace(t, 0xf0, 0, 100*time.Millisecond, false, nil),
+	}
+}
+
+func newPolicies() []Policy {
+	return []Policy{KeepErrors(), KeepSlow(time.Second), KeepAttribute("user.tier", "premium")}
+}
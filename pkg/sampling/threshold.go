@@ -0,0 +1,147 @@
+package sampling
+
+import (
+	"fmt"
+	"github.com/k4ji/tracesimulator/pkg/model/span"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// maxThreshold is the exclusive upper bound of the 56-bit rejection thresholds and randomness values
+const maxThreshold = uint64(1) << 56
+
+// otelTraceStateKey is the tracestate member holding the OpenTelemetry sampling values
+const otelTraceStateKey = "ot"
+
+// ThresholdFromProbability returns the rejection threshold of the OpenTelemetry consistent probability sampling.
+// A trace is kept when its 56-bit randomness value is greater than or equal to the threshold.
+func ThresholdFromProbability(probability float64) (uint64, error) {
+	if probability <= 0 || probability > 1 || math.IsNaN(probability) {
+		return 0, fmt.Errorf("sampling probability must be in (0, 1], got %f", probability)
+	}
+	// the probability is scaled by a power of two, which is exact unlike the complement 1 - probability
+	accepted := uint64(math.Round(probability * float64(maxThreshold)))
+	if accepted == 0 {
+		return 0, fmt.Errorf("sampling probability %g is too small", probability)
+	}
+	return maxThreshold - accepted, nil
+}
+
+// encodeThreshold encodes the threshold as the th value of the tracestate, with its trailing zeros removed
+func encodeThreshold(threshold uint64) string {
+	encoded := strings.TrimRight(fmt.Sprintf("%014x", threshold), "0")
+	if encoded == "" {
+		return "0"
+	}
+	return encoded
+}
+
+// decodeThreshold decodes the th value of the tracestate
+func decodeThreshold(encoded string) (uint64, error) {
+	if encoded == "" || len(encoded) > 14 {
+		return 0, fmt.Errorf("invalid threshold %q", encoded)
+	}
+	threshold, err := strconv.ParseUint(encoded+strings.Repeat("0", 14-len(encoded)), 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid threshold %q: %w", encoded, err)
+	}
+	return threshold, nil
+}
+
+// traceState is a parsed W3C tracestate with the OpenTelemetry member split into its sub-keys
+type traceState struct {
+	// ot holds the sub-keys of the OpenTelemetry member in order, such as "th:8"
+	ot []string
+	// others holds the other members in order
+	others []string
+}
+
+func parseTraceState(raw string) traceState {
+	var state traceState
+	for _, member := range strings.Split(raw, ",") {
+		member = strings.TrimSpace(member)
+		if member == "" {
+			continue
+		}
+		if value, found := strings.CutPrefix(member, otelTraceStateKey+"="); found {
+			for _, entry := range strings.Split(value, ";") {
+				if entry != "" {
+					state.ot = append(state.ot, entry)
+				}
+			}
+			continue
+		}
+		state.others = append(state.others, member)
+	}
+	return state
+}
+
+// value returns the value of a sub-key of the OpenTelemetry member
+func (s traceState) value(key string) (string, bool) {
+	for _, entry := range s.ot {
+		if value, found := strings.CutPrefix(entry, key+":"); found {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// with returns the state with the sub-key of the OpenTelemetry member set to the value
+func (s traceState) with(key string, value string) traceState {
+	ot := make([]string, 0, len(s.ot)+1)
+	for _, entry := range s.ot {
+		if !strings.HasPrefix(entry, key+":") {
+			ot = append(ot, entry)
+		}
+	}
+	// the threshold goes first as the other sub-keys may be added by later stages
+	ot = append([]string{key + ":" + value}, ot...)
+	return traceState{ot: ot, others: s.others}
+}
+
+// String encodes the state with the modified OpenTelemetry member first, as required by W3C trace context
+func (s traceState) String() string {
+	members := make([]string, 0, len(s.others)+1)
+	if len(s.ot) > 0 {
+		members = append(members, otelTraceStateKey+"="+strings.Join(s.ot, ";"))
+	}
+	members = append(members, s.others...)
+	return strings.Join(members, ",")
+}
+
+// thresholdOf returns the rejection threshold already applied to the trace, zero when it was not sampled before
+func thresholdOf(root *span.TreeNode) (uint64, error) {
+	encoded, ok := parseTraceState(root.TraceState()).value("th")
+	if !ok {
+		return 0, nil
+	}
+	return decodeThreshold(encoded)
+}
+
+// randomnessOf returns the 56-bit randomness value of the trace, taken from the rv sub-key of the tracestate
+// when present and from the rightmost 7 bytes of the trace ID otherwise
+func randomnessOf(root *span.TreeNode) (uint64, error) {
+	if encoded, ok := parseTraceState(root.TraceState()).value("rv"); ok {
+		if len(encoded) != 14 {
+			return 0, fmt.Errorf("invalid randomness value %q", encoded)
+		}
+		return strconv.ParseUint(encoded, 16, 64)
+	}
+	var randomness uint64
+	for _, b := range root.TraceID().Bytes()[9:] {
+		randomness = randomness<<8 | uint64(b)
+	}
+	return randomness, nil
+}
+
+// keep marks all spans of the trace as sampled, recording the threshold in their tracestate when given
+func keep(root *span.TreeNode, threshold *uint64) {
+	root.SetTraceFlags(root.TraceFlags().WithSampled(true))
+	if threshold != nil {
+		root.SetTraceState(parseTraceState(root.TraceState()).with("th", encodeThreshold(*threshold)).String())
+	}
+	for _, child := range root.Children() {
+		keep(child, threshold)
+	}
+}
@@ -0,0 +1,86 @@
+package sampling
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestThresholdFromProbability(t *testing.T) {
+	type testCase struct {
+		name        string
+		probability float64
+		expected    string
+	}
+
+	testCases := []testCase{
+		{name: "keep all traces", probability: 1, expected: "0"},
+		{name: "keep half of the traces", probability: 0.5, expected: "8"},
+		{name: "keep a quarter of the traces", probability: 0.25, expected: "c"},
+		{name: "round a tenth of the traces", probability: 0.1, expected: "e6666666666666"},
+		{name: "round a hundredth of the traces", probability: 0.01, expected: "fd70a3d70a3d71"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			threshold, err := ThresholdFromProbability(tc.probability)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, encodeThreshold(threshold))
+			decoded, err := decodeThreshold(tc.expected)
+			assert.NoError(t, err)
+			assert.Equal(t, threshold, decoded)
+		})
+	}
+}
+
+func TestThresholdFromProbabilityError(t *testing.T) {
+	type testCase struct {
+		name        string
+		probability float64
+	}
+
+	testCases := []testCase{
+		{name: "zero probability", probability: 0},
+		{name: "negative probability", probability: -0.5},
+		{name: "probability above 1", probability: 1.5},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ThresholdFromProbability(tc.probability)
+			assert.ErrorContains(t, err, "sampling probability must be in (0, 1]")
+		})
+	}
+}
+
+func TestTraceState(t *testing.T) {
+	type testCase struct {
+		name       string
+		traceState string
+		expected   string
+	}
+
+	testCases := []testCase{
+		{
+			name:       "add the threshold to an empty tracestate",
+			traceState: "",
+			expected:   "ot=th:8",
+		},
+		{
+			name:       "replace the threshold and move the member first",
+			traceState: "vendor=abc, ot=rv:0123456789abcd;th:c",
+			expected:   "ot=th:8;rv:0123456789abcd,vendor=abc",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, parseTraceState(tc.traceState).with("th", "8").String())
+		})
+	}
+
+	t.Run("read a value of the member", func(t *testing.T) {
+		value, ok := parseTraceState("vendor=abc, ot=rv:0123456789abcd;th:c").value("rv")
+		assert.True(t, ok)
+		assert.Equal(t, "0123456789abcd", value)
+	})
+}
@@ -1,10 +1,10 @@
 package simulator
 
 import (
-	"crypto/rand"
 	"fmt"
 	simulator "github.com/k4ji/tracesimulator/pkg/adapter"
 	"github.com/k4ji/tracesimulator/pkg/blueprint"
+	"github.com/k4ji/tracesimulator/pkg/idgen"
 	"github.com/k4ji/tracesimulator/pkg/model/span"
 	"github.com/k4ji/tracesimulator/pkg/model/task"
 	"time"
@@ -13,17 +13,82 @@ import (
 // Simulator is a struct that simulates traces based on a blueprint and export them to a specific format using an adapter.
 type Simulator[T any] struct {
 	adapter simulator.Adapter[T]
+	options options
+}
+
+// options holds the optional behaviors of a Simulator
+type options struct {
+	// validateOverrun makes Run fail when a child span starts before or ends after its parent
+	validateOverrun bool
+	// stages process the span trees in order before they are given to the adapter
+	stages []Stage
+	// idGenerator generates the trace and span IDs, random by default
+	idGenerator IDGenerator
+}
+
+// IDGenerator generates the trace and span IDs of the simulated spans.
+// Implementations for various ID formats are available in the idgen package.
+type IDGenerator interface {
+	NewTraceID() span.TraceID
+	NewSpanID() span.ID
+}
+
+// randomTraceIDGenerator is an IDGenerator whose trace IDs meet the randomness requirements of W3C trace context level 2,
+// in which case the random trace flag is set on the generated spans
+type randomTraceIDGenerator interface {
+	RandomTraceID() bool
+}
+
+// Stage processes the span trees between their construction and the adapter, such as a sampler dropping traces.
+// A stage receives the root spans of the traces and returns the root spans of the traces to keep.
+type Stage interface {
+	Process(rootSpans []*span.TreeNode) ([]*span.TreeNode, error)
+}
+
+// Option configures optional behaviors of a Simulator
+type Option func(*options)
+
+// WithOverrunValidation makes Run return an error when a child span starts before or ends after its parent.
+func WithOverrunValidation() Option {
+	return func(o *options) {
+		o.validateOverrun = true
+	}
+}
+
+// WithStages appends stages processing the span trees before they are given to the adapter, in the given order.
+func WithStages(stages ...Stage) Option {
+	return func(o *options) {
+		o.stages = append(o.stages, stages...)
+	}
+}
+
+// WithIDGenerator replaces the random generation of trace and span IDs.
+func WithIDGenerator(generator IDGenerator) Option {
+	return func(o *options) {
+		o.idGenerator = generator
+	}
 }
 
 // New creates a new Simulator instance with the provided adapter.
-func New[T any](adapter simulator.Adapter[T]) *Simulator[T] {
-	return &Simulator[T]{adapter: adapter}
+func New[T any](adapter simulator.Adapter[T], opts ...Option) *Simulator[T] {
+	s := &Simulator[T]{adapter: adapter, options: options{idGenerator: idgen.NewRandom()}}
+	for _, opt := range opts {
+		opt(&s.options)
+	}
+	return s
 }
 
 // Run executes the simulation by interpreting the blueprint, generating spans, and transforming them using the adapter.
-func (s *Simulator[T]) Run(blueprint blueprint.Blueprint, baseEndTime time.Time) (T, error) {
+// A blueprint.TimedBlueprint is interpreted at baseEndTime.
+func (s *Simulator[T]) Run(bp blueprint.Blueprint, baseEndTime time.Time) (T, error) {
 	var zero T
-	traceRootTaskNodes, err := blueprint.Interpret()
+	var traceRootTaskNodes []*task.TreeNode
+	var err error
+	if timed, ok := bp.(blueprint.TimedBlueprint); ok {
+		traceRootTaskNodes, err = timed.InterpretAt(baseEndTime)
+	} else {
+		traceRootTaskNodes, err = bp.Interpret()
+	}
 	if err != nil {
 		return zero, fmt.Errorf("failed to interpret blueprint: %w", err)
 	}
@@ -31,12 +96,18 @@ func (s *Simulator[T]) Run(blueprint blueprint.Blueprint, baseEndTime time.Time)
 	// Convert task trees to spans and hold mapping of ExternalID to span
 	rootSpans := make([]*span.TreeNode, 0, len(traceRootTaskNodes))
 	externalIDToSpan := make(map[task.ExternalID]*span.TreeNode)
+	idGenerator := s.options.idGenerator
+	random, ok := idGenerator.(randomTraceIDGenerator)
+	randomTraceID := ok && random.RandomTraceID()
 	for _, taskTree := range traceRootTaskNodes {
-		traceID := generateTraceID()
-		rootSpan, err := span.FromTaskTree(taskTree, traceID, baseEndTime, generateSpanID)
+		traceID := idGenerator.NewTraceID()
+		rootSpan, err := span.FromTaskTree(taskTree, traceID, baseEndTime, idGenerator.NewSpanID)
 		if err != nil {
 			return zero, fmt.Errorf("failed to construct span tree: %w", err)
 		}
+		if randomTraceID {
+			markRandomTraceID(rootSpan)
+		}
 		mp := rootSpan.ExternalIDToSpan()
 		for externalID, spanNode := range mp {
 			if _, exists := externalIDToSpan[externalID]; exists {
@@ -56,6 +127,19 @@ func (s *Simulator[T]) Run(blueprint blueprint.Blueprint, baseEndTime time.Time)
 		}
 	}
 
+	// Schedule the consumers of queues after the producers of their messages, possibly across traces
+	if err := span.ResolveMessaging(rootSpans); err != nil {
+		return zero, fmt.Errorf("failed to resolve messaging: %w", err)
+	}
+
+	if s.options.validateOverrun {
+		for _, rootSpan := range rootSpans {
+			if err := rootSpan.ValidateChildrenWithinParent(); err != nil {
+				return zero, fmt.Errorf("failed to validate span tree: %w", err)
+			}
+		}
+	}
+
 	// Shift timestamps to ensure all spans end before the current time
 	latestEndTime := baseEndTime
 	for _, rootSpan := range rootSpans {
@@ -66,6 +150,13 @@ func (s *Simulator[T]) Run(blueprint blueprint.Blueprint, baseEndTime time.Time)
 		rootSpan.ShiftTimestamps(adjustmentDuration)
 	}
 
+	for _, stage := range s.options.stages {
+		rootSpans, err = stage.Process(rootSpans)
+		if err != nil {
+			return zero, fmt.Errorf("failed to process spans: %w", err)
+		}
+	}
+
 	// Convert spans to the desired format using the adapter
 	transformed, err := s.adapter.Transform(rootSpans)
 	if err != nil {
@@ -85,14 +176,10 @@ func (s *Simulator[T]) findLatestEndTime(node *span.TreeNode, latestEndTime time
 	return latestEndTime
 }
 
-func generateTraceID() span.TraceID {
-	var id [16]byte
-	_, _ = rand.Read(id[:])
-	return span.NewTraceID(id)
-}
-
-func generateSpanID() span.ID {
-	var id [8]byte
-	_, _ = rand.Read(id[:])
-	return span.NewSpanID(id)
+// markRandomTraceID sets the random trace flag on the span and its descendants
+func markRandomTraceID(node *span.TreeNode) {
+	node.SetTraceFlags(node.TraceFlags() | span.TraceFlagsRandom)
+	for _, child := range node.Children() {
+		markRandomTraceID(child)
+	}
 }
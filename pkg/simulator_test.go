@@ -1,9 +1,11 @@
 package simulator
 
 import (
+	"fmt"
 	"github.com/k4ji/tracesimulator/pkg/adapter"
 	"github.com/k4ji/tracesimulator/pkg/blueprint/service"
 	"github.com/k4ji/tracesimulator/pkg/blueprint/service/model"
+	"github.com/k4ji/tracesimulator/pkg/idgen"
 	"github.com/k4ji/tracesimulator/pkg/model/span"
 	"github.com/k4ji/tracesimulator/pkg/model/task"
 	"github.com/k4ji/tracesimulator/pkg/model/task/taskduration"
@@ -465,6 +467,129 @@ func TestSimulator_Run(t *testing.T) {
 		assert.Errorf(t, err, "failed to interpret blueprint: duplicate ExternalID detected: {%s}", duplicateExternalID)
 	})
 
+	t.Run("fail when a child span overruns its parent with overrun validation", func(t *testing.T) {
+		overrunBlueprint := service.NewServiceBlueprint([]model.Service{
+			{
+				Name: "service-a",
+				Tasks: []model.Task{
+					{
+						Name:     "root-task",
+						Delay:    NewAbsoluteDurationDelay(0),
+						Duration: NewAbsoluteDurationDuration(100 * time.Millisecond),
+						Kind:     "server",
+						Children: []model.Task{
+							{
+								Name:     "child-task",
+								Delay:    NewAbsoluteDurationDelay(50 * time.Millisecond),
+								Duration: NewAbsoluteDurationDuration(100 * time.Millisecond),
+								Kind:     "internal",
+							},
+						},
+					},
+				},
+			},
+		})
+
+		_, err := New[[]string](&MockAdapter{}).Run(&overrunBlueprint, time.Now())
+		assert.NoError(t, err)
+		_, err = New[[]string](&MockAdapter{}, WithOverrunValidation()).Run(&overrunBlueprint, time.Now())
+		assert.Error(t, err)
+	})
+
+	t.Run("schedule consumers after the producers of other traces", func(t *testing.T) {
+		consumption, _ := task.NewConsumption("orders", NewAbsoluteDurationDelay(200*time.Millisecond), 1)
+		messagingBlueprint := service.NewServiceBlueprint([]model.Service{
+			{
+				Name: "checkout",
+				Tasks: []model.Task{
+					{
+						Name:     "place-order",
+						Kind:     "producer",
+						Delay:    NewAbsoluteDurationDelay(0),
+						Duration: NewAbsoluteDurationDuration(100 * time.Millisecond),
+						Publish:  "orders",
+					},
+				},
+			},
+			{
+				Name: "fulfillment",
+				Tasks: []model.Task{
+					{
+						Name:     "process-order",
+						Kind:     "consumer",
+						Delay:    NewAbsoluteDurationDelay(0),
+						Duration: NewAbsoluteDurationDuration(300 * time.Millisecond),
+						Consume:  consumption,
+					},
+				},
+			},
+		})
+		baseEndTime := time.Now()
+		spans, err := New[[]*span.TreeNode](&simulator.NoOpAdapter{}).Run(&messagingBlueprint, baseEndTime)
+		assert.NoError(t, err)
+		assert.Len(t, spans, 2)
+		producer, consumer := spans[0], spans[1]
+		assert.Equal(t, producer.EndTime().Add(200*time.Millisecond), consumer.StartTime())
+		assert.Equal(t, baseEndTime, consumer.EndTime())
+		assert.Equal(t, []*span.TreeNode{producer}, consumer.LinkedTo())
+	})
+
+	t.Run("process span trees with the stages in order", func(t *testing.T) {
+		var order []string
+		keepFirst := stageFunc(func(rootSpans []*span.TreeNode) ([]*span.TreeNode, error) {
+			order = append(order, "keep-first")
+			return rootSpans[:1], nil
+		})
+		count := stageFunc(func(rootSpans []*span.TreeNode) ([]*span.TreeNode, error) {
+			order = append(order, fmt.Sprintf("count-%d", len(rootSpans)))
+			return rootSpans, nil
+		})
+		transformed, err := New[[]string](&MockAdapter{}, WithStages(keepFirst, count)).Run(&blueprint, time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"root-task-a"}, transformed)
+		assert.Equal(t, []string{"keep-first", "count-1"}, order)
+	})
+
+	t.Run("return error if a stage fails", func(t *testing.T) {
+		failing := stageFunc(func(rootSpans []*span.TreeNode) ([]*span.TreeNode, error) {
+			return nil, fmt.Errorf("failed")
+		})
+		_, err := New[[]string](&MockAdapter{}, WithStages(failing)).Run(&blueprint, time.Now())
+		assert.Error(t, err)
+	})
+
+	t.Run("generate IDs with the given generator", func(t *testing.T) {
+		spans, err := New[[]*span.TreeNode](&simulator.NoOpAdapter{}, WithIDGenerator(idgen.NewSequential())).Run(&blueprint, time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, "00000000000000000000000000000001", spans[0].TraceID().String())
+		assert.Equal(t, "0000000000000001", spans[0].ID().String())
+		assert.False(t, spans[0].TraceFlags()&span.TraceFlagsRandom != 0)
+	})
+
+	t.Run("set the random trace flag for W3C random trace IDs", func(t *testing.T) {
+		spans, err := New[[]*span.TreeNode](&simulator.NoOpAdapter{}, WithIDGenerator(idgen.NewW3C())).Run(&blueprint, time.Now())
+		assert.NoError(t, err)
+		for _, root := range spans {
+			assert.Equal(t, span.TraceFlagsRandom, root.TraceFlags())
+			for _, child := range root.Children() {
+				assert.Equal(t, span.TraceFlagsRandom, child.TraceFlags())
+			}
+		}
+	})
+
+	t.Run("interpret timed blueprints at the base end time", func(t *testing.T) {
+		var interpretedAt time.Time
+		timed := timedBlueprintFunc(func(now time.Time) ([]*task.TreeNode, error) {
+			interpretedAt = now
+			return blueprint.Interpret()
+		})
+		baseEndTime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		transformed, err := New[[]string](&MockAdapter{}).Run(timed, baseEndTime)
+		assert.NoError(t, err)
+		assert.Len(t, transformed, 3)
+		assert.Equal(t, baseEndTime, interpretedAt)
+	})
+
 	t.Run("transform span trees to a different format using the adapter", func(t *testing.T) {
 		sim := New[[]string](&MockAdapter{})
 		transformed, err := sim.Run(&blueprint, time.Now())
@@ -476,6 +601,22 @@ func TestSimulator_Run(t *testing.T) {
 	})
 }
 
+type stageFunc func(rootSpans []*span.TreeNode) ([]*span.TreeNode, error)
+
+func (f stageFunc) Process(rootSpans []*span.TreeNode) ([]*span.TreeNode, error) {
+	return f(rootSpans)
+}
+
+type timedBlueprintFunc func(now time.Time) ([]*task.TreeNode, error)
+
+func (f timedBlueprintFunc) Interpret() ([]*task.TreeNode, error) {
+	return nil, fmt.Errorf("interpreted without time")
+}
+
+func (f timedBlueprintFunc) InterpretAt(now time.Time) ([]*task.TreeNode, error) {
+	return f(now)
+}
+
 func NewAbsoluteDurationDelay(duration time.Duration) task.Delay {
 	e, _ := taskduration.NewAbsoluteDuration(duration)
 	d, _ := task.NewDelay(e)